@@ -1,15 +1,13 @@
 package api
 
 import (
-	"bytes"
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"fineas/pkg/services"
 	"fmt"
-	"io"
 	"log"
-	"mime/multipart"
 	"net"
 	"net/http"
 	"net/url"
@@ -19,8 +17,6 @@ import (
 
 	"github.com/joho/godotenv"
 	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 // handles the ticker request
@@ -44,16 +40,8 @@ func HandleQuoteRequest(w http.ResponseWriter, r *http.Request) {
 		NewsSummary       string
 		CompanyDesc       string
 		TechnicalAnalysis string
-	}
-
-	// to represent data posted to the data ingestor
-	type PostDataInfo struct {
-		Ticker            string `json:"Ticker,omitempty"`
-		StockPerformance  string `json:"StockPerformance,omitempty"`
-		FinancialHealth   string `json:"FinancialHealth,omitempty"`
-		NewsSummary       string `json:"NewsSummary,omitempty"`
-		CompanyDesc       string `json:"CompanyDesc,omitempty"`
-		TechnicalAnalysis string `json:"TechnicalAnalysis,omitempty"`
+		PeerComparison    string `json:",omitempty"`
+		Valuation         string `json:",omitempty"`
 	}
 
 	// aggregate of all event sequences
@@ -96,7 +84,7 @@ func HandleQuoteRequest(w http.ResponseWriter, r *http.Request) {
 	// Get the financial information from the services
 	// and return it as the response
 
-	err := godotenv.Load("../../.env") // load the .env file
+	err := godotenv.Load(aggregatorEnvFile) // load the .env file
 	if err != nil {
 		log.Fatal("Error loading .env file")
 	}
@@ -108,26 +96,12 @@ func HandleQuoteRequest(w http.ResponseWriter, r *http.Request) {
 	eventSequenceArray = append(eventSequenceArray, "collected request ip \n")
 	aggLog.RequestIP = ip
 
-	STK_SERVICE_URL := os.Getenv("STK_SERVICE_URL")
-	FIN_SERVICE_URL := os.Getenv("FIN_SERVICE_URL")
-	NEWS_SERVICE_URL := os.Getenv("NEWS_SERVICE_URL")
-	DESC_SERVICE_URL := os.Getenv("DESC_SERVICE_URL")
-	TA_SERVICE_URL := os.Getenv("TA_SERVICE_URL")
-	STK_TEMPLATE := os.Getenv("STK_TEMPLATE")
-	FIN_TEMPLATE := os.Getenv("FIN_TEMPLATE")
-	NEWS_TEMPLATE := os.Getenv("NEWS_TEMPLATE")
-	DESC_TEMPLATE := os.Getenv("DESC_TEMPLATE")
-	TA_TEMPLATE := os.Getenv("TA_TEMPLATE")
 	PASS_KEY := os.Getenv("PASS_KEY")
 	KB_WRITE_KEY := os.Getenv("KB_WRITE_KEY")
 	MR_WRITE_KEY := os.Getenv("MR_WRITE_KEY")
 
-	// connnect to mongodb
-	MONGO_DB_LOGGER_PASSWORD := os.Getenv("MONGO_DB_LOGGER_PASSWORD")
-	serverAPI := options.ServerAPI(options.ServerAPIVersion1)
-	opts := options.Client().ApplyURI("mongodb+srv://kobenaidun:" + MONGO_DB_LOGGER_PASSWORD + "@cluster0.z9znpv9.mongodb.net/?retryWrites=true&w=majority").SetServerAPIOptions(serverAPI)
-	// Create a new client and connect to the server
-	client, err := mongo.Connect(context.TODO(), opts)
+	// connnect to the report store, mongodb outside of tests
+	store, err := openReportStore(context.TODO())
 	if err != nil {
 		log.Println("Couldn't connect to database")
 		eventSequenceArray = append(eventSequenceArray, "could not connect to database \n")
@@ -135,7 +109,7 @@ func HandleQuoteRequest(w http.ResponseWriter, r *http.Request) {
 		panic(err)
 	}
 	defer func() {
-		if err = client.Disconnect(context.TODO()); err != nil {
+		if err = store.Close(context.TODO()); err != nil {
 			log.Println("Database disconnected")
 			eventSequenceArray = append(eventSequenceArray, "could not connect to database \n")
 			w.Write([]byte("Error: Could not connect to database"))
@@ -152,100 +126,73 @@ func HandleQuoteRequest(w http.ResponseWriter, r *http.Request) {
 
 	fmt.Print("passHash: " + passHash + "\n")
 
-	currentYear := time.Now().Format("2006")
+	currentYear := aggregatorNow().Format("2006")
 	queriedInfoAggregate.Ticker = ticker
+
+	// crypto, index and ETF tickers get their own templates and annotation queries
+	sections := getReportSectionSet(ticker, currentYear)
+	eventSequenceArray = append(eventSequenceArray, "selected "+sections.AssetClass+" section set \n")
 	eventSequenceArray = append(eventSequenceArray, "queried ticker \n")
 
-	stk_info := getFinancialInfo(ticker, "/stk", STK_SERVICE_URL, passHash, writekey, eventSequenceArray)
-	queriedInfoAggregate.YtdInfo = stk_info
-	stk_annotation := postSearchQuery(ticker+" financial price information for "+currentYear, "/search", passHash)
+	// typed clients for the data, search and llm services
+	svc := newServiceClient(passHash)
+	ctx := r.Context()
+	tickerRequest := services.TickerRequest{Ticker: ticker, WriteKey: writekey}
+
+	stkInfo, stkErr := services.Get(ctx, svc, services.Stk, tickerRequest)
+	queriedInfoAggregate.YtdInfo = stkInfo.Result
+	stk_annotation := getSearchAnnotation(ctx, svc, sections.StkAnnotation, "stk")
 	eventSequenceArray = append(eventSequenceArray, "queried stk info \n")
 
-	fin_info := getFinancialInfo(ticker, "/fin", FIN_SERVICE_URL, passHash, writekey, eventSequenceArray)
-	queriedInfoAggregate.FinInfo = fin_info
-	fin_annotation := postSearchQuery(ticker+" financials and 10k filings for "+currentYear, "/search", passHash)
+	finInfo, finErr := services.Get(ctx, svc, services.Fin, tickerRequest)
+	queriedInfoAggregate.FinInfo = finInfo.Result
+	fin_annotation := getSearchAnnotation(ctx, svc, sections.FinAnnotation, "fin")
 	eventSequenceArray = append(eventSequenceArray, "queried fin info \n")
 
-	news_info := getFinancialInfo(ticker, "/news", NEWS_SERVICE_URL, passHash, writekey, eventSequenceArray)
-	queriedInfoAggregate.NewsInfo = news_info
+	newsInfo, newsErr := services.Get(ctx, svc, services.News, tickerRequest)
+	queriedInfoAggregate.NewsInfo = newsInfo.Result
 	eventSequenceArray = append(eventSequenceArray, "queried news info \n")
 
-	desc_info := getFinancialInfo(ticker, "/desc", DESC_SERVICE_URL, passHash, writekey, eventSequenceArray)
-	queriedInfoAggregate.DescInfo = desc_info
-	desc_annotation := postSearchQuery(ticker+" company description", "/search", passHash)
+	descInfo, descErr := services.Get(ctx, svc, services.Desc, tickerRequest)
+	// only the profile summary goes to the model, not the structured fields
+	queriedInfoAggregate.DescInfo = descInfo.Result
+	desc_annotation := getSearchAnnotation(ctx, svc, sections.DescAnnotation, "desc")
 	eventSequenceArray = append(eventSequenceArray, "queried desc info \n")
 
-	ta_info := getFinancialInfo(ticker, "/ta", TA_SERVICE_URL, passHash, writekey, eventSequenceArray)
-	queriedInfoAggregate.TaInfo = ta_info
+	taInfo, taErr := services.Get(ctx, svc, services.TA, tickerRequest)
+	queriedInfoAggregate.TaInfo = taInfo.Result
 	eventSequenceArray = append(eventSequenceArray, "queried ta info \n")
 
 	fmt.Println("TEST PRINTS")
-	fmt.Println("stk info " + stk_info)
-	fmt.Println("fin info " + fin_info)
-	fmt.Println("news info" + news_info)
-	fmt.Println("desc info" + desc_info)
-	fmt.Println("ta info" + ta_info)
+	fmt.Println("stk info " + queriedInfoAggregate.YtdInfo)
+	fmt.Println("fin info " + queriedInfoAggregate.FinInfo)
+	fmt.Println("news info" + queriedInfoAggregate.NewsInfo)
+	fmt.Println("desc info" + queriedInfoAggregate.DescInfo)
+	fmt.Println("ta info" + queriedInfoAggregate.TaInfo)
 
 	// stock perfomance
-	if stk_info != "400 Bad Request" && stk_info != "500 Internal Server Error" {
-		stkTemplate := STK_TEMPLATE
-		stkInference := getPromptInference("For ASSET_NAME: "+ticker+"\n"+string(queriedInfoAggregate.YtdInfo)+"\n"+string(stk_annotation), stkTemplate, "/llm", "http://0.0.0.0:5432", eventSequenceArray, passHash)
-		stkInference = strings.Trim(stkInference, "{}")
-		promptInference.StockPerformance = stkInference
-		eventSequenceArray = append(eventSequenceArray, "collected stk prompt inference \n")
-	} else {
-		//
-		promptInference.StockPerformance = ""
-		eventSequenceArray = append(eventSequenceArray, "stk prompt inference failed \n")
-	}
+	promptInference.StockPerformance, eventSequenceArray = getSectionInference(ctx, svc, "stk", stkErr, ticker, queriedInfoAggregate.YtdInfo+"\n"+stk_annotation, sections.StkTemplate, eventSequenceArray)
 	// financial health
-	if fin_info != "400 Bad Request" && fin_info != "500 Internal Server Error" {
-		finTemplate := FIN_TEMPLATE
-		finInference := getPromptInference("For ASSET_NAME: "+ticker+"\n"+string(queriedInfoAggregate.FinInfo)+"\n"+string(fin_annotation), finTemplate, "/llm", "http://0.0.0.0:5432", eventSequenceArray, passHash)
-		finInference = strings.Trim(finInference, "{}")
-		promptInference.FinancialHealth = finInference
-		eventSequenceArray = append(eventSequenceArray, "collected fin prompt inference \n")
-	} else {
-		//
-		promptInference.FinancialHealth = ""
-		eventSequenceArray = append(eventSequenceArray, "fin prompt inference failed \n")
-	}
+	promptInference.FinancialHealth, eventSequenceArray = getSectionInference(ctx, svc, "fin", finErr, ticker, queriedInfoAggregate.FinInfo+"\n"+fin_annotation, sections.FinTemplate, eventSequenceArray)
 	// news summary
-	if news_info != "400 Bad Request" && news_info != "500 Internal Server Error" {
-		newsTemplate := NEWS_TEMPLATE
-		newsInference := getPromptInference("For ASSET_NAME: "+ticker+"\n"+string(queriedInfoAggregate.NewsInfo), newsTemplate, "/llm", "http://0.0.0.0:5432", eventSequenceArray, passHash)
-		newsInference = strings.Trim(newsInference, "{}")
-		promptInference.NewsSummary = newsInference
-		eventSequenceArray = append(eventSequenceArray, "collected news prompt inference \n")
-	} else {
-		//
-		promptInference.NewsSummary = ""
-		eventSequenceArray = append(eventSequenceArray, "news prompt inference failed \n")
-	}
+	promptInference.NewsSummary, eventSequenceArray = getSectionInference(ctx, svc, "news", newsErr, ticker, queriedInfoAggregate.NewsInfo, sections.NewsTemplate, eventSequenceArray)
 	// company description
-	if desc_info != "400 Bad Request" && desc_info != "500 Internal Server Error" {
-		descTemplate := DESC_TEMPLATE
-		descInference := getPromptInference("For ASSET_NAME: "+ticker+"\n"+string(queriedInfoAggregate.DescInfo)+"\n"+string(desc_annotation), descTemplate, "/llm", "http://0.0.0.0:5432", eventSequenceArray, passHash)
-		descInference = strings.Trim(descInference, "{}")
-		promptInference.CompanyDesc = descInference
-		eventSequenceArray = append(eventSequenceArray, "collected desc prompt inference \n")
-	} else {
-		//
-		promptInference.CompanyDesc = ""
-		eventSequenceArray = append(eventSequenceArray, "desc prompt inference failed \n")
+	promptInference.CompanyDesc, eventSequenceArray = getSectionInference(ctx, svc, "desc", descErr, ticker, queriedInfoAggregate.DescInfo+"\n"+desc_annotation, sections.DescTemplate, eventSequenceArray)
+	// technical analysis
+	promptInference.TechnicalAnalysis, eventSequenceArray = getSectionInference(ctx, svc, "ta", taErr, ticker, queriedInfoAggregate.TaInfo, sections.TaTemplate, eventSequenceArray)
+
+	// peer comparison and valuation are optional sections for equities
+	if (queryParams.Get("peers") == "true" || os.Getenv("INCLUDE_PEERS_SECTION") == "true") && sections.PeersTemplate != "" {
+		peersInfo, peersErr := services.Get(ctx, svc, services.Peers, tickerRequest)
+		promptInference.PeerComparison, eventSequenceArray = getSectionInference(ctx, svc, "peers", peersErr, ticker, peersInfo.Result, sections.PeersTemplate, eventSequenceArray)
+		eventSequenceArray = append(eventSequenceArray, "queried peers info \n")
 	}
-	// company description
-	if ta_info != "400 Bad Request" && ta_info != "500 Internal Server Error" {
-		taTemplate := TA_TEMPLATE
-		taInference := getPromptInference("For ASSET_NAME: "+ticker+"\n"+string(queriedInfoAggregate.TaInfo), taTemplate, "/llm", "http://0.0.0.0:5432", eventSequenceArray, passHash)
-		taInference = strings.Trim(taInference, "{}")
-		promptInference.TechnicalAnalysis = taInference
-		eventSequenceArray = append(eventSequenceArray, "collected ta prompt inference \n")
-	} else {
-		//
-		promptInference.TechnicalAnalysis = ""
-		eventSequenceArray = append(eventSequenceArray, "ta prompt inference failed \n")
+	if (queryParams.Get("valuation") == "true" || os.Getenv("INCLUDE_VALUATION_SECTION") == "true") && sections.ValuationTemplate != "" {
+		valuationInfo, valuationErr := services.Get(ctx, svc, services.Valuation, tickerRequest)
+		promptInference.Valuation, eventSequenceArray = getSectionInference(ctx, svc, "valuation", valuationErr, ticker, valuationInfo.Result, sections.ValuationTemplate, eventSequenceArray)
+		eventSequenceArray = append(eventSequenceArray, "queried valuation info \n")
 	}
+
 	//constructs valid json string
 	stockperformace := strings.Replace(promptInference.StockPerformance, "{", "|", -1)
 	stockperformace = strings.Replace(stockperformace, "}", "|", -1)
@@ -258,37 +205,30 @@ func HandleQuoteRequest(w http.ResponseWriter, r *http.Request) {
 	technicalanalysis := strings.Replace(promptInference.TechnicalAnalysis, "{", "|", -1)
 	technicalanalysis = strings.Replace(technicalanalysis, "}", "|", -1)
 
-	// If writekey is valid, post the data to the data ingestor
+	// If writekey is valid, ingest the report into the knowledge base
 	fmt.Println("writekey: ", writekey)
 	fmt.Println("KB_WRITE_KEY: ", KB_WRITE_KEY)
 	fmt.Println("len(writekey): ", len(writekey))
 	if len(writekey) != 0 {
-		// "Ticker" is often redundant in your embeddings, but up to you
-		// Maybe you do want ticker in the index. We'll set it to empty for example.
-		postDataInfo := PostDataInfo{
-			StockPerformance:  promptInference.StockPerformance,
-			FinancialHealth:   promptInference.FinancialHealth,
-			NewsSummary:       promptInference.NewsSummary,
-			CompanyDesc:       promptInference.CompanyDesc,
-			TechnicalAnalysis: promptInference.TechnicalAnalysis,
-		}
-		fmt.Println("postDataInfo: ", postDataInfo)
-
-		// Marshal struct -> JSON
-		postJsonData, err := json.Marshal(postDataInfo)
+		// ingest the report sections into the chat knowledge base
+		ingestCtx, cancelIngest := context.WithTimeout(r.Context(), 2*time.Minute)
+		result, err := ingestReport(ingestCtx, ticker, sections, map[string]string{
+			"stk":       promptInference.StockPerformance,
+			"fin":       promptInference.FinancialHealth,
+			"news":      promptInference.NewsSummary,
+			"desc":      promptInference.CompanyDesc,
+			"ta":        promptInference.TechnicalAnalysis,
+			"peers":     promptInference.PeerComparison,
+			"valuation": promptInference.Valuation,
+		})
+		cancelIngest()
 		if err != nil {
-			fmt.Println("Error marshaling JSON:", err)
-			return
-		}
-
-		// Post data to ingestor (Python)
-		resPostFinancialData := postFinancialData(string(postJsonData), passHash)
-		if !strings.Contains(resPostFinancialData, "200") {
-			// Ingestor didn't respond with success
+			log.Println("Error ingesting report:", err)
 			eventSequenceArray = append(eventSequenceArray, "data ingestor post failed \n")
 			w.Write([]byte("Error: Data Ingestor Post Failed."))
 			return
 		}
+		eventSequenceArray = append(eventSequenceArray, "ingested "+fmt.Sprint(result.Chunks)+" report chunks \n")
 		log.Println("Ingestion Completed Successfully")
 	}
 
@@ -304,23 +244,6 @@ func HandleQuoteRequest(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if writekey == MR_WRITE_KEY && len(writekey) != 0 {
-		// Connect to the database and the collection
-		db := client.Database("FinancialInformation")
-		collection := db.Collection("TickersList")
-
-		// Check if ticker already exists in the collection
-		filter := bson.M{"Ticker": ticker}
-		existingDoc := collection.FindOne(context.TODO(), filter)
-
-		// If the document exists, delete it
-		if existingDoc.Err() == nil {
-			_, err = collection.DeleteOne(context.TODO(), filter)
-			if err != nil {
-				log.Printf("Error deleting existing document: %v", err)
-				return // Or handle the error as per your application logic
-			}
-		}
-
 		// Convert JSON to BSON format for MongoDB insertion
 		var bsonDoc bson.M
 		err = bson.UnmarshalExtJSON(promptInferenceJson, true, &bsonDoc)
@@ -329,10 +252,10 @@ func HandleQuoteRequest(w http.ResponseWriter, r *http.Request) {
 			return // Or handle the error as per your application logic
 		}
 
-		// Insert the new document into MongoDB
-		_, err = collection.InsertOne(context.TODO(), bsonDoc)
+		// Replace the ticker's previous report with the new document
+		err = store.ReplaceReport(context.TODO(), ticker, bsonDoc)
 		if err != nil {
-			log.Printf("Error inserting new document: %v", err)
+			log.Printf("Error replacing existing document: %v", err)
 			return // Or handle the error as per your application logic
 		}
 	}
@@ -341,214 +264,13 @@ func HandleQuoteRequest(w http.ResponseWriter, r *http.Request) {
 	aggLog.Timestamp = time.Now()
 	eventSequenceArray = append(eventSequenceArray, "sent prompt inference response \n")
 	aggLog.EventSequence = eventSequenceArray
-	db := client.Database("MicroserviceLogs")
-	collection := db.Collection("AggregatorServiceLogs")
-	_, err = collection.InsertOne(context.TODO(), aggLog)
+	err = store.InsertLog(context.TODO(), aggLog)
 	if err != nil {
 		log.Fatal(err)
 	}
 
 }
 
-// gets the financial information from the polygon.io services
-func getFinancialInfo(ticker string, handlerID string, handlerURL string, passHash string, writekey string, eventSequenceArray []string) string {
-
-	// Create a new HTTP client
-	client := &http.Client{}
-
-	// Construct the URL with query parameters
-	base_url := handlerURL + handlerID
-	url := base_url + "?" + "ticker=" + ticker + "&writekey=" + writekey
-
-	// Create a GET request
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		//Write the error to the client
-		//write to event sequence
-		eventSequenceArray = append(eventSequenceArray, "could not create request \n")
-		http.Error(nil, err.Error(), http.StatusBadRequest)
-		return "400 Bad Request"
-	}
-	// Set the Authorization header with the Bearer token
-	req.Header.Set("Authorization", "Bearer "+passHash)
-
-	// Send the request
-	resp, err := client.Do(req)
-	if err != nil {
-		panic(err)
-	}
-	defer resp.Body.Close()
-
-	// Read the response body as a string
-	responseBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		//write to event sequence
-		eventSequenceArray = append(eventSequenceArray, "could not read response body \n")
-		http.Error(nil, err.Error(), http.StatusInternalServerError)
-		return "500 Internal Server Error"
-	}
-
-	return string(responseBody)
-
-}
-
-// gets the prompt inference from the LLM service
-func getPromptInference(prompt string, template string, handlerID string, handlerURL string, eventSequenceArray []string, passHash string) string {
-
-	// Create an HTTP client
-	client := &http.Client{}
-
-	// Construct the full URL for the handler
-	baseUrl := handlerURL + handlerID
-
-	// Construct the full prompt by combining the template and user prompt
-	fullPrompt := template + prompt
-
-	// Create the payload to be sent as JSON
-	payload := map[string]string{
-		"prompt": fullPrompt,
-	}
-
-	// Encode the payload as JSON
-	jsonPayload, err := json.Marshal(payload)
-	if err != nil {
-		eventSequenceArray = append(eventSequenceArray, "could not marshal JSON payload \n")
-		return "400 Bad Request"
-	}
-
-	// Create a POST request with the JSON payload
-	req, err := http.NewRequest("POST", baseUrl, bytes.NewBuffer(jsonPayload))
-	if err != nil {
-		eventSequenceArray = append(eventSequenceArray, "could not create request \n")
-		return "400 Bad Request"
-	}
-
-	// Set the necessary headers
-	req.Header.Set("Authorization", "Bearer "+passHash)
-	req.Header.Set("Content-Type", "application/json")
-
-	// Send the request
-	resp, err := client.Do(req)
-	if err != nil {
-		eventSequenceArray = append(eventSequenceArray, "could not send request \n")
-		return "400 Bad Request"
-	}
-	defer resp.Body.Close()
-
-	// Check if the response status is not 200 OK
-	if resp.StatusCode != http.StatusOK {
-		eventSequenceArray = append(eventSequenceArray, fmt.Sprintf("received non-OK response: %d\n", resp.StatusCode))
-		return fmt.Sprintf("%d %s", resp.StatusCode, http.StatusText(resp.StatusCode))
-	}
-
-	// Read the response body as a string
-	responseBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		eventSequenceArray = append(eventSequenceArray, "could not read response body \n")
-		return "500 Internal Server Error"
-	}
-
-	// Return the response body as string
-	return string(responseBody)
-}
-
-// Posts financial data to data ingestor service
-func postFinancialData(dataValue string, passHash string) string {
-	// Endpoint and bearer token
-	url := "http://0.0.0.0:6001/ingestor"
-	bearerToken := passHash
-
-	// Create a buffer to hold our multipart form data
-	var b bytes.Buffer
-	w := multipart.NewWriter(&b)
-
-	// Create a form field called "info" and write our JSON into it
-	formField, err := w.CreateFormField("info")
-	if err != nil {
-		fmt.Println("Error creating form field:", err)
-		return ""
-	}
-	// dataValue is the JSON string, e.g. {"key1":"value1","key2":"value2"}
-	_, err = formField.Write([]byte(dataValue))
-	if err != nil {
-		fmt.Println("Error writing to form field:", err)
-		return ""
-	}
-
-	// Close the multipart writer to finalize the form data
-	err = w.Close()
-	if err != nil {
-		fmt.Println("Error closing multipart writer:", err)
-		return ""
-	}
-
-	// Create a new POST request with our multipart form
-	req, err := http.NewRequest("POST", url, &b)
-	if err != nil {
-		fmt.Println("Error creating request:", err)
-		return ""
-	}
-
-	// Set the correct Content-Type for multipart data
-	req.Header.Set("Content-Type", w.FormDataContentType())
-
-	// Set the Authorization header
-	req.Header.Set("Authorization", "Bearer "+bearerToken)
-
-	// Create an HTTP client and execute the request
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		fmt.Println("Error sending request:", err)
-		return ""
-	}
-	defer resp.Body.Close()
-
-	// Read the response
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		fmt.Println("Error reading response:", err)
-		return ""
-	}
-
-	// Log and return the body for debugging
-	fmt.Println("Response:", string(respBody))
-	return string(respBody)
-}
-
-func postSearchQuery(searchquery string, handlerURL string, passHash string) string {
-	// Create payload as bytes
-	payload := []byte(fmt.Sprintf("query=%s", searchquery))
-	// Create HTTP client
-	client := &http.Client{}
-	//
-	fullURL := "http://0.0.0.0:8070" + handlerURL
-	// Create POST request
-	req, err := http.NewRequest("POST", fullURL, bytes.NewBuffer(payload))
-	if err != nil {
-		fmt.Println("Error creating request:", err)
-		return ""
-	}
-	// Set Authorization header
-	req.Header.Set("Authorization", "Bearer "+passHash)
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	// Send the request
-	resp, err := client.Do(req)
-	if err != nil {
-		fmt.Println("Error sending request:", err)
-		return ""
-	}
-	defer resp.Body.Close()
-	// Read response
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		fmt.Println("Error reading response:", err)
-		return ""
-	}
-	fmt.Println("Response:", string(respBody))
-	return string(respBody)
-}
-
 // converts prompt to a URL compatible format
 func urlConverter(_url string) string {
 
@@ -0,0 +1,305 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fineas/pkg/services"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// captures what the aggregator stores instead of writing to Mongo
+type fakeReportStore struct {
+	mu      sync.Mutex
+	reports []bson.M
+	logs    []interface{}
+}
+
+func (s *fakeReportStore) ReplaceReport(ctx context.Context, ticker string, report bson.M) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reports = append(s.reports, report)
+	return nil
+}
+
+func (s *fakeReportStore) InsertLog(ctx context.Context, entry interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.logs = append(s.logs, entry)
+	return nil
+}
+
+func (s *fakeReportStore) Close(ctx context.Context) error { return nil }
+
+// fake data providers, search and LLM behind one server. Requests and
+// prompts are recorded in order, paths in fail answer 500
+type fakeServices struct {
+	mu       sync.Mutex
+	requests []string
+	prompts  []string
+	fail     map[string]bool
+}
+
+func (f *fakeServices) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	request := r.Method + " " + r.URL.Path
+	if r.URL.RawQuery != "" {
+		request += "?" + r.URL.Query().Encode()
+	}
+	if r.Method == "POST" && r.URL.Path != "/llm" {
+		request += " " + string(body)
+	}
+	f.requests = append(f.requests, request)
+	if f.fail[r.URL.Path] {
+		http.Error(w, "provider unavailable", http.StatusInternalServerError)
+		return
+	}
+
+	ticker := r.URL.Query().Get("ticker")
+	var resp interface{}
+	switch r.URL.Path {
+	case "/stk", "/fin", "/ta":
+		resp = services.ResultResponse{Result: fmt.Sprintf("%s data for %s: close $101.25, revenue $12.5 billion.", strings.TrimPrefix(r.URL.Path, "/"), ticker)}
+	case "/news":
+		resp = services.NewsResponse{Result: "Headlines for " + ticker + ": record quarter (sentiment 0.62)", Page: 1, PageSize: 10, LookbackDays: 7}
+	case "/desc":
+		resp = services.DescResponse{Result: ticker + " makes widgets. CEO: Jane Doe."}
+	case "/peers":
+		resp = services.PeersResponse{Result: ticker + " P/E 28.1, peer median 24.3"}
+	case "/valuation":
+		resp = services.ValuationResponse{Result: ticker + " DCF fair value $118.40"}
+	case "/search":
+		var req services.SearchRequest
+		json.Unmarshal(body, &req)
+		resp = services.SearchResponse{Results: []services.SearchResult{{
+			Title:   "Result for " + req.Section,
+			URL:     "https://example.com/" + req.Section,
+			Snippet: "Snippet about " + req.Query,
+		}}, Page: 1, PageSize: 1, Provider: "fake"}
+	case "/llm":
+		var req services.LLMRequest
+		json.Unmarshal(body, &req)
+		f.prompts = append(f.prompts, req.Prompt)
+		fmt.Fprintf(w, "{Section %d written from the data.}", len(f.prompts))
+		return
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// points every service, template and store of the aggregator at fixtures so
+// only the code under test decides the prompts and documents
+func setupAggregatorFixtures(t *testing.T, serverURL string) {
+	dir := t.TempDir()
+	envFile := filepath.Join(dir, ".env")
+	if err := os.WriteFile(envFile, []byte("# golden test\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	previousEnvFile := aggregatorEnvFile
+	aggregatorEnvFile = envFile
+	t.Cleanup(func() { aggregatorEnvFile = previousEnvFile })
+
+	env := map[string]string{
+		"PASS_KEY":     "golden-pass-key",
+		"MR_WRITE_KEY": "mr-key",
+		"KB_WRITE_KEY": "kb-key",
+
+		"STK_TEMPLATE":  "Write the stock performance section. ",
+		"FIN_TEMPLATE":  "Write the financial health section. ",
+		"NEWS_TEMPLATE": "Write the news summary section. ",
+		"DESC_TEMPLATE": "Write the company description section. ",
+		"TA_TEMPLATE":   "Write the technical analysis section. ",
+
+		"TEMPLATE_VERSION":          "",
+		"INCLUDE_PEERS_SECTION":     "",
+		"INCLUDE_VALUATION_SECTION": "",
+		"PEERS_TEMPLATE":            "",
+		"VALUATION_TEMPLATE":        "",
+		"CRYPTO_FIN_TEMPLATE":       "",
+		"CRYPTO_DESC_TEMPLATE":      "",
+		"INDEX_FIN_TEMPLATE":        "",
+		"INDEX_DESC_TEMPLATE":       "",
+
+		"VECTOR_STORE":           "local",
+		"VECTOR_STORE_PATH":      filepath.Join(dir, "vectors.json"),
+		"VECTOR_STORE_INDEX":     "flat",
+		"EMBEDDING_PROVIDER":     "hashing",
+		"EMBEDDING_DIMENSION":    "",
+		"EMBEDDING_CACHE_DIR":    "",
+		"INGEST_CHUNK_SENTENCES": "",
+		"INGEST_CHUNK_OVERLAP":   "",
+	}
+	for _, key := range []string{"STK", "FIN", "NEWS", "DESC", "TA", "PEERS", "VALUATION", "SEARCH"} {
+		env[key+"_SERVICE_URL"] = serverURL
+	}
+	env["REPORT_LLM_SERVICE_URL"] = serverURL
+	for key, value := range env {
+		t.Setenv(key, value)
+	}
+
+	previousOpen := openReportStore
+	t.Cleanup(func() { openReportStore = previousOpen })
+
+	previousNow := aggregatorNow
+	aggregatorNow = func() time.Time { return time.Date(2024, 5, 3, 14, 30, 0, 0, time.UTC) }
+	t.Cleanup(func() { aggregatorNow = previousNow })
+}
+
+// the chunks the ingestor wrote for a ticker, without their ids and vectors
+func storedChunks(t *testing.T, ticker string) []map[string]interface{} {
+	data, err := os.ReadFile(os.Getenv("VECTOR_STORE_PATH"))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stored struct {
+		Vectors []struct {
+			Metadata map[string]interface{} `json:"metadata"`
+		} `json:"vectors"`
+	}
+	if err := json.Unmarshal(data, &stored); err != nil {
+		t.Fatal(err)
+	}
+	var chunks []map[string]interface{}
+	for _, v := range stored.Vectors {
+		if v.Metadata["ticker"] == ticker {
+			chunks = append(chunks, v.Metadata)
+		}
+	}
+	sort.Slice(chunks, func(i, j int) bool {
+		a, b := fmt.Sprint(chunks[i]["section"], chunks[i]["chunk"]), fmt.Sprint(chunks[j]["section"], chunks[j]["chunk"])
+		return a < b
+	})
+	return chunks
+}
+
+func indentJSON(t *testing.T, v interface{}) string {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// compares got with the golden file, or rewrites it with -update
+func checkGolden(t *testing.T, name string, got string) {
+	path := filepath.Join("testdata", "aggregator", name+".golden")
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v, run go test ./api -run TestAggregatorGolden -update to create it", err)
+	}
+	if got == string(want) {
+		return
+	}
+	gotLines, wantLines := strings.Split(got, "\n"), strings.Split(string(want), "\n")
+	for i := 0; i < len(gotLines) || i < len(wantLines); i++ {
+		var g, w string
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if g != w {
+			t.Fatalf("%s differs from line %d:\n- %s\n+ %s\nrun go test ./api -run TestAggregatorGolden -update if the change is intended", path, i+1, w, g)
+		}
+	}
+}
+
+func TestAggregatorGolden(t *testing.T) {
+	cases := []struct {
+		name   string
+		query  string
+		fail   []string
+		status int
+	}{
+		{name: "equity_report", query: "ticker=AAPL&writekey=mr-key&peers=true&valuation=true", status: http.StatusOK},
+		{name: "knowledge_base_only", query: "ticker=MSFT&writekey=kb-key", status: http.StatusOK},
+		{name: "crypto_without_writekey", query: "ticker=X:BTCUSD", status: http.StatusOK},
+		{name: "fin_provider_down", query: "ticker=NVDA&writekey=mr-key", fail: []string{"/fin"}, status: http.StatusOK},
+	}
+
+	fake := &fakeServices{}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	setupAggregatorFixtures(t, server.URL)
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			fake.mu.Lock()
+			fake.requests, fake.prompts = nil, nil
+			fake.fail = make(map[string]bool)
+			for _, path := range c.fail {
+				fake.fail[path] = true
+			}
+			fake.mu.Unlock()
+			store := &fakeReportStore{}
+			openReportStore = func(ctx context.Context) (reportStore, error) { return store, nil }
+
+			req := httptest.NewRequest("GET", "/?"+c.query, nil)
+			recorder := httptest.NewRecorder()
+			HandleQuoteRequest(recorder, req)
+			if recorder.Code != c.status {
+				t.Errorf("got status %d, want %d", recorder.Code, c.status)
+			}
+
+			var b strings.Builder
+			fmt.Fprintf(&b, "# GET /?%s\n\n## service requests\n", c.query)
+			for _, request := range fake.requests {
+				b.WriteString(request + "\n")
+			}
+			for i, prompt := range fake.prompts {
+				fmt.Fprintf(&b, "\n## prompt %d\n%s\n", i+1, prompt)
+			}
+			b.WriteString("\n## stored reports\n")
+			for _, report := range store.reports {
+				b.WriteString(indentJSON(t, report) + "\n")
+			}
+			b.WriteString("\n## knowledge base chunks\n")
+			ticker := strings.SplitN(strings.TrimPrefix(c.query, "ticker="), "&", 2)[0]
+			for _, chunk := range storedChunks(t, ticker) {
+				b.WriteString(indentJSON(t, chunk) + "\n")
+			}
+			b.WriteString("\n## request logs\n")
+			for _, entry := range store.logs {
+				var fields map[string]interface{}
+				json.Unmarshal([]byte(indentJSON(t, entry)), &fields)
+				delete(fields, "Timestamp")
+				delete(fields, "ExecutionTimeMs")
+				b.WriteString(indentJSON(t, fields) + "\n")
+			}
+
+			checkGolden(t, c.name, b.String())
+		})
+	}
+}
@@ -1,27 +1,30 @@
 package api
 
 import (
-	"bytes"
 	"context"
-	"crypto/sha256"
-	"encoding/hex"
 	"encoding/json"
+	"fineas/pkg/chat"
+	"fineas/pkg/citation"
+	"fineas/pkg/entity"
+	"fineas/pkg/registry"
+	"fineas/pkg/services"
+	"fineas/pkg/vectorstore"
 	"fmt"
-	"io/ioutil"
 	"log"
 	"net/http"
 	"os"
-	"strconv"
-	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/pinecone-io/go-pinecone/pinecone"
-	"google.golang.org/protobuf/types/known/structpb"
 )
 
 type PromptPayload struct {
 	Prompt string `json:"prompt"`
+	// SessionID continues a conversation created with POST /chat/sessions
+	SessionID string `json:"session_id,omitempty"`
+	// IncludeSources returns every source given to the model, not only the
+	// cited ones
+	IncludeSources bool `json:"include_sources,omitempty"`
 }
 
 func prettifyStruct(obj interface{}) string {
@@ -29,50 +32,35 @@ func prettifyStruct(obj interface{}) string {
 	return string(bytes)
 }
 
-func min(a, b string) string {
-	if a < b {
-		return a
-	}
-	return b
-}
-
-func max(a, b string) string {
-	if a > b {
-		return a
-	}
-	return b
-}
-
 func ChatbotQuery() http.Handler {
 
 	router := gin.Default()
 	router.Use(corsMiddleware())
 
 	PASS_KEY := os.Getenv("PASS_KEY")
-	PINECONE_API_KEY := os.Getenv("PINECONE_API_KEY")
-	PINECONE_HOST := os.Getenv("PINECONE_HOST")
 
-	// Initialize Pinecone client
-	ClientParams := pinecone.NewClientParams{
-		ApiKey: PINECONE_API_KEY,
-		Host:   PINECONE_HOST,
+	// Vector store holding the ingested report chunks. Without it, or when
+	// its dimension does not match the embedder, only keyword retrieval runs
+	store, err := getVectorStore()
+	if err != nil {
+		log.Println("Failed to initialize vector store:", err)
+	} else if e, err := getEmbedder(); err != nil {
+		log.Println("Failed to initialize embedder:", err)
+	} else if err := checkEmbeddingDimension(context.Background(), e, store); err != nil {
+		log.Println("Disabling dense retrieval:", err)
+		store = nil
 	}
 
-	// Initialize Pinecone index
-	indexParams := pinecone.NewIndexConnParams{
-		Host: PINECONE_HOST,
+	// tracked tickers and company names recognized in prompts scope retrieval
+	var extractor *entity.Extractor
+	if reg, err := registry.Load(getEnvDefault("TICKERS_LIST_PATH", registry.DefaultPath)); err != nil {
+		log.Println("Failed to load tickers list, retrieval is not scoped by ticker:", err)
+	} else {
+		extractor = entity.New(reg.Tickers)
 	}
 
-	pineconeClient, err := pinecone.NewClient(ClientParams)
-	if err != nil {
-		log.Println("Failed to initialize Pinecone client:", err)
-		http.Error(nil, "Failed to initialize Pinecone client", http.StatusInternalServerError)
-	}
-	index, err := pineconeClient.Index(indexParams)
-	if err != nil {
-		log.Println("Failed to initialize Pinecone index:", err)
-		http.Error(nil, "Failed to initialize Pinecone index", http.StatusInternalServerError)
-	}
+	// conversation sessions
+	chatSessionRoutes(router, PASS_KEY)
 
 	router.POST("/chat", func(c *gin.Context) {
 
@@ -85,84 +73,57 @@ func ChatbotQuery() http.Handler {
 
 		log.Println("Received prompt:", jsonData.Prompt)
 
-		passhash := c.GetHeader("Authorization")[7:]
-		sha256Hash := sha256.Sum256([]byte(PASS_KEY))
-		HASH_KEY := hex.EncodeToString(sha256Hash[:])
-
-		if passhash != HASH_KEY {
-			log.Println("Unauthorized access attempt with passhash:", passhash)
-			c.String(http.StatusUnauthorized, "Unauthorized access")
-			return
-		}
-
-		// Embedding the user's prompt
-		queryVector, err := embedQuery(jsonData.Prompt, PINECONE_API_KEY)
-		if err != nil {
-			log.Println("Failed to embed query:", err)
-			c.String(http.StatusInternalServerError, "Internal Server Error, Generating Embeddings")
+		HASH_KEY, ok := chatAuthorized(c, PASS_KEY)
+		if !ok {
 			return
 		}
 
-		log.Println("Query vector:", queryVector)
-
-		LLM_SERVICE_URL := "http://0.0.0.0:8090"
-		url := LLM_SERVICE_URL + "/llm"
-		headers := map[string]string{
-			"Authorization": "Bearer " + HASH_KEY,
-			"Content-Type":  "application/json",
-		}
-
-		promptPayload := PromptPayload{
-			Prompt: jsonData.Prompt,
-		}
-
-		promptPayload.Prompt = fmt.Sprintf(`
-			For the following prompt, please only give two comma separated dates in YYYY-MM-DD format using no
-			spaces which refer to the context of the following prompt. If a date cannot
-			 be extrapolated then just use the current date. Do not respond with anything else other than the two dates in the format YYYY-MM-DD,YYYY-MM-DD.
-			Prompt: %s
-		 `, promptPayload.Prompt)
-
-		chatResponse, err := fetchChatResponse(url, headers, promptPayload)
-		if err != nil {
-			log.Println("Failed to fetch response from LLM service:", err)
-			c.String(http.StatusInternalServerError, "Internal Server Error Fetching Response")
-			return
-		}
+		// typed clients for the llm and search services and the data services
+		// behind the agent tools
+		svc := services.New(HASH_KEY, map[services.Service]string{
+			services.ServiceLLM:    os.Getenv("LLM_SERVICE_URL"),
+			services.ServiceSearch: os.Getenv("SEARCH_SERVICE_URL"),
+			services.ServiceFin:    os.Getenv("FIN_SERVICE_URL"),
+			services.ServiceNews:   os.Getenv("NEWS_SERVICE_URL"),
+			services.ServiceTA:     os.Getenv("TA_SERVICE_URL"),
+		})
 
-		//this will be a comma separated string of two dates in YYYY-MM-DD format
-		dates := strings.Split(chatResponse, ",")
-		if len(dates) != 2 {
-			log.Printf("Failed to extract dates from LLM response: %v", chatResponse)
-			c.String(http.StatusInternalServerError, "Internal Server Error Extracting Dates")
-			return
+		// In a session a follow-up is rewritten into a standalone question,
+		// which is what gets retrieved
+		question := jsonData.Prompt
+		var sessions chat.Repository
+		var session *chat.Session
+		conversation := newChatConversation(svc)
+		if jsonData.SessionID != "" {
+			if sessions, session, ok = loadChatSession(c, jsonData.SessionID); !ok {
+				return
+			}
+			rewritten, err := conversation.Rewrite(c.Request.Context(), session, jsonData.Prompt)
+			if err != nil {
+				log.Println("Failed to rewrite follow-up question:", err)
+			}
+			question = rewritten
+			log.Println("Standalone question:", question)
+			c.Header("X-Session-Id", session.ID)
 		}
 
-		date1 := dates[0]
-		date2 := dates[1]
-
-		// Calculate minimum and maximum dates
-		minDate := min(date1, date2)
-		maxDate := max(date1, date2)
-
-		minDate = strings.ReplaceAll(minDate, "-", "")
-		maxDate = strings.ReplaceAll(maxDate, "-", "")
-
-		minDateInt, err := strconv.Atoi(minDate)
-		if err != nil {
-			log.Printf("Failed to convert minDate to integer: %v", err)
+		// Embedding the user's prompt for dense retrieval, without it only
+		// keyword retrieval runs
+		var queryVector []float32
+		if store != nil {
+			queryVector, err = embedQuery(c.Request.Context(), question)
+			if err != nil {
+				log.Println("Failed to embed query, continuing with keyword retrieval:", err)
+			}
+			log.Println("Query vector:", queryVector)
 		}
-		log.Println("Min date integer:", minDateInt)
-		log.Println("Max date integer plus 1:", minDateInt+1)
 
-		maxDateInt, err := strconv.Atoi(maxDate)
-		if err != nil {
-			log.Printf("Failed to convert maxDate to integer: %v", err)
-		}
-		log.Println("Max date integer:", maxDateInt)
-		log.Println("Max date integer plus 1:", maxDateInt+1)
+		// Date range the prompt is about, matched against the YYYYMMDD current_date of each chunk
+		dates := chatDateRange(c.Request.Context(), svc, question)
+		minDateInt, maxDateInt := dates.Keys()
+		log.Println("Date range:", minDateInt, maxDateInt)
 
-		// Define the metadata filter for Pinecone
+		// Define the metadata filter for the vector store
 		metadataMap := map[string]interface{}{
 			"current_date": map[string]interface{}{
 				"$gte": minDateInt,
@@ -171,74 +132,48 @@ func ChatbotQuery() http.Handler {
 		}
 
 		log.Println("Metadata map:", metadataMap)
+		metadataFilter := vectorstore.Filter(metadataMap)
 
-		// Convert the metadataMap to a struct for Pinecone
-		metadataFilter, err := structpb.NewStruct(metadataMap)
-		if err != nil {
-			log.Println("Failed to create metadata map:", err)
-			c.String(http.StatusBadRequest, "Internal server error, Metadata Map")
-			return
-		}
+		// the keyword retriever uses the same date range as the vector filter
+		fromDate, toDate := dates.From, dates.End()
 
-		ctx := context.Background()
-		// Perform a similarity search in Pinecone
-		searchLimit := uint32(2) // Number of similar documents to retrieve
-		searchRes, err := index.QueryByVectorValues(ctx, &pinecone.QueryByVectorValuesRequest{
-			Vector:         queryVector,
-			TopK:           searchLimit,
-			MetadataFilter: metadataFilter,
-		})
+		// the companies the prompt asks about, each gets its own retrieval
+		tickers := extractor.Tickers(question)
+		log.Println("Tickers in prompt:", tickers)
+
+		// Hybrid dense and keyword retrieval, fused and optionally reranked
+		candidates, err := retrieveChatContext(c.Request.Context(), svc, store, question, queryVector, metadataFilter, fromDate, toDate, tickers)
 		if err != nil {
-			log.Println("Failed to perform similarity search:", err)
+			log.Println("Failed to retrieve context:", err)
 			c.String(http.StatusInternalServerError, "Internal Server Error Similarity Search")
 			return
 		}
 
-		log.Println("Search results:", prettifyStruct(searchRes))
-
-		// Once we have vector IDs, we can fetch their metadata to get the text
-		var contextData []interface{}
-		if len(searchRes.Matches) > 0 {
-			vectorIds := make([]string, len(searchRes.Matches))
-			for i, match := range searchRes.Matches {
-				vectorIds[i] = match.Vector.Id
-			}
-			fetchRes, err := index.FetchVectors(ctx, vectorIds)
-			if err != nil {
-				log.Println("Failed to fetch vectors:", err)
-				c.String(http.StatusInternalServerError, "Internal Server Error Fetching Vectors")
-				return
-			}
-
-			// Log the fetched vectors to debug
-			log.Println("Fetched vectors:", prettifyStruct(fetchRes))
-
-			// Extract the metadata (which should contain your text)
-			for _, vector := range fetchRes.Vectors {
-				if vector.Metadata != nil {
-					log.Println("Vector metadata:", vector.Metadata)
-					contextData = append(contextData, vector.Metadata)
-				} else {
-					log.Println("No metadata found for vector ID:", vector.Id)
-				}
-			}
+		for _, candidate := range candidates {
+			log.Printf("Retrieved %s from %v with score %.4f", candidate.ID, candidate.Retrievers, candidate.Score)
 		}
 
-		contextString := prettifyStruct(contextData)
-		log.Println("Context string:", contextString)
-
-		searchInformation, err := getSearchQuery(jsonData.Prompt, HASH_KEY)
+		searchResults, err := getSearchQuery(c.Request.Context(), svc, question)
 		if err != nil {
 			log.Println("Failed to fetch search information:", err)
 			c.String(http.StatusInternalServerError, "Internal Server Error Search Info")
 			return
 		}
 
-		log.Println("Search information:", searchInformation)
+		// retrieved chunks and search results numbered for the model to cite
+		sources := chatSources(candidates, searchResults)
+		sourcesString := citation.Prompt(sources, parseIntParam(os.Getenv("CHAT_SOURCE_CHARS"), 4000, 200, 100000))
+		log.Println("Sources:", sourcesString)
 
 		currentDate := time.Now().Format("01-02-2006")
 		log.Println("Current date:", currentDate)
 
+		// earlier turns of the session, the summary and the recent messages
+		history := "None"
+		if session != nil && len(session.Messages) > 0 {
+			history = conversation.History(session)
+		}
+
 		promptfield := fmt.Sprintf(`
 
 		You are an AI assistant named Fineas AI tasked with giving stock market
@@ -246,40 +181,62 @@ func ChatbotQuery() http.Handler {
 		When displaying numbers, show two decimal places. Your response will answer the following prompt using structured
 		informative headers, short paragraph segments, annotations, and bullet points for the given financial data. If relevant
 		to the prompt, include general company information such as background history, founder history, current leadership, product history,
-		business segments and their revenue contributions, and anything else pertinent like M&A transactions. You will also attach annotation
-		url and title information only defined within the annotations section of this prompt throughout response segments in the text. Please include as
-		many relevant url link annotations as possible. Please make sure to include the annotation url and title in the response if it is available.
-		You will structure the annotation in the response by ciiting the source title in brackets and having the url next to it with no spaces.
-		Example: [Source Title]https://www.google.com. If this information is not available, please omit it from the response and do not reference it at all.
-		However, if the annotation is available, please always include it in the response.
+		business segments and their revenue contributions, and anything else pertinent like M&A transactions. Only use the
+		information of the SOURCES section, if it does not answer the prompt say so.
+		%s
 
 			CURRENT DATE:
 			%s
 			
-			PROMPT:
+			CONVERSATION SO FAR:
 			%s
 
-			ANNOTATIONS:
+			PROMPT:
 			%s
 
-			CONTEXT:
-			%s`, currentDate, jsonData.Prompt, searchInformation, contextString)
-
-		promptPayload = PromptPayload{
-			Prompt: promptfield,
-		}
+			SOURCES:
+			%s`, citation.Instructions, currentDate, history, question, sourcesString)
 
-		log.Println("Prompt payload:", promptPayload)
+		log.Println("Prompt:", promptfield)
 
-		chatResponse, err = fetchChatResponse(url, headers, promptPayload)
+		// the model may call tools for live data before answering, their
+		// outputs are numbered after the retrieved sources
+		run, err := newChatAgent(svc).Run(c.Request.Context(), promptfield, sources)
 		if err != nil {
 			log.Println("Failed to fetch response from LLM service:", err)
 			c.String(http.StatusInternalServerError, "Failed to fetch response from LLM service")
 			return
 		}
 
-		log.Println("Chat response:", chatResponse)
-		c.String(http.StatusOK, chatResponse)
+		log.Println("Chat response:", run.Answer)
+
+		// URLs outside the sources are dropped and every [n] marker is linked
+		// to the claim it supports
+		result := citation.Resolve(run.Answer, run.Sources)
+		if len(result.RejectedURLs) > 0 {
+			log.Println("Removed URLs not in the sources:", result.RejectedURLs)
+		}
+		answer := chatAnswer{Result: result, ToolCalls: run.Calls}
+		if jsonData.IncludeSources {
+			answer.Sources = run.Sources
+		}
+
+		if session != nil {
+			now := time.Now().UTC()
+			turn := chat.Message{Role: chat.User, Content: jsonData.Prompt, Time: now}
+			if question != jsonData.Prompt {
+				turn.Standalone = question
+			}
+			session.Append(turn, chat.Message{Role: chat.Assistant, Content: result.Answer, Time: now})
+			if err := conversation.Summarize(c.Request.Context(), session); err != nil {
+				log.Println("Failed to summarize chat session:", err)
+			}
+			if err := sessions.Save(c.Request.Context(), session); err != nil {
+				log.Println("Failed to save chat session:", err)
+			}
+			answer.SessionID = session.ID
+		}
+		c.JSON(http.StatusOK, answer)
 	})
 
 	return router
@@ -290,7 +247,8 @@ func corsMiddleware() gin.HandlerFunc {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
 		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, DELETE, OPTIONS")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "X-Session-Id")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
@@ -301,107 +259,22 @@ func corsMiddleware() gin.HandlerFunc {
 	}
 }
 
-func embedQuery(prompt, apiKey string) ([]float32, error) {
-	ctx := context.Background()
-
-	// Create a new Pinecone client
-	pc, err := pinecone.NewClient(pinecone.NewClientParams{
-		ApiKey: apiKey,
-	})
-	if err != nil {
-		log.Printf("Failed to create Pinecone client: %v", err)
-		return nil, fmt.Errorf("failed to create Pinecone client: %v", err)
-	}
-
-	embeddingModel := "multilingual-e5-large"
-	queryParameters := pinecone.EmbedParameters{
-		InputType: "query",
-		Truncate:  "END",
-	}
-
-	// Embed the query using Pinecone's inference API
-	queryEmbeddingsResponse, err := pc.Inference.Embed(ctx, &pinecone.EmbedRequest{
-		Model:      embeddingModel,
-		TextInputs: []string{prompt},
-		Parameters: queryParameters,
-	})
-	if err != nil {
-		log.Printf("Failed to embed query: %v", err)
-		return nil, fmt.Errorf("failed to embed query: %v", err)
-	}
-
-	// Assuming the response contains embeddings in a similar structure
-	if len(*queryEmbeddingsResponse.Data) == 0 {
-		log.Println("No embedding data found in response")
-		return nil, fmt.Errorf("no embedding data found")
-	}
-
-	// Log and return the embedding data
-	log.Println("Embedding data:", (*queryEmbeddingsResponse.Data)[0].Values)
-	return *(*queryEmbeddingsResponse.Data)[0].Values, nil
-}
-
-func getSearchQuery(rawData, passhash string) (string, error) {
-	query := rawData
-
-	type SearchQuery struct {
-		Query string `json:"query"`
-	}
-
-	searchQuery := SearchQuery{Query: query}
-	jsonData, err := json.Marshal(searchQuery)
-	if err != nil {
-		return "", fmt.Errorf("failed to marshal data: %v", err)
-	}
-
-	req, err := http.NewRequest("POST", "http://localhost:8070/search", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return "", fmt.Errorf("failed to create request: %v", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+passhash)
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("failed to send request: %v", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := ioutil.ReadAll(resp.Body)
+func getSearchQuery(ctx context.Context, svc *services.Client, rawData string) ([]services.SearchResult, error) {
+	resp, err := services.Post(ctx, svc, services.Search, services.SearchRequest{Query: rawData})
 	if err != nil {
-		return "", fmt.Errorf("failed to read response body: %v", err)
+		return nil, fmt.Errorf("failed to fetch search results: %v", err)
 	}
 
-	log.Printf("Search service response code: %d, body: %s", resp.StatusCode, string(body))
-	return string(body), nil
+	log.Printf("Search service returned %d results from %s (cached: %t)", len(resp.Results), resp.Provider, resp.Cached)
+	return resp.Results, nil
 }
 
-func fetchChatResponse(url string, headers map[string]string, payload PromptPayload) (string, error) {
-	jsonData, _ := json.Marshal(payload)
-
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return "", err
-	}
-
-	for key, value := range headers {
-		req.Header.Set(key, value)
-	}
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
-
-	body, err := ioutil.ReadAll(resp.Body)
+func fetchChatResponse(ctx context.Context, svc *services.Client, payload PromptPayload) (string, error) {
+	resp, err := services.Post(ctx, svc, services.LLM, services.LLMRequest{Prompt: payload.Prompt})
 	if err != nil {
 		return "Error fetching response from LLM service", err
 	}
 
-	log.Println(string(body))
-	return string(body), nil
+	log.Println(string(resp))
+	return string(resp), nil
 }
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"fineas/pkg/agent"
+	"fineas/pkg/citation"
+	"fineas/pkg/retrieval"
+	"fineas/pkg/services"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// response of /chat, the answer with the sources its [n] markers cite
+type chatAnswer struct {
+	citation.Result
+	// ToolCalls are the agent tool calls made for the answer
+	ToolCalls []agent.Call `json:"tool_calls,omitempty"`
+	// Sources are all numbered sources, returned with include_sources
+	Sources   []citation.Source `json:"sources,omitempty"`
+	SessionID string            `json:"session_id,omitempty"`
+}
+
+// numbers the retrieved chunks and then the search results as the sources a
+// chat answer may cite. Snippets are cut to CHAT_SNIPPET_CHARS characters
+func chatSources(candidates []retrieval.Candidate, results []services.SearchResult) []citation.Source {
+	snippetChars := parseIntParam(os.Getenv("CHAT_SNIPPET_CHARS"), 280, 40, 4000)
+	sources := make([]citation.Source, 0, len(candidates)+len(results))
+	for _, candidate := range candidates {
+		source := citation.Source{
+			ChunkID: candidate.ID,
+			Text:    candidate.Text,
+			Snippet: snippet(candidate.Text, snippetChars),
+			Title:   metadataString(candidate.Metadata, "title"),
+			URL:     metadataString(candidate.Metadata, "url"),
+			Ticker:  metadataString(candidate.Metadata, "ticker"),
+			Date:    metadataString(candidate.Metadata, "current_date"),
+		}
+		if source.Title == "" {
+			source.Title = strings.TrimSpace(source.Ticker + " " + metadataString(candidate.Metadata, "section"))
+		}
+		sources = append(sources, source)
+	}
+	for _, result := range results {
+		sources = append(sources, citation.Source{
+			Title:   result.Title,
+			URL:     result.URL,
+			Snippet: snippet(result.Snippet, snippetChars),
+			Date:    result.Date,
+			Text:    result.Snippet,
+		})
+	}
+	return sources
+}
+
+// reads a metadata field as a string, numeric dates are stored as YYYYMMDD
+func metadataString(metadata map[string]interface{}, key string) string {
+	switch value := metadata[key].(type) {
+	case string:
+		return value
+	case float64:
+		return fmt.Sprintf("%.0f", value)
+	case int, int32, int64:
+		return fmt.Sprintf("%d", value)
+	}
+	return ""
+}
+
+// cuts text to at most n bytes on a word boundary
+func snippet(text string, n int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if len(text) <= n {
+		return text
+	}
+	cut := text[:n]
+	if i := strings.LastIndex(cut, " "); i > n/2 {
+		cut = cut[:i]
+	}
+	return cut + "..."
+}
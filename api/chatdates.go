@@ -0,0 +1,55 @@
+package api
+
+import (
+	"context"
+	"fineas/pkg/daterange"
+	"fineas/pkg/services"
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+// reads the dates a chat prompt is about. The prompt is parsed locally and
+// only sent to the llm service when it talks about a time the parser does not
+// understand. Prompts without any date look back CHAT_DEFAULT_LOOKBACK_DAYS
+// days. Fiscal periods start in CHAT_FISCAL_YEAR_START_MONTH
+func chatDateRange(ctx context.Context, svc *services.Client, prompt string) daterange.Range {
+	parser := daterange.Parser{
+		Now:             time.Now(),
+		FiscalYearStart: time.Month(parseIntParam(os.Getenv("CHAT_FISCAL_YEAR_START_MONTH"), 1, 1, 12)),
+	}
+	if dates, ok := parser.Parse(prompt); ok {
+		log.Printf("Date range %q parsed from prompt", dates.Matched)
+		return dates
+	}
+	if daterange.MentionsTime(prompt) {
+		dates, err := askDateRange(ctx, svc, prompt, parser.Now)
+		if err == nil {
+			return dates
+		}
+		log.Println("Failed to extract dates with the llm service:", err)
+	}
+	today := time.Date(parser.Now.Year(), parser.Now.Month(), parser.Now.Day(), 0, 0, 0, 0, parser.Now.Location())
+	days := parseIntParam(os.Getenv("CHAT_DEFAULT_LOOKBACK_DAYS"), 7, 1, 3650)
+	return daterange.Range{From: today.AddDate(0, 0, -days+1), To: today}
+}
+
+// asks the llm service for the dates of a prompt the parser could not read
+func askDateRange(ctx context.Context, svc *services.Client, prompt string, now time.Time) (daterange.Range, error) {
+	question := fmt.Sprintf(`
+		Today is %s. For the following prompt, please only give two comma separated dates in YYYY-MM-DD format using no
+		spaces which refer to the context of the following prompt. If a date cannot
+		 be extrapolated then just use the current date. Do not respond with anything else other than the two dates in the format YYYY-MM-DD,YYYY-MM-DD.
+		Prompt: %s
+	 `, now.Format("2006-01-02"), prompt)
+	answer, err := fetchChatResponse(ctx, svc, PromptPayload{Prompt: question})
+	if err != nil {
+		return daterange.Range{}, err
+	}
+	dates, ok := daterange.ParseDates(answer, now.Location())
+	if !ok {
+		return daterange.Range{}, fmt.Errorf("no dates in %q", answer)
+	}
+	return dates, nil
+}
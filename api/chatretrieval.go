@@ -0,0 +1,209 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fineas/pkg/fulltext"
+	"fineas/pkg/retrieval"
+	"fineas/pkg/services"
+	"fineas/pkg/vectorstore"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// reads a float setting, falling back when it is unset or malformed
+func getEnvFloat(key string, fallback float64) float64 {
+	value, err := strconv.ParseFloat(os.Getenv(key), 64)
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+// reads the chat retrieval settings. CHAT_TOP_K chunks reach the prompt out of
+// CHAT_CANDIDATES_K per retriever, CHAT_MIN_DENSE_SCORE and
+// CHAT_MIN_KEYWORD_SCORE drop weak matches before fusion and
+// CHAT_MIN_RERANK_SCORE drops weak matches after reranking
+func chatRetrievalOptions() retrieval.Options {
+	opts := retrieval.DefaultOptions()
+	opts.TopK = parseIntParam(os.Getenv("CHAT_TOP_K"), opts.TopK, 1, 50)
+	opts.CandidatesK = parseIntParam(os.Getenv("CHAT_CANDIDATES_K"), 2*opts.TopK, opts.TopK, 200)
+	opts.RRFK = getEnvFloat("CHAT_RRF_K", opts.RRFK)
+	opts.MinRetrieverScores = map[string]float64{
+		"dense":   getEnvFloat("CHAT_MIN_DENSE_SCORE", 0),
+		"keyword": getEnvFloat("CHAT_MIN_KEYWORD_SCORE", 0),
+	}
+	opts.MinRerankScore = getEnvFloat("CHAT_MIN_RERANK_SCORE", 0)
+	return opts
+}
+
+// builds the optional reranking stage. CHAT_RERANKER is "cross-encoder" for
+// the Pinecone hosted CHAT_RERANK_MODEL, "llm" to grade with the llm service,
+// anything else disables reranking
+func newChatReranker(svc *services.Client) retrieval.Reranker {
+	switch os.Getenv("CHAT_RERANKER") {
+	case "cross-encoder":
+		return retrieval.NewCrossEncoder(os.Getenv("PINECONE_API_KEY"), os.Getenv("CHAT_RERANK_MODEL"))
+	case "llm":
+		return &retrieval.LLMReranker{Complete: func(ctx context.Context, prompt string) (string, error) {
+			return fetchChatResponse(ctx, svc, PromptPayload{Prompt: prompt})
+		}}
+	}
+	return nil
+}
+
+// dense retrieval over the vector store with the prompt embedding, the
+// matches carry their metadata so they need no second fetch
+func denseRetriever(store vectorstore.Store, queryVector []float32, filter vectorstore.Filter) retrieval.Retriever {
+	return retrieval.RetrieverFunc{Label: "dense", Func: func(ctx context.Context, query string, limit int) ([]retrieval.Candidate, error) {
+		if store == nil {
+			return nil, errors.New("vector store is not configured")
+		}
+		matches, err := store.Query(ctx, vectorstore.QueryRequest{Vector: queryVector, TopK: limit, Filter: filter})
+		if err != nil {
+			return nil, err
+		}
+		candidates := make([]retrieval.Candidate, 0, len(matches))
+		for _, match := range matches {
+			candidate := retrieval.Candidate{ID: match.ID, Score: match.Score, Metadata: match.Metadata}
+			candidate.Text, _ = match.Metadata["text"].(string)
+			if candidate.Text == "" {
+				candidate.Text = prettifyStruct(match.Metadata)
+			}
+			candidates = append(candidates, candidate)
+		}
+		return candidates, nil
+	}}
+}
+
+// keyword retrieval over the internal BM25 index of reports, raw service
+// outputs and news, within the same dates and tickers as the dense filter
+func keywordRetriever(from time.Time, to time.Time, tickers []string) retrieval.Retriever {
+	return retrieval.RetrieverFunc{Label: "keyword", Func: func(ctx context.Context, query string, limit int) ([]retrieval.Candidate, error) {
+		index, _, err := getInternalIndex(ctx)
+		if err != nil {
+			return nil, err
+		}
+		results := index.Search(fulltext.Query{Text: query, Tickers: tickers, From: from, To: to, Limit: limit})
+		candidates := make([]retrieval.Candidate, 0, len(results.Hits))
+		for _, hit := range results.Hits {
+			metadata := map[string]interface{}{
+				"text":    hit.Text,
+				"ticker":  hit.Ticker,
+				"section": hit.Section,
+				"source":  hit.Source,
+			}
+			if hit.Title != "" {
+				metadata["title"] = hit.Title
+			}
+			if hit.URL != "" {
+				metadata["url"] = hit.URL
+			}
+			if !hit.Date.IsZero() {
+				metadata["current_date"] = hit.Date.Format("20060102")
+			}
+			candidates = append(candidates, retrieval.Candidate{ID: hit.ID, Text: hit.Text, Metadata: metadata, Score: hit.Score})
+		}
+		return candidates, nil
+	}}
+}
+
+// restricts a dense filter to the chunks of one ticker. Chunks ingested
+// before they were tagged with a ticker still match unless
+// CHAT_STRICT_TICKER_FILTER is "true"
+func tickerFilter(filter vectorstore.Filter, ticker string) vectorstore.Filter {
+	scope := vectorstore.Filter{"ticker": ticker}
+	if os.Getenv("CHAT_STRICT_TICKER_FILTER") != "true" {
+		scope = vectorstore.Filter{"$or": []vectorstore.Filter{
+			{"ticker": ticker},
+			{"ticker": map[string]interface{}{"$exists": false}},
+		}}
+	}
+	if len(filter) == 0 {
+		return scope
+	}
+	return vectorstore.Filter{"$and": []vectorstore.Filter{filter, scope}}
+}
+
+// retrieves the chat context with dense and keyword retrieval fused by
+// reciprocal rank, reranked when a reranker is configured. With tickers the
+// retrieval is scoped to them, one retrieval per ticker merged in turn so a
+// comparison gets context for every company
+func retrieveChatContext(ctx context.Context, svc *services.Client, store vectorstore.Store, prompt string, queryVector []float32, filter vectorstore.Filter, from time.Time, to time.Time, tickers []string) ([]retrieval.Candidate, error) {
+	opts := chatRetrievalOptions()
+	if len(tickers) == 0 {
+		return retrieveHybrid(ctx, svc, prompt, opts, chatRetrievers(store, queryVector, filter, from, to, nil)...)
+	}
+	if limit := parseIntParam(os.Getenv("CHAT_MAX_TICKERS"), 4, 1, 20); len(tickers) > limit {
+		tickers = tickers[:limit]
+	}
+	// split the context budget between the tickers
+	opts.TopK = (opts.TopK + len(tickers) - 1) / len(tickers)
+	if opts.TopK < 2 {
+		opts.TopK = 2
+	}
+
+	lists := make([][]retrieval.Candidate, len(tickers))
+	errs := make([]error, len(tickers))
+	var wg sync.WaitGroup
+	for i, ticker := range tickers {
+		wg.Add(1)
+		go func(i int, ticker string) {
+			defer wg.Done()
+			lists[i], errs[i] = retrieveHybrid(ctx, svc, prompt, opts,
+				chatRetrievers(store, queryVector, tickerFilter(filter, ticker), from, to, []string{ticker})...)
+		}(i, ticker)
+	}
+	wg.Wait()
+
+	failed := 0
+	for i, err := range errs {
+		if err != nil {
+			log.Printf("Retrieval for %s failed: %v", tickers[i], err)
+			failed++
+		}
+	}
+	if failed == len(tickers) {
+		return nil, errors.Join(errs...)
+	}
+	return retrieval.Interleave(0, lists...), nil
+}
+
+// the retrievers named in CHAT_RETRIEVERS, "dense,keyword" by default. Dense
+// retrieval is left out without a vector store or query embedding
+func chatRetrievers(store vectorstore.Store, queryVector []float32, filter vectorstore.Filter, from time.Time, to time.Time, tickers []string) []retrieval.Retriever {
+	var retrievers []retrieval.Retriever
+	for _, name := range splitList(getEnvDefault("CHAT_RETRIEVERS", "dense,keyword")) {
+		switch name {
+		case "dense":
+			if store == nil || queryVector == nil {
+				continue
+			}
+			retrievers = append(retrievers, denseRetriever(store, queryVector, filter))
+		case "keyword":
+			retrievers = append(retrievers, keywordRetriever(from, to, tickers))
+		default:
+			log.Println("Unknown chat retriever:", name)
+		}
+	}
+	return retrievers
+}
+
+// runs one hybrid retrieval, a failing retriever is logged and skipped
+func retrieveHybrid(ctx context.Context, svc *services.Client, prompt string, opts retrieval.Options, retrievers ...retrieval.Retriever) ([]retrieval.Candidate, error) {
+	hybrid := &retrieval.Hybrid{
+		Retrievers: retrievers,
+		Reranker:   newChatReranker(svc),
+		Options:    opts,
+	}
+	candidates, err := hybrid.Retrieve(ctx, prompt)
+	var partial *retrieval.PartialError
+	if errors.As(err, &partial) {
+		log.Println("Retriever failed, continuing with the others:", err)
+		return candidates, nil
+	}
+	return candidates, err
+}
@@ -0,0 +1,182 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fineas/pkg/chat"
+	"fineas/pkg/services"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	chatSessions     chat.Repository
+	chatSessionsErr  error
+	chatSessionsOnce sync.Once
+)
+
+// returns the chat session repository. CHAT_SESSION_STORE is "mongo" for the
+// FinancialInformation.ChatSessions collection or "memory" to keep sessions
+// in process
+func getChatSessions() (chat.Repository, error) {
+	chatSessionsOnce.Do(func() {
+		if getEnvDefault("CHAT_SESSION_STORE", "mongo") == "memory" {
+			chatSessions = chat.NewMemory()
+			return
+		}
+		client, err := connectMongo(context.Background())
+		if err != nil {
+			chatSessionsErr = err
+			return
+		}
+		chatSessions = chat.NewMongo(client.Database("FinancialInformation").Collection("ChatSessions"))
+	})
+	return chatSessions, chatSessionsErr
+}
+
+// builds the history manager of chat sessions. The last CHAT_HISTORY_WINDOW
+// messages are sent verbatim and older ones summarized in at most
+// CHAT_SUMMARY_MAX_CHARS characters
+func newChatConversation(svc *services.Client) *chat.Conversation {
+	return &chat.Conversation{
+		Complete: func(ctx context.Context, prompt string) (string, error) {
+			return fetchChatResponse(ctx, svc, PromptPayload{Prompt: prompt})
+		},
+		Window:          parseIntParam(os.Getenv("CHAT_HISTORY_WINDOW"), 6, 0, 100),
+		MaxSummaryChars: parseIntParam(os.Getenv("CHAT_SUMMARY_MAX_CHARS"), 2000, 200, 20000),
+	}
+}
+
+// checks the pass key hash of a chat request and returns it
+func chatAuthorized(c *gin.Context, passKey string) (string, bool) {
+	sha256Hash := sha256.Sum256([]byte(passKey))
+	hashKey := hex.EncodeToString(sha256Hash[:])
+	passhash := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if passhash != hashKey {
+		log.Println("Unauthorized access attempt with passhash:", passhash)
+		c.String(http.StatusUnauthorized, "Unauthorized access")
+		return "", false
+	}
+	return hashKey, true
+}
+
+// loads a session, answering 404 or 500 when it cannot
+func loadChatSession(c *gin.Context, id string) (chat.Repository, *chat.Session, bool) {
+	sessions, err := getChatSessions()
+	if err != nil {
+		log.Println("Failed to open chat sessions:", err)
+		c.String(http.StatusInternalServerError, "Internal Server Error, Chat Sessions")
+		return nil, nil, false
+	}
+	session, err := sessions.Get(c.Request.Context(), id)
+	if errors.Is(err, chat.ErrNotFound) {
+		c.String(http.StatusNotFound, "Chat session not found")
+		return nil, nil, false
+	}
+	if err != nil {
+		log.Println("Failed to load chat session:", err)
+		c.String(http.StatusInternalServerError, "Internal Server Error, Chat Sessions")
+		return nil, nil, false
+	}
+	return sessions, session, true
+}
+
+// response of the session listing
+type chatSessionList struct {
+	Sessions []chat.Info `json:"sessions"`
+}
+
+// request creating a session, the title defaults to the first question
+type chatSessionRequest struct {
+	Title string `json:"title,omitempty"`
+}
+
+// registers the endpoints that create, list, fetch and delete chat sessions
+func chatSessionRoutes(router *gin.Engine, passKey string) {
+	router.POST("/chat/sessions", func(c *gin.Context) {
+		if _, ok := chatAuthorized(c, passKey); !ok {
+			return
+		}
+		var req chatSessionRequest
+		if c.Request.ContentLength > 0 {
+			if err := c.BindJSON(&req); err != nil {
+				return
+			}
+		}
+		sessions, err := getChatSessions()
+		if err != nil {
+			log.Println("Failed to open chat sessions:", err)
+			c.String(http.StatusInternalServerError, "Internal Server Error, Chat Sessions")
+			return
+		}
+		session := chat.NewSession(req.Title, time.Now().UTC())
+		if err := sessions.Save(c.Request.Context(), session); err != nil {
+			log.Println("Failed to save chat session:", err)
+			c.String(http.StatusInternalServerError, "Internal Server Error, Chat Sessions")
+			return
+		}
+		c.JSON(http.StatusCreated, session)
+	})
+
+	router.GET("/chat/sessions", func(c *gin.Context) {
+		if _, ok := chatAuthorized(c, passKey); !ok {
+			return
+		}
+		sessions, err := getChatSessions()
+		if err != nil {
+			log.Println("Failed to open chat sessions:", err)
+			c.String(http.StatusInternalServerError, "Internal Server Error, Chat Sessions")
+			return
+		}
+		infos, err := sessions.List(c.Request.Context(), chat.ListOptions{
+			Limit:  parseIntParam(c.Query("limit"), 20, 1, 100),
+			Offset: parseIntParam(c.Query("offset"), 0, 0, 100000),
+		})
+		if err != nil {
+			log.Println("Failed to list chat sessions:", err)
+			c.String(http.StatusInternalServerError, "Internal Server Error, Chat Sessions")
+			return
+		}
+		c.JSON(http.StatusOK, chatSessionList{Sessions: infos})
+	})
+
+	router.GET("/chat/sessions/:id", func(c *gin.Context) {
+		if _, ok := chatAuthorized(c, passKey); !ok {
+			return
+		}
+		if _, session, ok := loadChatSession(c, c.Param("id")); ok {
+			c.JSON(http.StatusOK, session)
+		}
+	})
+
+	router.DELETE("/chat/sessions/:id", func(c *gin.Context) {
+		if _, ok := chatAuthorized(c, passKey); !ok {
+			return
+		}
+		sessions, err := getChatSessions()
+		if err != nil {
+			log.Println("Failed to open chat sessions:", err)
+			c.String(http.StatusInternalServerError, "Internal Server Error, Chat Sessions")
+			return
+		}
+		err = sessions.Delete(c.Request.Context(), c.Param("id"))
+		if errors.Is(err, chat.ErrNotFound) {
+			c.String(http.StatusNotFound, "Chat session not found")
+			return
+		}
+		if err != nil {
+			log.Println("Failed to delete chat session:", err)
+			c.String(http.StatusInternalServerError, "Internal Server Error, Chat Sessions")
+			return
+		}
+		c.Status(http.StatusNoContent)
+	})
+}
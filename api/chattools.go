@@ -0,0 +1,244 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fineas/pkg/agent"
+	"fineas/pkg/citation"
+	"fineas/pkg/services"
+	"fmt"
+	"log"
+	"math"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	polygon "github.com/polygon-io/client-go/rest"
+	"github.com/polygon-io/client-go/rest/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var tickerParam = agent.Param{Name: "ticker", Type: "string", Description: "symbol such as AAPL, X:BTCUSD for crypto", Required: true}
+
+// the tools the chat agent can call, backed by the same services that write
+// the reports so current data does not wait for the next ingestion
+func chatTools(svc *services.Client) []agent.Tool {
+	return []agent.Tool{
+		{
+			Name:        "get_quote",
+			Description: "latest daily open, high, low, close and volume",
+			Params:      []agent.Param{tickerParam},
+			Run:         getQuoteTool,
+		},
+		{
+			Name:        "get_financials",
+			Description: "latest income statement, balance sheet and cash flow figures",
+			Params:      []agent.Param{tickerParam},
+			Run: func(ctx context.Context, args agent.Args) ([]citation.Source, error) {
+				return tickerServiceTool(ctx, svc, services.Fin, args.String("ticker"), "financials")
+			},
+		},
+		{
+			Name:        "get_indicators",
+			Description: "technical indicators: SMA, EMA, MACD and RSI",
+			Params:      []agent.Param{tickerParam},
+			Run: func(ctx context.Context, args agent.Args) ([]citation.Source, error) {
+				return tickerServiceTool(ctx, svc, services.TA, args.String("ticker"), "technical indicators")
+			},
+		},
+		{
+			Name:        "get_news",
+			Description: "recent news articles with their sentiment",
+			Params: []agent.Param{
+				tickerParam,
+				{Name: "limit", Type: "number", Description: "articles to return, 5 by default"},
+				{Name: "lookback_days", Type: "number", Description: "how many days back, 7 by default"},
+			},
+			Run: func(ctx context.Context, args agent.Args) ([]citation.Source, error) {
+				return getNewsTool(ctx, svc, args)
+			},
+		},
+		{
+			Name:        "get_report",
+			Description: "the stored market research report, all sections or one of stk, fin, news, desc, ta, peers, valuation",
+			Params: []agent.Param{
+				tickerParam,
+				{Name: "section", Type: "string", Description: "report section"},
+			},
+			Run: getReportTool,
+		},
+		{
+			Name:        "compute_ratio",
+			Description: "divides two figures, use it instead of doing arithmetic yourself",
+			Params: []agent.Param{
+				{Name: "numerator", Type: "number", Required: true},
+				{Name: "denominator", Type: "number", Required: true},
+				{Name: "label", Type: "string", Description: "what the ratio is, e.g. AAPL P/E"},
+				{Name: "percent", Type: "boolean", Description: "express the ratio as a percentage"},
+			},
+			Run: computeRatioTool,
+		},
+	}
+}
+
+// previous session aggregates from Polygon
+func getQuoteTool(ctx context.Context, args agent.Args) ([]citation.Source, error) {
+	ticker := strings.ToUpper(args.String("ticker"))
+	c := polygon.New(os.Getenv("API_KEY"))
+	res, err := c.GetPreviousCloseAgg(ctx, models.GetPreviousCloseAggParams{Ticker: ticker}.WithAdjusted(true))
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Results) == 0 {
+		return nil, fmt.Errorf("no quote for %s", ticker)
+	}
+	agg := res.Results[0]
+	day := time.Time(agg.Timestamp)
+	text := fmt.Sprintf("%s on %s: open $%.2f, high $%.2f, low $%.2f, close $%.2f, volume %.0f, VWAP $%.2f",
+		ticker, day.Format("2006-01-02"), agg.Open, agg.High, agg.Low, agg.Close, agg.Volume, agg.VWAP)
+	if agg.Open != 0 {
+		text += fmt.Sprintf(", %.2f%% from the open", (agg.Close-agg.Open)/agg.Open*100)
+	}
+	return []citation.Source{{
+		Title:   ticker + " quote",
+		Ticker:  ticker,
+		Date:    day.Format("20060102"),
+		Text:    text,
+		Snippet: text,
+	}}, nil
+}
+
+// calls a ticker data service without a write key, so nothing is stored
+func tickerServiceTool(ctx context.Context, svc *services.Client, endpoint services.GetEndpoint[services.TickerRequest, services.ResultResponse], ticker string, title string) ([]citation.Source, error) {
+	ticker = strings.ToUpper(ticker)
+	resp, err := services.Get(ctx, svc, endpoint, services.TickerRequest{Ticker: ticker})
+	if err != nil {
+		return nil, err
+	}
+	return []citation.Source{{
+		Title:   ticker + " " + title,
+		Ticker:  ticker,
+		Date:    time.Now().Format("20060102"),
+		Text:    resp.Result,
+		Snippet: snippet(resp.Result, 280),
+	}}, nil
+}
+
+// the news summary followed by one source per article so each can be cited
+// with its URL
+func getNewsTool(ctx context.Context, svc *services.Client, args agent.Args) ([]citation.Source, error) {
+	ticker := strings.ToUpper(args.String("ticker"))
+	limit := args.Int("limit", 5, 1, 20)
+	resp, err := services.Get(ctx, svc, services.News, services.TickerRequest{Ticker: ticker, Params: url.Values{
+		"page_size":     {strconv.Itoa(limit)},
+		"lookback_days": {strconv.Itoa(args.Int("lookback_days", 7, 1, 365))},
+	}})
+	if err != nil {
+		return nil, err
+	}
+	sources := []citation.Source{{
+		Title:   ticker + " news summary",
+		Ticker:  ticker,
+		Date:    time.Now().Format("20060102"),
+		Text:    fmt.Sprintf("%s\nSentiment over the last %d days: %s (%+.2f)", resp.Result, resp.LookbackDays, resp.Sentiment.Label, resp.Sentiment.Value),
+		Snippet: snippet(resp.Result, 280),
+	}}
+	for i, article := range resp.Articles {
+		if i == limit {
+			break
+		}
+		source := citation.Source{
+			Title:   article.Title,
+			URL:     article.URL,
+			Ticker:  ticker,
+			Text:    article.Title + "\n" + article.Snippet,
+			Snippet: snippet(article.Snippet, 280),
+		}
+		if !article.PublishedAt.IsZero() {
+			source.Date = article.PublishedAt.Format("20060102")
+		}
+		sources = append(sources, source)
+	}
+	return sources, nil
+}
+
+// reads the ticker's report from FinancialInformation.TickersList
+func getReportTool(ctx context.Context, args agent.Args) ([]citation.Source, error) {
+	ticker := strings.ToUpper(args.String("ticker"))
+	section := strings.ToLower(args.String("section"))
+	client, err := connectMongo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Disconnect(context.Background())
+	var report storedReport
+	err = client.Database("FinancialInformation").Collection("TickersList").FindOne(ctx, bson.M{"Ticker": ticker}).Decode(&report)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, fmt.Errorf("no report for %s", ticker)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var sources []citation.Source
+	for _, s := range reportSections {
+		text := s.Field(report)
+		if text == "" || (section != "" && section != s.Section) {
+			continue
+		}
+		sources = append(sources, citation.Source{
+			Title:   ticker + " report " + s.Section + " section",
+			Ticker:  ticker,
+			Date:    report.ID.Timestamp().Format("20060102"),
+			Text:    text,
+			Snippet: snippet(text, 280),
+		})
+	}
+	if len(sources) == 0 && section != "" {
+		return nil, fmt.Errorf("the %s report has no %s section", ticker, section)
+	}
+	return sources, nil
+}
+
+func computeRatioTool(ctx context.Context, args agent.Args) ([]citation.Source, error) {
+	numerator, _ := args.Float("numerator")
+	denominator, _ := args.Float("denominator")
+	if denominator == 0 {
+		return nil, errors.New("denominator is zero")
+	}
+	ratio := numerator / denominator
+	if math.IsInf(ratio, 0) || math.IsNaN(ratio) {
+		return nil, errors.New("ratio is not a finite number")
+	}
+	label := args.String("label")
+	if label == "" {
+		label = "ratio"
+	}
+	value := fmt.Sprintf("%.4f", ratio)
+	if args.Bool("percent") {
+		value = fmt.Sprintf("%.2f%%", ratio*100)
+	}
+	text := fmt.Sprintf("%s = %s / %s = %s", label, strconv.FormatFloat(numerator, 'f', -1, 64), strconv.FormatFloat(denominator, 'f', -1, 64), value)
+	return []citation.Source{{Title: label, Text: text, Snippet: text}}, nil
+}
+
+// builds the chat agent. It calls at most CHAT_AGENT_MAX_STEPS tools, 4 by
+// default and 0 to answer from the retrieved sources only
+func newChatAgent(svc *services.Client) *agent.Agent {
+	a := &agent.Agent{
+		Complete: func(ctx context.Context, prompt string) (string, error) {
+			return fetchChatResponse(ctx, svc, PromptPayload{Prompt: prompt})
+		},
+		MaxSteps:       parseIntParam(os.Getenv("CHAT_AGENT_MAX_STEPS"), 4, 0, 10),
+		MaxSourceChars: parseIntParam(os.Getenv("CHAT_SOURCE_CHARS"), 4000, 200, 100000),
+		OnCall: func(call agent.Call) {
+			log.Printf("Tool call %d: %s %v -> sources %v in %dms %s", call.Step, call.Tool, call.Arguments, call.Sources, call.DurationMs, call.Error)
+		},
+	}
+	if a.MaxSteps > 0 {
+		a.Tools = chatTools(svc)
+	}
+	return a
+}
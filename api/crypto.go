@@ -0,0 +1,79 @@
+package api
+
+import (
+	"context"
+	"fineas/pkg/crypto"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	polygon "github.com/polygon-io/client-go/rest"
+	"github.com/polygon-io/client-go/rest/models"
+)
+
+// checks if the ticker is a polygon crypto ticker
+func isCryptoTicker(ticker string) bool {
+	return strings.HasPrefix(ticker, "X:")
+}
+
+// builds the crypto market and on-chain sources from the environment
+func newCryptoSources() (crypto.Source, crypto.OnChainSource) {
+	source := crypto.NewCoinGecko(os.Getenv("CRYPTO_DATA_URL"), os.Getenv("COINGECKO_API_KEY"))
+
+	var onChain crypto.OnChainSource = crypto.NoOnChain{}
+	if onChainURL := os.Getenv("CRYPTO_ONCHAIN_URL"); onChainURL != "" {
+		onChain = crypto.NewHTTPOnChain(onChainURL)
+	}
+	return source, onChain
+}
+
+// gets the crypto asset profile used in place of the company description
+func getCryptoDescription(ticker string) (string, error) {
+	source, _ := newCryptoSources()
+	profile, err := source.Profile(context.Background(), crypto.Symbol(ticker))
+	if err != nil {
+		return "", err
+	}
+	return profile.Summary(), nil
+}
+
+// gets supply, market and on-chain statistics used in place of financial statements
+func getCryptoFundamentals(c *polygon.Client, ticker string) (string, error) {
+	ctx := context.Background()
+	source, onChain := newCryptoSources()
+	symbol := crypto.Symbol(ticker)
+
+	market, err := source.Market(ctx, symbol)
+	if err != nil {
+		return "", err
+	}
+
+	// prefer polygon's previous close so price and volume agree with the stk section
+	params := models.GetPreviousCloseAggParams{
+		Ticker: ticker,
+	}.WithAdjusted(true)
+	prev, err := c.GetPreviousCloseAgg(ctx, params)
+	if err != nil {
+		log.Println("Error fetching previous close for", ticker, err)
+	} else if len(prev.Results) > 0 {
+		agg := prev.Results[0]
+		market.Price = agg.Close
+		market.Volume24h = agg.Volume * agg.VWAP
+		if market.CirculatingSupply > 0 {
+			market.MarketCap = agg.Close * market.CirculatingSupply
+		}
+	}
+
+	stats, err := onChain.Stats(ctx, symbol)
+	if err != nil {
+		log.Println("Error fetching on-chain stats for", ticker, err)
+	}
+
+	fundamentals := crypto.Fundamentals{
+		Market:  *market,
+		OnChain: stats,
+		AsOf:    time.Now(),
+	}
+	return fundamentals.Summary(), nil
+}
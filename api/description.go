@@ -4,6 +4,8 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
+	"fineas/pkg/services"
 	"fmt"
 	"log"
 	"net"
@@ -17,7 +19,6 @@ import (
 
 	"github.com/joho/godotenv"
 	polygon "github.com/polygon-io/client-go/rest"
-	"github.com/polygon-io/client-go/rest/models"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -33,9 +34,7 @@ func DescriptionService(w http.ResponseWriter, r *http.Request) {
 		EventSequence   []string
 	}
 
-	type descOUTPUT struct {
-		Result string
-	}
+	type descOUTPUT = services.DescResponse
 
 	var descLog DESCLOG
 	var eventSequenceArray []string
@@ -92,11 +91,6 @@ func DescriptionService(w http.ResponseWriter, r *http.Request) {
 	ticker := queryParams.Get("ticker")
 	writeKey := queryParams.Get("writekey")
 
-	if strings.HasPrefix(ticker, "X:") || strings.HasPrefix(ticker, "I:") {
-		w.Write([]byte("500 Internal Server Error"))
-		return
-
-	}
 	if len(ticker) == 0 {
 		log.Println("Missing required parameter 'ticker' in the query string")
 		w.Write([]byte(http.StatusText(http.StatusBadRequest)))
@@ -108,25 +102,39 @@ func DescriptionService(w http.ResponseWriter, r *http.Request) {
 	//log ticker
 	eventSequenceArray = append(eventSequenceArray, "ticker collected \n")
 
-	// set params
-	date := models.Date(time.Now())
-	fmt.Println("CURRENT DATE: ", date)
-	params := models.GetTickerDetailsParams{
-		Ticker: ticker,
-		Date:   &date,
-	}.WithDate(date)
-
-	// make request
-	res, err := c.GetTickerDetails(context.Background(), params)
-	if err != nil {
-		log.Println(err)
+	if isCryptoTicker(ticker) {
+		// crypto assets have no ticker details, use the crypto profile instead
+		desc_info, err := getCryptoDescription(ticker)
+		if err != nil {
+			log.Println("Error fetching crypto profile:", err)
+			eventSequenceArray = append(eventSequenceArray, "could not collect crypto profile \n")
+			w.Write([]byte("500 Internal Server Error"))
+			return
+		}
+		output.Result = desc_info
+		eventSequenceArray = append(eventSequenceArray, "crypto profile collected \n")
+	} else if holdings, err := getFundHoldings(ticker); holdings != nil || err != nil {
+		// indexes and ETFs are described by their holdings
+		if err != nil {
+			log.Println("Error fetching fund holdings:", err)
+			eventSequenceArray = append(eventSequenceArray, "could not collect fund holdings \n")
+			w.Write([]byte("500 Internal Server Error"))
+			return
+		}
+		output.Result = holdings.Summary(10)
+		eventSequenceArray = append(eventSequenceArray, "fund profile collected \n")
+	} else {
+		companyProfile, err := getCompanyProfile(c, ticker)
+		if err != nil {
+			log.Println("Error fetching company profile:", err)
+			eventSequenceArray = append(eventSequenceArray, "could not collect company profile \n")
+			w.Write([]byte("500 Internal Server Error"))
+			return
+		}
+		output.Profile = companyProfile
+		output.Result = companyProfile.Summary()
+		eventSequenceArray = append(eventSequenceArray, "company profile collected \n")
 	}
-	//market cap is in notation 2.99437134256e+12
-	//convert to integer with no decimal places or scientific notation
-	marketCap := int(res.Results.MarketCap)
-
-	desc_info := fmt.Sprint("DESCRIPTION: ", res.Results.Description, " TOTAL EMPLOYEES: ", res.Results.TotalEmployees, " MARKET CAP: ", marketCap)
-	output.Result = desc_info
 	fmt.Println(output.Result)
 
 	if (writeKey == WRITE_KEY) && (len(writeKey) != 0) {
@@ -180,8 +188,12 @@ func DescriptionService(w http.ResponseWriter, r *http.Request) {
 	endTime := time.Now()
 	elapsedTime := endTime.Sub(startTime)
 	descLog.ExecutionTimeMs = float32(elapsedTime.Milliseconds())
+	descJson, err := json.Marshal(output)
+	if err != nil {
+		eventSequenceArray = append(eventSequenceArray, "Error: could not marshal desc struct into json"+err.Error()+"\n")
+	}
 	w.Header().Set("Content-Type", "application/json")
-	w.Write([]byte(fmt.Sprint(output)))
+	w.Write(descJson)
 	descLog.Timestamp = time.Now()
 
 	// insert the log into the database
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"context"
+	"fineas/pkg/embed"
+	"fineas/pkg/vectorstore"
+	"os"
+	"strconv"
+	"sync"
+)
+
+var (
+	embedder     embed.Embedder
+	embedderErr  error
+	embedderOnce sync.Once
+)
+
+// returns the shared embedder. EMBEDDING_PROVIDER is "pinecone", "openai" or
+// "hashing" with EMBEDDING_MODEL and EMBEDDING_DIMENSION. Vectors are cached
+// by text hash and model in an EMBEDDING_CACHE_SIZE entry LRU and, when
+// EMBEDDING_CACHE_DIR is set, on disk so retries do not embed twice
+func getEmbedder() (embed.Embedder, error) {
+	embedderOnce.Do(func() {
+		dimension, _ := strconv.Atoi(os.Getenv("EMBEDDING_DIMENSION"))
+		var base embed.Embedder
+		switch getEnvDefault("EMBEDDING_PROVIDER", "pinecone") {
+		case "openai":
+			openai, err := embed.NewOpenAI(os.Getenv("OPENAI_API_KEY"), os.Getenv("EMBEDDING_MODEL"), dimension)
+			if err != nil {
+				embedderErr = err
+				return
+			}
+			base = openai
+		case "hashing":
+			base = embed.NewHashing(dimension)
+		default:
+			hosted, err := embed.NewPinecone(os.Getenv("PINECONE_API_KEY"), os.Getenv("EMBEDDING_MODEL"))
+			if err != nil {
+				embedderErr = err
+				return
+			}
+			base = hosted
+		}
+
+		caches := []embed.Cache{embed.NewLRU(parseIntParam(os.Getenv("EMBEDDING_CACHE_SIZE"), 1024, 1, 1000000))}
+		if dir := os.Getenv("EMBEDDING_CACHE_DIR"); dir != "" {
+			caches = append(caches, embed.DiskCache{Dir: dir})
+		}
+		embedder = &embed.Cached{Embedder: base, Caches: caches}
+	})
+	return embedder, embedderErr
+}
+
+// checks that the embedder's vectors fit the vector store
+func checkEmbeddingDimension(ctx context.Context, e embed.Embedder, store vectorstore.Store) error {
+	dimension, err := store.Dimension(ctx)
+	if err != nil {
+		return err
+	}
+	return embed.CheckDimension(e, dimension)
+}
+
+// embeds a chat prompt as a query
+func embedQuery(ctx context.Context, prompt string) ([]float32, error) {
+	e, err := getEmbedder()
+	if err != nil {
+		return nil, err
+	}
+	return embed.One(ctx, e, prompt, embed.Query)
+}
@@ -0,0 +1,104 @@
+package api
+
+import (
+	"context"
+	"fineas/pkg/extract"
+	"fineas/pkg/news"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var (
+	articleExtractor     *extract.Extractor
+	articleExtractorOnce sync.Once
+)
+
+// returns the shared extractor so robots.txt rules, per domain rate limits and
+// the memory cache carry over between requests. EXTRACT_CACHE_DIR switches to
+// a disk cache
+func getArticleExtractor() *extract.Extractor {
+	articleExtractorOnce.Do(func() {
+		intervalMs, err := strconv.Atoi(getEnvDefault("EXTRACT_MIN_INTERVAL_MS", "1000"))
+		if err != nil {
+			intervalMs = 1000
+		}
+		ttl := 7 * 24 * time.Hour
+
+		var cache extract.Cache = extract.NewMemoryCache(ttl)
+		if dir := os.Getenv("EXTRACT_CACHE_DIR"); dir != "" {
+			cache = extract.DirCache{Dir: dir, TTL: ttl}
+		}
+		articleExtractor = extract.New(getEnvDefault("EXTRACT_USER_AGENT", "FineasBot/1.0"), time.Duration(intervalMs)*time.Millisecond, cache)
+	})
+	return articleExtractor
+}
+
+// publisher URLs of Google News links, kept between requests so a link is
+// resolved once
+var googleNewsResolved sync.Map
+
+// resolves a Google News link to the publisher URL, other links are returned
+// unchanged
+func resolveArticleURL(ctx context.Context, rawURL string) (string, error) {
+	if !news.IsGoogleNewsURL(rawURL) {
+		return rawURL, nil
+	}
+	if resolved, ok := googleNewsResolved.Load(rawURL); ok {
+		return resolved.(string), nil
+	}
+	resolved, err := news.ResolveGoogleNewsURL(ctx, &http.Client{Timeout: 15 * time.Second}, rawURL)
+	if err != nil {
+		return "", err
+	}
+	googleNewsResolved.Store(rawURL, resolved)
+	return resolved, nil
+}
+
+// extracts the given URLs and fills in the text of the matching articles.
+// Google News links are resolved to the publisher first, so robots.txt and
+// rate limits apply to the site the text comes from
+func extractArticleText(ctx context.Context, articles []news.Article, urls []string) map[string]error {
+	seen := make(map[string]bool)
+	failures := make(map[string]error)
+	// the article URLs each extracted URL stands for
+	origins := make(map[string][]string)
+	var targets []string
+	for _, rawURL := range urls {
+		if _, err := url.Parse(rawURL); err != nil || seen[rawURL] {
+			continue
+		}
+		seen[rawURL] = true
+		target, err := resolveArticleURL(ctx, rawURL)
+		if err != nil {
+			failures[rawURL] = err
+			continue
+		}
+		if _, ok := origins[target]; !ok {
+			targets = append(targets, target)
+		}
+		origins[target] = append(origins[target], rawURL)
+	}
+
+	results, failed := getArticleExtractor().ExtractAll(ctx, targets, 4)
+	for target, err := range failed {
+		for _, origin := range origins[target] {
+			failures[origin] = err
+		}
+	}
+	texts := make(map[string]string, len(results))
+	for target, result := range results {
+		for _, origin := range origins[target] {
+			texts[origin] = result.Text
+		}
+	}
+	for i := range articles {
+		if text, ok := texts[articles[i].URL]; ok {
+			articles[i].Text = text
+		}
+	}
+	return failures
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fineas/pkg/serviceauth"
+	"fineas/pkg/services"
 	"fmt"
 	"log"
 	"math/big"
@@ -34,9 +35,7 @@ func FinService(w http.ResponseWriter, r *http.Request) {
 		EventSequence   []string
 	}
 
-	type finOUTPUT struct {
-		Result string
-	}
+	type finOUTPUT = services.ResultResponse
 
 	var finLog FINLOG
 	var eventSequenceArray []string
@@ -95,10 +94,6 @@ func FinService(w http.ResponseWriter, r *http.Request) {
 	// Ticker input checking
 	ticker := queryParams.Get("ticker")
 	writeKey := queryParams.Get("writekey")
-	if strings.HasPrefix(ticker, "X:") || strings.HasPrefix(ticker, "I:") {
-		w.Write([]byte("500 Internal Server Error"))
-		return
-	}
 	if len(ticker) == 0 {
 		log.Println("Missing required parameter 'ticker' in the query string")
 		w.Write([]byte(http.StatusText(http.StatusBadRequest)))
@@ -110,53 +105,77 @@ func FinService(w http.ResponseWriter, r *http.Request) {
 	// Log ticker
 	eventSequenceArray = append(eventSequenceArray, "ticker collected \n")
 
-	// Make request to Polygon API for stock financials
-	params := models.ListStockFinancialsParams{}.
-		WithTicker(ticker)
+	var collection string
+	holdings, fundErr := getFundHoldings(ticker)
+	isFund := holdings != nil || fundErr != nil
+	if isCryptoTicker(ticker) {
+		// crypto assets have no statements, collect supply and market fundamentals instead
+		collection, err = getCryptoFundamentals(c, ticker)
+		if err != nil {
+			log.Println("Error fetching crypto fundamentals:", err)
+			eventSequenceArray = append(eventSequenceArray, "could not collect crypto fundamentals \n")
+			w.Write([]byte("500 Internal Server Error"))
+			return
+		}
+		eventSequenceArray = append(eventSequenceArray, "Collected crypto fundamentals \n")
+	} else if isFund {
+		// indexes and ETFs aggregate the fundamentals of their constituents
+		if fundErr != nil {
+			log.Println("Error fetching fund fundamentals:", fundErr)
+			eventSequenceArray = append(eventSequenceArray, "could not collect fund fundamentals \n")
+			w.Write([]byte("500 Internal Server Error"))
+			return
+		}
+		collection = getFundFundamentals(c, holdings)
+		eventSequenceArray = append(eventSequenceArray, "Collected fund fundamentals \n")
+	} else {
+		// Make request to Polygon API for stock financials
+		params := models.ListStockFinancialsParams{}.
+			WithTicker(ticker)
 
-	iter := c.VX.ListStockFinancials(context.Background(), params)
+		iter := c.VX.ListStockFinancials(context.Background(), params)
 
-	// Accumulate financial values
-	var collection string
-	for iter.Next() {
-		res := fmt.Sprint(iter.Item())
-		collection += res
-	}
+		// Accumulate financial values
+		for iter.Next() {
+			res := fmt.Sprint(iter.Item())
+			collection += res
+		}
 
-	if collection == "" {
-		eventSequenceArray = append(eventSequenceArray, "could not collect financial statements \n")
-		w.Write([]byte("500 Internal Server Error"))
-		return
-	}
+		if collection == "" {
+			eventSequenceArray = append(eventSequenceArray, "could not collect financial statements \n")
+			w.Write([]byte("500 Internal Server Error"))
+			return
+		}
 
-	if iter.Err() != nil {
-		eventSequenceArray = append(eventSequenceArray, "could not collect financial statements"+iter.Err().Error()+"\n")
-		w.Write([]byte("500 Internal Server Error"))
-		return
-	}
+		if iter.Err() != nil {
+			eventSequenceArray = append(eventSequenceArray, "could not collect financial statements"+iter.Err().Error()+"\n")
+			w.Write([]byte("500 Internal Server Error"))
+			return
+		}
 
-	eventSequenceArray = append(eventSequenceArray, "Collected financial statements \n")
+		eventSequenceArray = append(eventSequenceArray, "Collected financial statements \n")
 
-	// Integrate financial statement accumulation here
-	targetSubstring := "equity_attributable_to_noncontrolling_interest"
-	index := strings.Index(collection, targetSubstring)
-	if index != -1 {
-		collection = collection[:index]
-		collection = deleteNumberBeforeUSD(collection)
+		// Integrate financial statement accumulation here
+		targetSubstring := "equity_attributable_to_noncontrolling_interest"
+		index := strings.Index(collection, targetSubstring)
+		if index != -1 {
+			collection = collection[:index]
+			collection = deleteNumberBeforeUSD(collection)
 
-		// Accumulate financial values
-		accumulatedValues, err := accumulateFinancialValues(collection)
-		if err != nil {
-			eventSequenceArray = append(eventSequenceArray, "error accumulating financial values: "+err.Error()+"\n")
+			// Accumulate financial values
+			accumulatedValues, err := accumulateFinancialValues(collection)
+			if err != nil {
+				eventSequenceArray = append(eventSequenceArray, "error accumulating financial values: "+err.Error()+"\n")
+			} else {
+				// Assign the accumulated values to the 'collection' variable
+				collection = accumulatedValues
+			}
+
+			// Log the accumulated values for debugging
+			collection = "The balance sheet for " + ticker + " is " + collection
 		} else {
-			// Assign the accumulated values to the 'collection' variable
-			collection = accumulatedValues
+			eventSequenceArray = append(eventSequenceArray, "could not properly collect financial statements \n")
 		}
-
-		// Log the accumulated values for debugging
-		collection = "The balance sheet for " + ticker + " is " + collection
-	} else {
-		eventSequenceArray = append(eventSequenceArray, "could not properly collect financial statements \n")
 	}
 
 	// Log execution time
@@ -215,7 +234,11 @@ func FinService(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	collection = "The balance sheet for " + ticker + " is " + collection + ", as of date and time " + time.Now().Format("01-02-2006 15:04:05")
+	if isCryptoTicker(ticker) || isFund {
+		collection = collection + ", as of date and time " + time.Now().Format("01-02-2006 15:04:05")
+	} else {
+		collection = "The balance sheet for " + ticker + " is " + collection + ", as of date and time " + time.Now().Format("01-02-2006 15:04:05")
+	}
 	output.Result = collection
 
 	finJson, err := json.Marshal(output) // marshal the stk struct into json
@@ -0,0 +1,124 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fineas/pkg/fund"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	polygon "github.com/polygon-io/client-go/rest"
+	"github.com/polygon-io/client-go/rest/models"
+)
+
+// builds the holdings provider from the environment, holdings files are
+// checked before the remote provider
+func newFundProvider() fund.Provider {
+	dir := getEnvDefault("FUND_HOLDINGS_DIR", "../../utils/data/holdings")
+	providers := fund.Chain{fund.FileProvider{Dir: dir}}
+	if holdingsURL := os.Getenv("FUND_HOLDINGS_URL"); holdingsURL != "" {
+		providers = append(providers, fund.NewHTTPProvider(holdingsURL))
+	}
+	return providers
+}
+
+// gets the holdings of an index or ETF, nil when the ticker is not a fund.
+// Handlers look them up once per request and pass them on. An index without
+// holdings gets a profile without constituents so its report is still
+// written. A failing lookup is returned for indexes, other tickers are then
+// treated as no fund
+func getFundHoldings(ticker string) (*fund.Holdings, error) {
+	if isCryptoTicker(ticker) || !fund.ValidTicker(ticker) {
+		return nil, nil
+	}
+	isIndex := strings.HasPrefix(strings.ToUpper(ticker), "I:")
+	holdings, err := newFundProvider().Holdings(context.Background(), ticker)
+	switch {
+	case err == nil:
+		return holdings, nil
+	case errors.Is(err, fund.ErrNotFound) && isIndex:
+		return &fund.Holdings{Ticker: ticker, Name: ticker, Type: fund.TypeIndex}, nil
+	case errors.Is(err, fund.ErrNotFound):
+		return nil, nil
+	case isIndex:
+		return nil, err
+	}
+	log.Println("Error looking up fund holdings for", ticker, err)
+	return nil, nil
+}
+
+// gets the aggregated constituent fundamentals and benchmark tracking used in
+// place of financial statements
+func getFundFundamentals(c *polygon.Client, holdings *fund.Holdings) string {
+	fundamentals := holdings.Analyze()
+	if holdings.Benchmark != "" && !strings.EqualFold(holdings.Benchmark, holdings.Ticker) {
+		tracking, err := getFundTracking(c, holdings.Ticker, holdings.Benchmark)
+		if err != nil {
+			log.Println("Error computing tracking for", holdings.Ticker, err)
+		} else {
+			fundamentals.Tracking = tracking
+		}
+	}
+	return fundamentals.Summary()
+}
+
+// compares a year of daily closes for the fund and its benchmark
+func getFundTracking(c *polygon.Client, ticker string, benchmark string) (*fund.Tracking, error) {
+	to := time.Now()
+	from := to.AddDate(-1, 0, 0)
+
+	fundCloses, err := getDailyCloses(c, ticker, from, to)
+	if err != nil {
+		return nil, err
+	}
+	benchCloses, err := getDailyCloses(c, benchmark, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	// only compare days where both have a close
+	var fundSeries, benchSeries []float64
+	for _, day := range sortedDays(fundCloses) {
+		if benchClose, ok := benchCloses[day]; ok {
+			fundSeries = append(fundSeries, fundCloses[day])
+			benchSeries = append(benchSeries, benchClose)
+		}
+	}
+	return fund.CompareTracking(benchmark, fundSeries, benchSeries)
+}
+
+// gets daily closes keyed by YYYY-MM-DD
+func getDailyCloses(c *polygon.Client, ticker string, from time.Time, to time.Time) (map[string]float64, error) {
+	params := models.ListAggsParams{
+		Ticker:     ticker,
+		Multiplier: 1,
+		Timespan:   models.Day,
+		From:       models.Millis(from),
+		To:         models.Millis(to),
+	}.WithAdjusted(true).WithOrder(models.Asc)
+
+	closes := make(map[string]float64)
+	iter := c.ListAggs(context.Background(), params)
+	for iter.Next() {
+		agg := iter.Item()
+		closes[time.Time(agg.Timestamp).Format("2006-01-02")] = agg.Close
+	}
+	if iter.Err() != nil {
+		return nil, iter.Err()
+	}
+	return closes, nil
+}
+
+// returns the map's day keys oldest first
+func sortedDays(closes map[string]float64) []string {
+	days := make([]string, 0, len(closes))
+	for day := range closes {
+		days = append(days, day)
+	}
+	// YYYY-MM-DD sorts lexically
+	sort.Strings(days)
+	return days
+}
@@ -0,0 +1,177 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fineas/pkg/ingest"
+	"fineas/pkg/serviceauth"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+var (
+	ingestor     *ingest.Ingestor
+	ingestorErr  error
+	ingestorOnce sync.Once
+)
+
+// returns the shared ingestor writing to the chat knowledge base. Chunks are
+// INGEST_CHUNK_SENTENCES sentences long and share INGEST_CHUNK_OVERLAP with
+// the previous chunk
+func getIngestor() (*ingest.Ingestor, error) {
+	ingestorOnce.Do(func() {
+		e, err := getEmbedder()
+		if err != nil {
+			ingestorErr = err
+			return
+		}
+		store, err := getVectorStore()
+		if err != nil {
+			ingestorErr = err
+			return
+		}
+		ingestor = &ingest.Ingestor{
+			Embedder: e,
+			Store:    store,
+			Chunking: ingestChunkOptions(),
+		}
+	})
+	return ingestor, ingestorErr
+}
+
+// the chunking of the ingestor, also used by the keyword index so report
+// chunks get the IDs they are stored under
+func ingestChunkOptions() ingest.ChunkOptions {
+	defaults := ingest.DefaultChunkOptions()
+	return ingest.ChunkOptions{
+		Sentences: parseIntParam(os.Getenv("INGEST_CHUNK_SENTENCES"), defaults.Sentences, 1, 100),
+		Overlap:   parseIntParam(os.Getenv("INGEST_CHUNK_OVERLAP"), defaults.Overlap, 0, 99),
+	}
+}
+
+// identifies the prompt templates a report was written with. TEMPLATE_VERSION
+// names it explicitly, otherwise it is a short hash of the templates
+func templateVersion(sections reportSectionSet) string {
+	if version := os.Getenv("TEMPLATE_VERSION"); version != "" {
+		return version
+	}
+	hash := sha256.New()
+	for _, template := range []string{sections.StkTemplate, sections.FinTemplate, sections.NewsTemplate,
+		sections.DescTemplate, sections.TaTemplate, sections.PeersTemplate, sections.ValuationTemplate} {
+		hash.Write([]byte(template))
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))[:12]
+}
+
+// ingests the report sections the aggregator generated for a ticker under
+// the day of the report
+func ingestReport(ctx context.Context, ticker string, sections reportSectionSet, report map[string]string) (ingest.Result, error) {
+	ing, err := getIngestor()
+	if err != nil {
+		return ingest.Result{}, err
+	}
+	version := templateVersion(sections)
+	var records []ingest.Record
+	for _, section := range reportSections {
+		if text := report[section.Section]; text != "" {
+			records = append(records, ingest.Record{
+				Ticker:          ticker,
+				Section:         section.Section,
+				Text:            text,
+				Date:            aggregatorNow(),
+				TemplateVersion: version,
+				Replace:         true,
+			})
+		}
+	}
+	return ing.Ingest(ctx, records)
+}
+
+// response of the ingestor endpoint, Status is kept for clients that look for "200"
+type ingestResponse struct {
+	Status int `json:"status"`
+	ingest.Result
+}
+
+// IngestorHandler chunks, embeds and stores the "info" form field for the
+// chatbot. It accepts the multipart and urlencoded forms the aggregator and
+// scripts/loadhistdata post, with optional ticker, section, source_url and
+// date (YYYY-MM-DD) fields
+func IngestorHandler(w http.ResponseWriter, r *http.Request) {
+	err := godotenv.Load("../../.env") // load the .env file
+	if err != nil {
+		log.Fatal("Error loading .env file")
+	}
+
+	// secure service with pass key hash
+	eventSequenceArray := []string{}
+	PASS_KEY := os.Getenv("PASS_KEY")
+	hash := sha256.New()
+	hash.Write([]byte(PASS_KEY))
+	passHash := hex.EncodeToString(hash.Sum(nil))
+	if !serviceauth.ServiceAuthMiddleware(w, r, eventSequenceArray, passHash) {
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Error: Method Not Allowed(405).", http.StatusMethodNotAllowed)
+		return
+	}
+	// ParseMultipartForm falls back to the urlencoded body
+	if err := r.ParseMultipartForm(32 << 20); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+		http.Error(w, "Error: Bad Request(400), could not read the form.", http.StatusBadRequest)
+		return
+	}
+	date, err := parseDateParam(r.FormValue("date"), false)
+	if err != nil {
+		http.Error(w, "Error: Bad Request(400), 'date' must be YYYY-MM-DD.", http.StatusBadRequest)
+		return
+	}
+	records := ingest.ParseInfo(r.FormValue("info"), r.FormValue("ticker"))
+	if len(records) == 0 {
+		http.Error(w, "Error: Bad Request(400), 'info' is empty.", http.StatusBadRequest)
+		return
+	}
+	for i := range records {
+		if section := strings.TrimSpace(r.FormValue("section")); section != "" {
+			records[i].Section = section
+		}
+		records[i].SourceURL = r.FormValue("source_url")
+		records[i].Date = date
+		records[i].TemplateVersion = os.Getenv("TEMPLATE_VERSION")
+	}
+
+	ing, err := getIngestor()
+	if err != nil {
+		log.Println("Error creating the ingestor:", err)
+		http.Error(w, "Error: Internal Server Error(500), the knowledge base is unavailable.", http.StatusInternalServerError)
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Minute)
+	defer cancel()
+	result, err := ing.Ingest(ctx, records)
+	if errors.Is(err, ingest.ErrEmpty) {
+		http.Error(w, "Error: Bad Request(400), 'info' has no text.", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		log.Println("Error ingesting data:", err)
+		http.Error(w, "Error: Internal Server Error(500), ingestion failed.", http.StatusInternalServerError)
+		return
+	}
+	log.Println("ingested " + strconv.Itoa(result.Chunks) + " chunks from " + strconv.Itoa(result.Records) + " records")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ingestResponse{Status: http.StatusOK, Result: result})
+}
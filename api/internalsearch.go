@@ -0,0 +1,396 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fineas/pkg/fulltext"
+	"fineas/pkg/ingest"
+	"fineas/pkg/news"
+	"fineas/pkg/profile"
+	"fineas/pkg/serviceauth"
+	"fineas/pkg/services"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/joho/godotenv"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// report sections stored in TickersList, keyed by the service that wrote them
+var reportSections = []struct {
+	Section string
+	Field   func(storedReport) string
+}{
+	{"stk", func(r storedReport) string { return r.StockPerformance }},
+	{"fin", func(r storedReport) string { return r.FinancialHealth }},
+	{"news", func(r storedReport) string { return r.NewsSummary }},
+	{"desc", func(r storedReport) string { return r.CompanyDesc }},
+	{"ta", func(r storedReport) string { return r.TechnicalAnalysis }},
+	{"peers", func(r storedReport) string { return r.PeerComparison }},
+	{"valuation", func(r storedReport) string { return r.Valuation }},
+}
+
+// a TickersList document
+type storedReport struct {
+	ID           primitive.ObjectID `bson:"_id"`
+	PostDataInfo `bson:",inline"`
+}
+
+// a RawInformation document, the bson form of a service output
+type rawInformation struct {
+	ID       primitive.ObjectID      `bson:"_id"`
+	Result   string                  `bson:"result"`
+	Articles []news.Article          `bson:"articles,omitempty"`
+	Profile  *profile.CompanyProfile `bson:"profile,omitempty"`
+}
+
+var (
+	internalIndexMu          sync.Mutex
+	internalIndex            *fulltext.Index
+	internalIndexBuiltAt     time.Time
+	internalIndexRefreshedAt time.Time
+	internalIndexLastRaw     primitive.ObjectID
+	internalIndexReports     map[string]bool
+	internalIndexRefreshing  bool
+
+	// serialises reads from Mongo, searches never wait on it once an index exists
+	internalIndexBuildMu sync.Mutex
+)
+
+// returns the keyword index over stored reports, raw service outputs and news
+// articles. Only the first call waits for the index to be built. Later calls
+// get the current index at once, and when it is older than
+// INTERNAL_INDEX_REFRESH_MINUTES a background refresh adds what was written
+// since. A stale index is kept if the refresh fails
+func getInternalIndex(ctx context.Context) (*fulltext.Index, time.Time, error) {
+	internalIndexMu.Lock()
+	index, refreshedAt := internalIndex, internalIndexRefreshedAt
+	if index != nil {
+		if time.Since(refreshedAt) >= internalIndexRefreshInterval() && !internalIndexRefreshing {
+			internalIndexRefreshing = true
+			go refreshInternalIndex()
+		}
+		internalIndexMu.Unlock()
+		return index, refreshedAt, nil
+	}
+	internalIndexMu.Unlock()
+
+	internalIndexBuildMu.Lock()
+	defer internalIndexBuildMu.Unlock()
+	internalIndexMu.Lock()
+	index, refreshedAt = internalIndex, internalIndexRefreshedAt
+	internalIndexMu.Unlock()
+	if index != nil {
+		return index, refreshedAt, nil
+	}
+	return updateInternalIndex(ctx)
+}
+
+func internalIndexRefreshInterval() time.Duration {
+	refreshMinutes, err := strconv.Atoi(getEnvDefault("INTERNAL_INDEX_REFRESH_MINUTES", "15"))
+	if err != nil {
+		refreshMinutes = 15
+	}
+	return time.Duration(refreshMinutes) * time.Minute
+}
+
+// runs in the background, searches keep using the current index meanwhile
+func refreshInternalIndex() {
+	defer func() {
+		internalIndexMu.Lock()
+		internalIndexRefreshing = false
+		internalIndexMu.Unlock()
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	internalIndexBuildMu.Lock()
+	defer internalIndexBuildMu.Unlock()
+	if _, _, err := updateInternalIndex(ctx); err != nil {
+		log.Println("could not refresh the internal search index, serving the previous one:", err)
+	}
+}
+
+// adds the raw outputs written since the last read and the current reports to
+// the index. Once a day, or when there is no index yet, a new index is built
+// from the last INTERNAL_INDEX_LOOKBACK_DAYS of raw outputs and swapped in, so
+// older outputs drop out. Callers hold internalIndexBuildMu
+func updateInternalIndex(ctx context.Context) (*fulltext.Index, time.Time, error) {
+	internalIndexMu.Lock()
+	index, builtAt, lastRaw, previousReports := internalIndex, internalIndexBuiltAt, internalIndexLastRaw, internalIndexReports
+	internalIndexMu.Unlock()
+
+	full := index == nil || time.Since(builtAt) >= 24*time.Hour
+	if full {
+		lookbackDays := parseIntParam(os.Getenv("INTERNAL_INDEX_LOOKBACK_DAYS"), 90, 1, 3650)
+		lastRaw = primitive.NewObjectIDFromTimestamp(time.Now().AddDate(0, 0, -lookbackDays))
+	}
+	docs, newest, err := loadIndexDocuments(ctx, lastRaw)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	if full {
+		index = fulltext.New()
+	}
+	index.Add(docs...)
+	// reports are read in full, chunks of a rewritten report are dropped
+	reports := make(map[string]bool)
+	for _, doc := range docs {
+		if doc.Source == fulltext.SourceReport {
+			reports[doc.ID] = true
+		}
+	}
+	if !full {
+		for id := range previousReports {
+			if !reports[id] {
+				index.Remove(id)
+			}
+		}
+	}
+
+	now := time.Now()
+	internalIndexMu.Lock()
+	defer internalIndexMu.Unlock()
+	internalIndex = index
+	internalIndexRefreshedAt = now
+	internalIndexReports = reports
+	if full {
+		internalIndexBuiltAt = now
+		log.Printf("internal search index built with %d documents", index.Len())
+	} else {
+		log.Printf("internal search index refreshed with %d documents", len(docs))
+	}
+	if newest.IsZero() {
+		newest = lastRaw
+	}
+	internalIndexLastRaw = newest
+	return index, now, nil
+}
+
+// reads the stored reports and the RawInformation documents with an _id after
+// since, returning them as index documents with the newest raw _id read
+func loadIndexDocuments(ctx context.Context, since primitive.ObjectID) ([]fulltext.Document, primitive.ObjectID, error) {
+	var newest primitive.ObjectID
+	client, err := connectMongo(ctx)
+	if err != nil {
+		return nil, newest, err
+	}
+	defer client.Disconnect(context.Background())
+	db := client.Database("FinancialInformation")
+
+	var reports []storedReport
+	cursor, err := db.Collection("TickersList").Find(ctx, bson.M{})
+	if err != nil {
+		return nil, newest, err
+	}
+	if err := cursor.All(ctx, &reports); err != nil {
+		return nil, newest, err
+	}
+
+	var raw []rawInformation
+	cursor, err = db.Collection("RawInformation").Find(ctx, bson.M{"_id": bson.M{"$gt": since}})
+	if err != nil {
+		return nil, newest, err
+	}
+	if err := cursor.All(ctx, &raw); err != nil {
+		return nil, newest, err
+	}
+	for _, info := range raw {
+		if info.ID.Hex() > newest.Hex() {
+			newest = info.ID
+		}
+	}
+
+	tracked := make(map[string]bool)
+	for _, report := range reports {
+		tracked[strings.ToUpper(report.Ticker)] = true
+	}
+	return indexDocuments(reports, raw, tracked), newest, nil
+}
+
+// turns stored reports and raw outputs into index documents. Report sections
+// are split into the chunks the ingestor stores and indexed under the same
+// IDs, so chat retrieval fuses a chunk found by both retrievers. Raw outputs
+// do not store their ticker, it is taken from their articles or profile or
+// from the first tracked ticker the text mentions
+func indexDocuments(reports []storedReport, raw []rawInformation, tracked map[string]bool) []fulltext.Document {
+	var docs []fulltext.Document
+	chunking := ingestChunkOptions()
+	for _, report := range reports {
+		ticker := strings.ToUpper(strings.TrimSpace(report.Ticker))
+		// the report is ingested when it is written, on the day of its _id
+		written := report.ID.Timestamp().Local()
+		day := ingest.DateKey(written)
+		for _, section := range reportSections {
+			for n, chunk := range ingest.Chunk(section.Field(report), chunking) {
+				docs = append(docs, fulltext.Document{
+					ID:      ingest.ChunkID(ticker, section.Section, "", day, n, chunk),
+					Ticker:  ticker,
+					Section: section.Section,
+					Source:  fulltext.SourceReport,
+					Title:   ticker + " " + section.Section,
+					Text:    chunk,
+					Date:    written,
+				})
+			}
+		}
+	}
+
+	for _, info := range raw {
+		ticker, section := "", ""
+		switch {
+		case len(info.Articles) > 0:
+			section = "news"
+			if len(info.Articles[0].Tickers) > 0 {
+				ticker = info.Articles[0].Tickers[0]
+			}
+		case info.Profile != nil:
+			section = "desc"
+			ticker = info.Profile.Ticker
+		}
+		if ticker == "" {
+			ticker = mentionedTicker(info.Result, tracked)
+		}
+		if info.Result != "" {
+			docs = append(docs, fulltext.Document{
+				ID:      "raw:" + info.ID.Hex(),
+				Ticker:  ticker,
+				Section: section,
+				Source:  fulltext.SourceRaw,
+				Text:    info.Result,
+				Date:    info.ID.Timestamp(),
+			})
+		}
+		for _, article := range info.Articles {
+			body := article.Snippet
+			if article.Text != "" {
+				body = article.Text
+			}
+			articleTicker := ticker
+			if len(article.Tickers) > 0 {
+				articleTicker = article.Tickers[0]
+			}
+			docs = append(docs, fulltext.Document{
+				ID:      "news:" + article.ID,
+				Ticker:  articleTicker,
+				Section: "news",
+				Source:  fulltext.SourceNews,
+				Title:   article.Title,
+				Text:    body,
+				URL:     article.URL,
+				Date:    article.PublishedAt,
+			})
+		}
+	}
+	return docs
+}
+
+// returns the first tracked ticker written in the text
+func mentionedTicker(text string, tracked map[string]bool) string {
+	for _, word := range strings.FieldsFunc(text, func(r rune) bool {
+		return !(r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == ':')
+	}) {
+		word = strings.TrimRight(word, ".")
+		if tracked[word] {
+			return word
+		}
+	}
+	return ""
+}
+
+// parses a YYYY-MM-DD filter, endOfDay makes a "to" date inclusive
+func parseDateParam(value string, endOfDay bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfDay {
+		date = date.Add(24*time.Hour - time.Nanosecond)
+	}
+	return date, nil
+}
+
+// InternalSearchHandler runs a keyword search over the stored reports, raw
+// service outputs and news articles, filtered by ticker, section, source and date
+func InternalSearchHandler(w http.ResponseWriter, r *http.Request) {
+	err := godotenv.Load("../../.env") // load the .env file
+	if err != nil {
+		log.Fatal("Error loading .env file")
+	}
+
+	// secure service with pass key hash
+	eventSequenceArray := []string{}
+	PASS_KEY := os.Getenv("PASS_KEY")
+	hash := sha256.New()
+	hash.Write([]byte(PASS_KEY))
+	passHash := hex.EncodeToString(hash.Sum(nil))
+	if !serviceauth.ServiceAuthMiddleware(w, r, eventSequenceArray, passHash) {
+		return
+	}
+
+	queryParams := r.URL.Query()
+	from, err := parseDateParam(queryParams.Get("from"), false)
+	if err != nil {
+		http.Error(w, "Error: Bad Request(400), 'from' must be YYYY-MM-DD.", http.StatusBadRequest)
+		return
+	}
+	to, err := parseDateParam(queryParams.Get("to"), true)
+	if err != nil {
+		http.Error(w, "Error: Bad Request(400), 'to' must be YYYY-MM-DD.", http.StatusBadRequest)
+		return
+	}
+	query := fulltext.Query{
+		Text:     queryParams.Get("q"),
+		Tickers:  splitList(queryParams.Get("ticker")),
+		Sections: splitList(queryParams.Get("section")),
+		Sources:  splitList(queryParams.Get("source")),
+		From:     from,
+		To:       to,
+		Limit:    parseIntParam(queryParams.Get("limit"), 10, 1, 100),
+		Offset:   parseIntParam(queryParams.Get("offset"), 0, 0, 10000),
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+	index, builtAt, err := getInternalIndex(ctx)
+	if err != nil {
+		log.Println("Error building the internal search index:", err)
+		http.Error(w, "Error building the search index", http.StatusInternalServerError)
+		return
+	}
+
+	results := index.Search(query)
+	if queryParams.Get("highlight") != "false" {
+		for i := range results.Hits {
+			hit := &results.Hits[i]
+			hit.Highlights = fulltext.DefaultHighlighter.Highlight(hit.Text, query.Text)
+			if len(hit.Highlights) == 0 {
+				hit.Highlights = fulltext.DefaultHighlighter.Highlight(hit.Title, query.Text)
+			}
+		}
+	}
+	log.Printf("internal search %q matched %d documents", query.Text, results.Total)
+
+	response := services.InternalSearchResponse{
+		Query:       query.Text,
+		Total:       results.Total,
+		Hits:        results.Hits,
+		Indexed:     index.Len(),
+		RefreshedAt: builtAt,
+	}
+	if response.Hits == nil {
+		response.Hits = []fulltext.Hit{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -3,6 +3,7 @@ package api
 import (
 	"crypto/sha256"
 	"encoding/hex"
+	"fineas/pkg/services"
 	"log"
 	"net/http"
 	"os"
@@ -30,10 +31,8 @@ func LLMHandler(router *gin.Engine) {
 
 		log.Println("Using CLAUDE_API_KEY:", CLAUDE_API_KEY) // Print the API key for debugging purposes
 
-		// Extract prompt from JSON payload
-		var jsonData struct {
-			Prompt string `json:"prompt"`
-		}
+		// Extract prompt from JSON payload, declared in the service contract
+		var jsonData services.LLMRequest
 		if err := c.BindJSON(&jsonData); err != nil {
 			log.Println("Error binding JSON:", err)
 			c.String(http.StatusBadRequest, "Invalid JSON payload")
@@ -5,17 +5,21 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"fineas/pkg/news"
+	"fineas/pkg/sentiment"
 	"fineas/pkg/serviceauth"
+	"fineas/pkg/services"
 	"fmt"
 	"log"
 	"net"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
-	"github.com/PuerkitoBio/goquery"
 	"github.com/joho/godotenv"
+	polygon "github.com/polygon-io/client-go/rest"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -31,9 +35,7 @@ func NewsService(w http.ResponseWriter, r *http.Request) {
 		EventSequence   []string
 	}
 
-	type newsOUTPUT struct {
-		Result string
-	}
+	type newsOUTPUT = services.NewsResponse
 
 	var newsLog NEWSLOG
 	var output newsOUTPUT
@@ -109,9 +111,28 @@ func NewsService(w http.ResponseWriter, r *http.Request) {
 	//log ticker
 	eventSequenceArray = append(eventSequenceArray, "ticker collected \n")
 
-	scrapeTickerURL := "https://news.google.com/search?q=" + strings.ToUpper(ticker) + "-news" + tag + "&hl=en-US&gl=US&ceid=US%3Aen"
-
-	textFromDiv, err := scrapeTextFromDiv(scrapeTickerURL, 5)
+	// paging and lookback window
+	lookbackDays := parseIntParam(queryParams.Get("lookback_days"), parseIntParam(os.Getenv("NEWS_LOOKBACK_DAYS"), 7, 1, 365), 1, 365)
+	page := parseIntParam(queryParams.Get("page"), 1, 1, 1000)
+	pageSize := parseIntParam(queryParams.Get("page_size"), 10, 1, 50)
+	cutoff := time.Now().AddDate(0, 0, -lookbackDays)
+
+	API_KEY := os.Getenv("API_KEY")
+	c := polygon.New(API_KEY)
+	sources := newNewsSources(c)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	articles, failures, err := news.FetchAll(ctx, sources, news.Query{
+		Ticker:   ticker,
+		Tag:      tag,
+		Since:    cutoff,
+		Limit:    50,
+		Language: "en",
+	})
+	for _, failure := range failures {
+		eventSequenceArray = append(eventSequenceArray, "news source "+failure.Source+" failed: "+failure.Error+" \n")
+	}
 	if err != nil {
 		http.Error(w, "Failed to scrape data", http.StatusInternalServerError)
 		eventSequenceArray = append(eventSequenceArray, "Failed to scrape data \n")
@@ -124,13 +145,60 @@ func NewsService(w http.ResponseWriter, r *http.Request) {
 		eventSequenceArray = append(eventSequenceArray, "Successfully scraped data \n")
 	}
 
+	articles = news.WithinLookback(articles, cutoff)
+	news.SortByRecency(articles)
+
+	// group coverage of the same story, the five most important stories feed the news template
+	clusters := news.ClusterArticles(articles, time.Now())
+
+	// full article text for the stories that feed the template and the requested page
+	if queryParams.Get("full_text") == "true" || os.Getenv("NEWS_FULL_TEXT") == "true" {
+		var urls []string
+		for i, cluster := range clusters {
+			if i == 5 {
+				break
+			}
+			urls = append(urls, cluster.Representative.URL)
+		}
+		for _, article := range news.Page(articles, page, pageSize) {
+			urls = append(urls, article.URL)
+		}
+		extractCtx, extractCancel := context.WithTimeout(context.Background(), 45*time.Second)
+		failed := extractArticleText(extractCtx, articles, urls)
+		extractCancel()
+		eventSequenceArray = append(eventSequenceArray, "extracted article text, "+strconv.Itoa(len(failed))+" failed \n")
+	}
+
+	// lexicon sentiment per article and per day
+	observations := scoreArticles(newSentimentScorer(), articles)
+	annotateRepresentatives(clusters, articles)
+	series := sentiment.Daily(strings.ToUpper(ticker), observations)
+	if (writeKey == WRITE_KEY) && (len(writeKey) != 0) {
+		series, err = storeNewsSentiment(client, strings.ToUpper(ticker), series, cutoff)
+		if err != nil {
+			eventSequenceArray = append(eventSequenceArray, "could not store news sentiment: "+err.Error()+" \n")
+		} else {
+			eventSequenceArray = append(eventSequenceArray, "stored news sentiment \n")
+		}
+	}
+	output.Sentiment = sentiment.Overall(series)
+	output.SentimentSeries = series
+
 	endTime := time.Now()
 	elapsedTime := endTime.Sub(startTime)
 	newsLog.ExecutionTimeMs = float32(elapsedTime.Milliseconds())
 
-	// Convert accumulated financial values to JSON format
-	textFromDiv = strings.ReplaceAll(textFromDiv, "\n", ". ")
-	output.Result = textFromDiv
+	var summaries []string
+	for i := 0; i < len(clusters) && i < 5; i++ {
+		summaries = append(summaries, clusters[i].Summary())
+	}
+	output.Result = sentiment.Summary(output.Sentiment, series) + " " + strings.Join(summaries, ". ")
+	output.Articles = news.Page(articles, page, pageSize)
+	output.Page = page
+	output.PageSize = pageSize
+	output.Total = len(articles)
+	output.LookbackDays = lookbackDays
+	output.Clusters = clusters
 
 	newsJson, err := json.Marshal(output) // marshal the stk struct into json
 	if err != nil {
@@ -204,39 +272,62 @@ func NewsService(w http.ResponseWriter, r *http.Request) {
 
 }
 
-// scrapeTextFromDiv scrapes the text from Google's top news section
-func scrapeTextFromDiv(url string, collectionSize int) (string, error) {
-	// Make a GET request to the URL
-	res, err := http.Get(url)
-	if err != nil {
-		return "", err
+// copies the extracted text and sentiment of each cluster's representative
+// from the articles, ClusterArticles returns copies made before either was set
+func annotateRepresentatives(clusters []news.Cluster, articles []news.Article) {
+	byURL := make(map[string]news.Article, len(articles))
+	for _, article := range articles {
+		byURL[article.URL] = article
 	}
-	defer res.Body.Close()
-
-	if res.StatusCode != 200 {
-		return "", fmt.Errorf("status code error: %d %s", res.StatusCode, res.Status)
+	for i := range clusters {
+		article, ok := byURL[clusters[i].Representative.URL]
+		if !ok {
+			continue
+		}
+		clusters[i].Representative.Text = article.Text
+		clusters[i].Representative.Sentiment = article.Sentiment
 	}
+}
 
-	// Parse the HTML response body
-	doc, err := goquery.NewDocumentFromReader(res.Body)
-	if err != nil {
-		return "", err
-	}
-
-	var results []string
-	doc.Find("a.JtKRv").Each(func(i int, s *goquery.Selection) {
-		if i < collectionSize {
-			headline := s.Text()
-			link, exists := s.Attr("href")
-			if exists {
-				// Format the URL correctly
-				fullURL := "https://news.google.com" + strings.TrimPrefix(link, ".")
-				results = append(results, fmt.Sprintf("Headline: %s, URL: %s", headline, fullURL))
+// builds the configured news sources. NEWS_SOURCES picks the adapters
+// (google, polygon, feeds), NEWS_FEEDS lists feeds as name|location pairs
+// separated by commas and NEWS_SEC_USER_AGENT enables the SEC press release feed
+func newNewsSources(c *polygon.Client) []news.Source {
+	var sources []news.Source
+	for _, name := range strings.Split(getEnvDefault("NEWS_SOURCES", "google,polygon,feeds"), ",") {
+		switch strings.TrimSpace(name) {
+		case "google":
+			sources = append(sources, news.NewGoogleSource(os.Getenv("NEWS_GOOGLE_URL")))
+		case "polygon":
+			sources = append(sources, &news.PolygonSource{Client: c, FixturePath: os.Getenv("NEWS_POLYGON_FIXTURE")})
+		case "feeds":
+			userAgent := os.Getenv("NEWS_SEC_USER_AGENT")
+			if userAgent != "" {
+				sources = append(sources, news.NewFeedSource("sec", []string{news.SECPressReleaseFeed}, "SEC EDGAR", userAgent))
+			}
+			for _, entry := range strings.Split(os.Getenv("NEWS_FEEDS"), ",") {
+				entry = strings.TrimSpace(entry)
+				if entry == "" {
+					continue
+				}
+				feedName, location, found := strings.Cut(entry, "|")
+				if !found {
+					feedName, location = "feed", entry
+				}
+				sources = append(sources, news.NewFeedSource(feedName, []string{location}, "", userAgent))
 			}
 		}
-	})
+	}
+	return sources
+}
 
-	return strings.Join(results, "\n"), nil
+// parses an integer query parameter, falling back to the default when missing or out of range
+func parseIntParam(value string, fallback int, min int, max int) int {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < min || n > max {
+		return fallback
+	}
+	return n
 }
 
 func removePrefixSuffix(ticker string) (string, string) {
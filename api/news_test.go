@@ -0,0 +1,54 @@
+package api
+
+import (
+	"fineas/pkg/news"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRepresentativesKeepSentiment(t *testing.T) {
+	now := time.Date(2024, 5, 3, 12, 0, 0, 0, time.UTC)
+	articles := []news.Article{
+		news.NewArticle("Apple beats estimates as iPhone sales surge", "https://reuters.com/apple-beats", "Reuters", now.Add(-2*time.Hour), "Apple reported record profit and strong growth", "en"),
+		news.NewArticle("Apple beats estimates as iPhone sales surge", "https://cnbc.com/apple-beats", "CNBC", now.Add(-3*time.Hour), "Apple reported record profit and strong growth", "en"),
+		news.NewArticle("Apple faces lawsuit over App Store fees", "https://bloomberg.com/apple-lawsuit", "Bloomberg", now.Add(-30*time.Hour), "Developers allege losses from the fees", "en"),
+	}
+	articles[0].Text = "Apple reported record profit, beating estimates on strong iPhone growth."
+
+	// clustering copies the articles before they are scored, as in NewsService
+	clusters := news.ClusterArticles(articles, now)
+	scoreArticles(newSentimentScorer(), articles)
+	annotateRepresentatives(clusters, articles)
+
+	if len(clusters) != 2 {
+		t.Fatalf("got %d clusters, want 2", len(clusters))
+	}
+	representative := clusters[0].Representative
+	if representative.Sentiment == nil {
+		t.Fatalf("representative %q has no sentiment", representative.URL)
+	}
+	var scored *news.Article
+	for i := range articles {
+		if articles[i].URL == representative.URL {
+			scored = &articles[i]
+		}
+	}
+	if scored == nil {
+		t.Fatalf("representative %q is not one of the articles", representative.URL)
+	}
+	if *representative.Sentiment != *scored.Sentiment {
+		t.Errorf("got sentiment %+v, want %+v", *representative.Sentiment, *scored.Sentiment)
+	}
+	if representative.Text != scored.Text {
+		t.Errorf("got text %q, want %q", representative.Text, scored.Text)
+	}
+	if !strings.Contains(clusters[0].Summary(), "Lexicon sentiment:") {
+		t.Errorf("summary %q has no lexicon sentiment", clusters[0].Summary())
+	}
+	for _, cluster := range clusters {
+		if cluster.Representative.Sentiment == nil {
+			t.Errorf("representative %q has no sentiment", cluster.Representative.URL)
+		}
+	}
+}
@@ -0,0 +1,335 @@
+package api
+
+import (
+	"encoding/json"
+	"fineas/pkg/chat"
+	"fineas/pkg/openapi"
+	"fineas/pkg/services"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// The OpenAPI document of every Fineas endpoint, kept next to the handlers.
+// A query parameter added to a handler is added here too so it is documented
+// and its type is validated before the handler runs
+
+var (
+	apiSpecOnce sync.Once
+	apiSpec     *openapi.Document
+)
+
+// serviceServer is the server a path is served on. /ret and /chat use TLS
+func serviceServer(port string, tls bool) []openapi.Server {
+	scheme := "http://"
+	if tls {
+		scheme = "https://"
+	}
+	host := getEnvDefault("OPENAPI_SERVER_HOST", "0.0.0.0")
+	return []openapi.Server{{URL: scheme + host + ":" + port}}
+}
+
+func tickerParams(extra ...openapi.Parameter) []openapi.Parameter {
+	params := []openapi.Parameter{
+		openapi.Query("ticker", openapi.String(), true, "ticker symbol, I: prefixed for indices and X: for crypto"),
+		openapi.Query("writekey", openapi.String(), false, "when it matches WRITE_KEY the result is stored in RawInformation and the body is empty"),
+	}
+	return append(params, extra...)
+}
+
+func jsonResponse(doc *openapi.Document, description string, v interface{}) map[string]*openapi.Response {
+	return map[string]*openapi.Response{
+		"200": {Description: description, Content: openapi.JSON(doc.SchemaFor(v))},
+		"400": {Description: "request does not match the specification"},
+		"401": {Description: "missing or wrong pass key hash"},
+	}
+}
+
+func tickerOperation(doc *openapi.Document, id string, summary string, response interface{}, extra ...openapi.Parameter) *openapi.Operation {
+	return &openapi.Operation{
+		OperationID: id,
+		Summary:     summary,
+		Tags:        []string{"services"},
+		Parameters:  tickerParams(extra...),
+		Responses:   jsonResponse(doc, summary, response),
+		Security:    openapi.BearerAuth,
+	}
+}
+
+func buildAPISpec() *openapi.Document {
+	doc := &openapi.Document{
+		OpenAPI: "3.0.3",
+		Info: openapi.Info{
+			Title:       "Fineas",
+			Version:     "1.0.0",
+			Description: "Financial report services. Each path is served on its own port, listed in the path servers.",
+		},
+		Paths: make(map[string]*openapi.PathItem),
+		Components: openapi.Components{
+			SecuritySchemes: map[string]*openapi.SecurityScheme{
+				"bearerAuth": {Type: "http", Scheme: "bearer", Description: "hex encoded sha256 of PASS_KEY"},
+			},
+		},
+	}
+
+	doc.Paths["/"] = &openapi.PathItem{
+		Servers: serviceServer("8080", false),
+		Get: &openapi.Operation{
+			OperationID: "aggregateReport",
+			Summary:     "Build the full report for a ticker from every service and store it",
+			Tags:        []string{"aggregator"},
+			Parameters: tickerParams(
+				openapi.Query("peers", openapi.Boolean(), false, "include the peer comparison section"),
+				openapi.Query("valuation", openapi.Boolean(), false, "include the valuation section"),
+			),
+			Responses: map[string]*openapi.Response{
+				"200": {Description: "the report sections", Content: openapi.JSON(doc.SchemaFor(PostDataInfo{}))},
+				"400": {Description: "request does not match the specification"},
+			},
+		},
+	}
+	doc.Paths["/stk"] = &openapi.PathItem{
+		Servers: serviceServer("8081", false),
+		Get:     tickerOperation(doc, "getStockPerformance", "Stock performance report", services.ResultResponse{}),
+	}
+	doc.Paths["/fin"] = &openapi.PathItem{
+		Servers: serviceServer("8082", false),
+		Get:     tickerOperation(doc, "getFinancialHealth", "Financial health report", services.ResultResponse{}),
+	}
+	doc.Paths["/news"] = &openapi.PathItem{
+		Servers: serviceServer("8083", false),
+		Get: tickerOperation(doc, "getNews", "News articles, clusters and sentiment", services.NewsResponse{},
+			openapi.Query("lookback_days", openapi.Integer(1, 365), false, "days of news to include, NEWS_LOOKBACK_DAYS by default"),
+			openapi.Query("page", openapi.Integer(1, 1000), false, "article page"),
+			openapi.Query("page_size", openapi.Integer(1, 50), false, "articles per page"),
+			openapi.Query("full_text", openapi.Boolean(), false, "extract the full text of the top articles"),
+		),
+	}
+	doc.Paths["/desc"] = &openapi.PathItem{
+		Servers: serviceServer("8084", false),
+		Get:     tickerOperation(doc, "getDescription", "Company description and profile", services.DescResponse{}),
+	}
+	doc.Paths["/peers"] = &openapi.PathItem{
+		Servers: serviceServer("8085", false),
+		Get: tickerOperation(doc, "getPeers", "Peer comparison table", services.PeersResponse{},
+			openapi.Query("max", openapi.Integer(1, 20), false, "maximum number of peers"),
+		),
+	}
+
+	valuationParams := []openapi.Parameter{
+		openapi.Query("multiples", openapi.Boolean(), false, "set to false to skip peer multiples"),
+		openapi.Query("years", openapi.Integer(1, 20), false, "years of explicit forecast"),
+	}
+	for _, key := range []string{"base_fcf", "growth_rate", "terminal_growth", "discount_rate", "risk_free_rate", "equity_risk_premium", "beta", "pre_tax_cost_of_debt", "tax_rate"} {
+		valuationParams = append(valuationParams, openapi.Query(key, openapi.Number(), false, "overrides the derived "+key))
+	}
+	doc.Paths["/valuation"] = &openapi.PathItem{
+		Servers: serviceServer("8086", false),
+		Get:     tickerOperation(doc, "getValuation", "DCF and multiples valuation", services.ValuationResponse{}, valuationParams...),
+	}
+	doc.Paths["/ta"] = &openapi.PathItem{
+		Servers: serviceServer("8089", false),
+		Get:     tickerOperation(doc, "getTechnicalAnalysis", "Technical analysis report", services.ResultResponse{}),
+	}
+	doc.Paths["/ret"] = &openapi.PathItem{
+		Servers: serviceServer("8035", true),
+		Get: &openapi.Operation{
+			OperationID: "retrieveReport",
+			Summary:     "Stored report of a ticker, an empty object when there is none",
+			Tags:        []string{"reports"},
+			Parameters: []openapi.Parameter{
+				openapi.Query("ticker", openapi.String(), true, "ticker symbol"),
+			},
+			Responses: map[string]*openapi.Response{
+				"200": {Description: "the stored report", Content: openapi.JSON(doc.SchemaFor(PostDataInfo{}))},
+				"400": {Description: "request does not match the specification"},
+			},
+		},
+	}
+	doc.Paths["/search"] = &openapi.PathItem{
+		Servers: serviceServer("8070", false),
+		Post: &openapi.Operation{
+			OperationID: "search",
+			Summary:     "Web search through the configured provider",
+			Tags:        []string{"services"},
+			RequestBody: &openapi.RequestBody{Required: true, Content: openapi.JSON(doc.SchemaFor(services.SearchRequest{}))},
+			Responses:   jsonResponse(doc, "search results", services.SearchResponse{}),
+			Security:    openapi.BearerAuth,
+		},
+	}
+	doc.Paths["/search/internal"] = &openapi.PathItem{
+		Servers: serviceServer("8070", false),
+		Get: &openapi.Operation{
+			OperationID: "searchInternal",
+			Summary:     "Keyword search over stored reports, raw service outputs and news articles",
+			Tags:        []string{"services"},
+			Parameters: []openapi.Parameter{
+				openapi.Query("q", openapi.String(), false, "keywords, without them the filtered documents are listed newest first"),
+				openapi.Query("ticker", openapi.String(), false, "comma separated tickers"),
+				openapi.Query("section", openapi.String(), false, "comma separated sections: stk, fin, news, desc, ta, peers, valuation"),
+				openapi.Query("source", openapi.String(), false, "comma separated sources: report, raw, news"),
+				openapi.Query("from", &openapi.Schema{Type: "string", Format: "date"}, false, "earliest document date"),
+				openapi.Query("to", &openapi.Schema{Type: "string", Format: "date"}, false, "latest document date, inclusive"),
+				openapi.Query("limit", openapi.Integer(1, 100), false, "hits per page"),
+				openapi.Query("offset", openapi.Integer(0, 10000), false, "hits to skip"),
+				openapi.Query("highlight", openapi.Boolean(), false, "set to false to skip highlighting"),
+			},
+			Responses: jsonResponse(doc, "matching documents", services.InternalSearchResponse{}),
+			Security:  openapi.BearerAuth,
+		},
+	}
+	doc.Paths["/llm"] = &openapi.PathItem{
+		Servers: serviceServer("8090", false),
+		Post: &openapi.Operation{
+			OperationID: "completePrompt",
+			Summary:     "Complete a prompt with the language model",
+			Tags:        []string{"services"},
+			// prompts carry the collected report data
+			RequestBody: &openapi.RequestBody{Required: true, Content: openapi.JSON(doc.SchemaFor(services.LLMRequest{})), MaxBytes: 8 << 20},
+			Responses: map[string]*openapi.Response{
+				"200": {Description: "the completion", Content: openapi.Text()},
+				"400": {Description: "request does not match the specification"},
+				"401": {Description: "missing or wrong pass key hash"},
+			},
+			Security: openapi.BearerAuth,
+		},
+	}
+	doc.Paths["/chat"] = &openapi.PathItem{
+		Servers: serviceServer("6002", true),
+		Post: &openapi.Operation{
+			OperationID: "chat",
+			Summary:     "Answer a question from the stored reports",
+			Tags:        []string{"chat"},
+			RequestBody: &openapi.RequestBody{Required: true, Content: openapi.JSON(doc.SchemaFor(PromptPayload{}))},
+			Responses: map[string]*openapi.Response{
+				"200": {Description: "the answer with its citations, X-Session-Id names the session it continued", Content: openapi.JSON(doc.SchemaFor(chatAnswer{}))},
+				"400": {Description: "request does not match the specification"},
+				"401": {Description: "missing or wrong pass key hash"},
+				"404": {Description: "session_id names no session"},
+			},
+			Security: openapi.BearerAuth,
+		},
+	}
+	sessionID := openapi.PathParam("id", openapi.String(), "session id")
+	doc.Paths["/chat/sessions"] = &openapi.PathItem{
+		Servers: serviceServer("6002", true),
+		Get: &openapi.Operation{
+			OperationID: "listChatSessions",
+			Summary:     "List chat sessions, most recently updated first",
+			Tags:        []string{"chat"},
+			Parameters: []openapi.Parameter{
+				openapi.Query("limit", openapi.Integer(1, 100), false, "sessions per page"),
+				openapi.Query("offset", openapi.Integer(0, 100000), false, "sessions to skip"),
+			},
+			Responses: jsonResponse(doc, "the sessions without their messages", chatSessionList{}),
+			Security:  openapi.BearerAuth,
+		},
+		Post: &openapi.Operation{
+			OperationID: "createChatSession",
+			Summary:     "Start a conversation, pass its id as session_id to /chat",
+			Tags:        []string{"chat"},
+			RequestBody: &openapi.RequestBody{Content: openapi.JSON(doc.SchemaFor(chatSessionRequest{}))},
+			Responses: map[string]*openapi.Response{
+				"201": {Description: "the new session", Content: openapi.JSON(doc.SchemaFor(chat.Session{}))},
+				"400": {Description: "request does not match the specification"},
+				"401": {Description: "missing or wrong pass key hash"},
+			},
+			Security: openapi.BearerAuth,
+		},
+	}
+	doc.Paths["/chat/sessions/{id}"] = &openapi.PathItem{
+		Servers: serviceServer("6002", true),
+		Get: &openapi.Operation{
+			OperationID: "getChatSession",
+			Summary:     "Fetch a chat session with its messages",
+			Tags:        []string{"chat"},
+			Parameters:  []openapi.Parameter{sessionID},
+			Responses: map[string]*openapi.Response{
+				"200": {Description: "the session", Content: openapi.JSON(doc.SchemaFor(chat.Session{}))},
+				"401": {Description: "missing or wrong pass key hash"},
+				"404": {Description: "no session with this id"},
+			},
+			Security: openapi.BearerAuth,
+		},
+		Delete: &openapi.Operation{
+			OperationID: "deleteChatSession",
+			Summary:     "Delete a chat session",
+			Tags:        []string{"chat"},
+			Parameters:  []openapi.Parameter{sessionID},
+			Responses: map[string]*openapi.Response{
+				"204": {Description: "the session was deleted"},
+				"401": {Description: "missing or wrong pass key hash"},
+				"404": {Description: "no session with this id"},
+			},
+			Security: openapi.BearerAuth,
+		},
+	}
+	doc.Paths["/ingestor"] = &openapi.PathItem{
+		Servers: serviceServer("6001", false),
+		Post: &openapi.Operation{
+			OperationID: "ingest",
+			Summary:     "Chunk, embed and store text in the chat knowledge base",
+			Tags:        []string{"chat"},
+			RequestBody: &openapi.RequestBody{Required: true, Content: openapi.Form(&openapi.Schema{
+				Type:     "object",
+				Required: []string{"info"},
+				Properties: map[string]*openapi.Schema{
+					"info":       {Type: "string", Description: "JSON object of report sections, {\"Info\": text} for historical data, or plain text"},
+					"ticker":     {Type: "string", Description: "ticker the text is about, overridden by a Ticker key in info"},
+					"section":    {Type: "string", Description: "section name for every record"},
+					"source_url": {Type: "string", Description: "where the text came from"},
+					"date":       {Type: "string", Format: "date", Description: "day the text describes, today when omitted"},
+				},
+			})},
+			Responses: jsonResponse(doc, "the stored chunks", ingestResponse{}),
+			Security:  openapi.BearerAuth,
+		},
+	}
+	doc.Paths["/openapi.json"] = &openapi.PathItem{
+		Get: &openapi.Operation{
+			OperationID: "getOpenAPI",
+			Summary:     "This document",
+			Tags:        []string{"docs"},
+			Responses: map[string]*openapi.Response{
+				"200": {Description: "the OpenAPI document", Content: openapi.JSON(&openapi.Schema{Type: "object"})},
+			},
+		},
+	}
+	return doc
+}
+
+// APISpec returns the OpenAPI document, built once
+func APISpec() *openapi.Document {
+	apiSpecOnce.Do(func() {
+		apiSpec = buildAPISpec()
+	})
+	return apiSpec
+}
+
+// OpenAPIHandler serves the OpenAPI document
+func OpenAPIHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(APISpec()); err != nil {
+		http.Error(w, "Error encoding OpenAPI document", http.StatusInternalServerError)
+	}
+}
+
+// ValidateRequest rejects requests that do not match the OpenAPI document
+// before they reach the handler
+func ValidateRequest(next http.Handler) http.Handler {
+	return APISpec().Middleware(next)
+}
+
+// ValidateGinRequest is ValidateRequest for the gin router of /llm
+func ValidateGinRequest() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := APISpec().Validate(c.Request); err != nil {
+			c.AbortWithStatusJSON(err.Status, err)
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,332 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fineas/pkg/peers"
+	"fineas/pkg/registry"
+	"fineas/pkg/serviceauth"
+	"fineas/pkg/services"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/joho/godotenv"
+	polygon "github.com/polygon-io/client-go/rest"
+	"github.com/polygon-io/client-go/rest/models"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// handles the peers request
+func PeersService(w http.ResponseWriter, r *http.Request) {
+
+	type PEERSLOG struct {
+		Timestamp       time.Time
+		ExecutionTimeMs float32
+		RequestIP       string
+		EventSequence   []string
+	}
+
+	type peersOUTPUT = services.PeersResponse
+
+	var peersLog PEERSLOG
+	var eventSequenceArray []string
+	var output peersOUTPUT
+
+	//load information structures
+	startTime := time.Now()
+	queryParams := r.URL.Query()
+	err := godotenv.Load("../../.env") // load the .env file
+	if err != nil {
+		log.Fatal("Error loading .env file")
+	}
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		fmt.Fprintf(w, "Error parsing IP address: %v", err)
+		return
+	}
+	eventSequenceArray = append(eventSequenceArray, "collected request ip \n")
+	peersLog.RequestIP = ip
+
+	// secure service with pass key hash
+	PASS_KEY := os.Getenv("PASS_KEY")
+	hash := sha256.New()
+	hash.Write([]byte(PASS_KEY))
+	getPassHash := hash.Sum(nil)
+	passHash := hex.EncodeToString(getPassHash)
+	if !serviceauth.ServiceAuthMiddleware(w, r, eventSequenceArray, passHash) {
+		return
+	}
+
+	// connnect to mongodb
+	MONGO_DB_LOGGER_PASSWORD := os.Getenv("MONGO_DB_LOGGER_PASSWORD")
+	serverAPI := options.ServerAPI(options.ServerAPIVersion1)
+	mongoURI := "mongodb+srv://kobenaidun:" + MONGO_DB_LOGGER_PASSWORD + "@cluster0.z9znpv9.mongodb.net/?retryWrites=true&w=majority"
+	opts := options.Client().ApplyURI(mongoURI).SetServerAPIOptions(serverAPI)
+	client, err := mongo.Connect(context.TODO(), opts)
+	if err != nil {
+		eventSequenceArray = append(eventSequenceArray, "could not connect to database client \n")
+		panic(err)
+	}
+	defer func() {
+		if err = client.Disconnect(context.TODO()); err != nil {
+			eventSequenceArray = append(eventSequenceArray, "could not connect to database \n")
+			panic(err)
+		}
+	}()
+
+	// polygon API connection
+	API_KEY := os.Getenv("API_KEY")
+	c := polygon.New(API_KEY)
+
+	// ticker input checking
+	ticker := strings.ToUpper(queryParams.Get("ticker"))
+	if len(ticker) == 0 {
+		log.Println("Missing required parameter 'ticker' in the query string")
+		http.Error(w, "Error: Bad Request(400), Missing required parameter 'ticker' in the query string.", http.StatusBadRequest)
+		eventSequenceArray = append(eventSequenceArray, "missing ticker \n")
+		return
+	}
+	if isCryptoTicker(ticker) || strings.HasPrefix(ticker, "I:") {
+		http.Error(w, "Error: Bad Request(400), Peer comparison is only available for equities.", http.StatusBadRequest)
+		return
+	}
+	maxPeers := 5
+	if value := queryParams.Get("max"); value != "" {
+		if n, err := strconv.Atoi(value); err == nil && n > 0 && n <= 20 {
+			maxPeers = n
+		}
+	}
+	eventSequenceArray = append(eventSequenceArray, "ticker collected \n")
+
+	table, err := getPeerComparison(c, ticker, maxPeers)
+	if err != nil {
+		log.Println("Error building peer comparison:", err)
+		eventSequenceArray = append(eventSequenceArray, "could not build peer comparison \n")
+		http.Error(w, "500 Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	output.Table = table
+	output.Result = table.Summary()
+	eventSequenceArray = append(eventSequenceArray, "built peer comparison \n")
+
+	peersJson, err := json.Marshal(output)
+	if err != nil {
+		eventSequenceArray = append(eventSequenceArray, "Error: could not marshal peers struct into json"+err.Error()+"\n")
+	}
+
+	endTime := time.Now()
+	elapsedTime := endTime.Sub(startTime)
+	peersLog.ExecutionTimeMs = float32(elapsedTime.Milliseconds())
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(peersJson)
+	peersLog.Timestamp = time.Now()
+
+	// insert the log into the database
+	eventSequenceArray = append(eventSequenceArray, "successfully served peers data \n")
+	peersLog.EventSequence = eventSequenceArray
+	db := client.Database("MicroserviceLogs")
+	collection := db.Collection("PeersServiceLogs")
+	_, err = collection.InsertOne(context.TODO(), peersLog)
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// selects the peer set and builds the comparables table
+func getPeerComparison(c *polygon.Client, ticker string, maxPeers int) (peers.Table, error) {
+	reg, err := registry.Load(getEnvDefault("TICKERS_LIST_PATH", registry.DefaultPath))
+	if err != nil {
+		return peers.Table{}, fmt.Errorf("could not load tickers list: %v", err)
+	}
+	overrides, err := peers.LoadOverrides(getEnvDefault("PEERS_CONFIG_PATH", "../../utils/data/peers.json"))
+	if err != nil {
+		return peers.Table{}, fmt.Errorf("could not load peer overrides: %v", err)
+	}
+
+	subjectMetrics, details, err := getPeerMetrics(c, ticker)
+	if err != nil {
+		return peers.Table{}, err
+	}
+
+	// the registry may not carry a SIC code, fall back to the ticker details
+	subject := peers.Subject{Ticker: ticker, SICCode: details.SICCode}
+	if entry, ok := reg.Lookup(ticker); ok {
+		if entry.SICCode != "" {
+			subject.SICCode = entry.SICCode
+		}
+		subject.Sector = entry.Sector
+	}
+	if subject.Sector == "" {
+		subject.Sector = registry.SICDivision(subject.SICCode)
+	}
+	peerTickers := peers.Select(reg, overrides, subject, maxPeers)
+	if len(peerTickers) == 0 {
+		// the tickers list has no industry data for this company's peers
+		related, err := getRelatedCompanies(ticker)
+		if err != nil {
+			log.Println("Error fetching related companies for", ticker, err)
+		}
+		for _, peer := range related {
+			if len(peerTickers) < maxPeers && !strings.EqualFold(peer, ticker) && !strings.Contains(peer, ":") {
+				peerTickers = append(peerTickers, strings.ToUpper(peer))
+			}
+		}
+	}
+	if len(peerTickers) == 0 {
+		return peers.Table{}, fmt.Errorf("no peers found for %s", ticker)
+	}
+
+	// fetch peer metrics concurrently, a failing peer is left out
+	metrics := make([]*peers.Metrics, len(peerTickers))
+	var wg sync.WaitGroup
+	for i, peer := range peerTickers {
+		wg.Add(1)
+		go func(i int, peer string) {
+			defer wg.Done()
+			m, _, err := getPeerMetrics(c, peer)
+			if err != nil {
+				log.Println("Error fetching peer metrics for", peer, err)
+				return
+			}
+			metrics[i] = m
+		}(i, peer)
+	}
+	wg.Wait()
+
+	rows := []peers.Metrics{*subjectMetrics}
+	for _, m := range metrics {
+		if m != nil {
+			rows = append(rows, *m)
+		}
+	}
+	return peers.BuildTable(ticker, rows), nil
+}
+
+// gets the companies Polygon relates to a ticker, the peers of a ticker whose
+// industry has no other match in the tickers list
+func getRelatedCompanies(ticker string) ([]string, error) {
+	reqURL := getEnvDefault("POLYGON_API_URL", "https://api.polygon.io") + "/v1/related-companies/" + url.PathEscape(ticker) + "?apiKey=" + url.QueryEscape(os.Getenv("API_KEY"))
+	client := &http.Client{Timeout: 15 * time.Second}
+	resp, err := client.Get(reqURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("related companies returned %s", resp.Status)
+	}
+	var related struct {
+		Results []struct {
+			Ticker string `json:"ticker"`
+		} `json:"results"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&related); err != nil {
+		return nil, err
+	}
+	tickers := make([]string, 0, len(related.Results))
+	for _, r := range related.Results {
+		tickers = append(tickers, r.Ticker)
+	}
+	return tickers, nil
+}
+
+// gets valuation and fundamental metrics for one company from ticker details
+// and its two most recent annual statements
+func getPeerMetrics(c *polygon.Client, ticker string) (*peers.Metrics, *models.Ticker, error) {
+	date := models.Date(time.Now())
+	params := models.GetTickerDetailsParams{
+		Ticker: ticker,
+	}.WithDate(date)
+	details, err := c.GetTickerDetails(context.Background(), params)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	m := &peers.Metrics{Ticker: ticker, Name: details.Results.Name}
+	marketCap := details.Results.MarketCap
+	if marketCap > 0 {
+		m.MarketCap = &marketCap
+	}
+
+	statements, err := getFinancialStatements(c, ticker, models.TFAnnual, 2)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(statements) == 0 {
+		return m, &details.Results, nil
+	}
+	latest := statements[0]
+
+	revenue, hasRevenue := getStatementValue(latest, "income_statement", "revenues")
+	netIncome, hasNetIncome := getFirstStatementValue(latest, "income_statement", "net_income_loss_attributable_to_parent", "net_income_loss")
+	operatingIncome, hasOperatingIncome := getStatementValue(latest, "income_statement", "operating_income_loss")
+	grossProfit, hasGrossProfit := getStatementValue(latest, "income_statement", "gross_profit")
+	equity, hasEquity := getFirstStatementValue(latest, "balance_sheet", "equity_attributable_to_parent", "equity")
+	assets, hasAssets := getStatementValue(latest, "balance_sheet", "assets")
+
+	if hasRevenue && revenue != 0 {
+		if hasGrossProfit {
+			m.GrossMargin = ratioPercent(grossProfit, revenue)
+		}
+		if hasOperatingIncome {
+			m.OperatingMargin = ratioPercent(operatingIncome, revenue)
+		}
+		if hasNetIncome {
+			m.NetMargin = ratioPercent(netIncome, revenue)
+		}
+	}
+	if hasNetIncome && hasEquity && equity > 0 {
+		m.ROE = ratioPercent(netIncome, equity)
+	}
+	if hasNetIncome && hasAssets && assets > 0 {
+		m.ROA = ratioPercent(netIncome, assets)
+	}
+	if marketCap > 0 && hasNetIncome && netIncome > 0 {
+		pe := marketCap / netIncome
+		m.PE = &pe
+	}
+	if marketCap > 0 && hasRevenue && revenue > 0 {
+		ps := marketCap / revenue
+		m.PS = &ps
+	}
+	if marketCap > 0 && hasEquity && equity > 0 {
+		pb := marketCap / equity
+		m.PB = &pb
+	}
+
+	// EBITDA falls back to operating income when D&A is not reported
+	if marketCap > 0 && hasOperatingIncome {
+		depreciation, _ := getStatementValue(latest, "income_statement", "depreciation_and_amortization")
+		ebitda := operatingIncome + depreciation
+		debt, _ := getStatementValue(latest, "balance_sheet", "long_term_debt")
+		cash, _ := getStatementValue(latest, "balance_sheet", "cash")
+		if ebitda > 0 {
+			evToEbitda := (marketCap + debt - cash) / ebitda
+			m.EVToEBITDA = &evToEbitda
+		}
+	}
+
+	if len(statements) > 1 && hasRevenue {
+		if prevRevenue, ok := getStatementValue(statements[1], "income_statement", "revenues"); ok && prevRevenue > 0 {
+			m.RevenueGrowth = ratioPercent(revenue-prevRevenue, prevRevenue)
+		}
+	}
+	return m, &details.Results, nil
+}
+
+// returns numerator over denominator as a percentage
+func ratioPercent(numerator float64, denominator float64) *float64 {
+	value := roundDecimal(numerator/denominator*100, 2)
+	return &value
+}
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"context"
+	"fineas/pkg/profile"
+	"log"
+	"os"
+	"time"
+
+	polygon "github.com/polygon-io/client-go/rest"
+	"github.com/polygon-io/client-go/rest/models"
+)
+
+// builds the optional profile enrichment sources from the environment
+func newProfileEnrichers() []profile.Enricher {
+	enrichers := []profile.Enricher{
+		profile.FileEnricher{Dir: getEnvDefault("PROFILE_ENRICHMENT_DIR", "../../utils/data/profiles")},
+	}
+	if enrichmentURL := os.Getenv("PROFILE_ENRICHMENT_URL"); enrichmentURL != "" {
+		enrichers = append(enrichers, profile.NewHTTPEnricher(enrichmentURL))
+	}
+	return enrichers
+}
+
+// gets the structured company profile from ticker details plus enrichment
+func getCompanyProfile(c *polygon.Client, ticker string) (*profile.CompanyProfile, error) {
+	ctx := context.Background()
+
+	// set params
+	date := models.Date(time.Now())
+	params := models.GetTickerDetailsParams{
+		Ticker: ticker,
+		Date:   &date,
+	}.WithDate(date)
+
+	// make request
+	res, err := c.GetTickerDetails(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	companyProfile := profile.FromTickerDetails(res.Results)
+
+	// enrichment is best effort, a failing source only loses its fields
+	for _, enricher := range newProfileEnrichers() {
+		enrichment, err := enricher.Enrich(ctx, ticker)
+		if err != nil {
+			log.Println("Error enriching profile for", ticker, err)
+			continue
+		}
+		companyProfile.Apply(enrichment)
+	}
+	return companyProfile, nil
+}
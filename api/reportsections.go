@@ -0,0 +1,118 @@
+package api
+
+import (
+	"fineas/pkg/crypto"
+	"os"
+)
+
+// default crypto templates used when the env file does not override them
+const (
+	defaultCryptoDescTemplate = `You are writing the asset profile section of a crypto market research report.
+Using only the asset information and annotations below, describe what the asset is, the problem it targets,
+its consensus and hashing design, its categories and ecosystem, and its origin. Do not invent founders,
+dates or figures that are not present. `
+	defaultCryptoFinTemplate = `You are writing the fundamentals section of a crypto market research report.
+Using only the supply, market and on-chain statistics and annotations below, analyze circulating versus max supply
+and dilution risk, market cap and 24h volume, market and exchange volume dominance, and any on-chain activity.
+Finish with an overall assessment of the asset's fundamental health. `
+	defaultIndexDescTemplate = `You are writing the profile section of a market research report on an index or ETF.
+Using only the fund information and annotations below, describe what the fund tracks, its issuer, its benchmark,
+how concentrated it is in its top holdings, and which companies dominate it. Do not invent figures that are not present. `
+	defaultIndexFinTemplate = `You are writing the fundamentals section of a market research report on an index or ETF.
+Using only the aggregated fundamentals and annotations below, analyze the weighted P/E and dividend yield, the sector
+breakdown and concentration risk, the expense ratio if it is an ETF, and how closely it tracks its benchmark.
+Finish with an overall assessment of the fund's valuation and diversification. `
+)
+
+// default peer comparison template used when the env file does not override it
+const defaultPeersTemplate = `You are writing the competitive position section of a market research report.
+Using only the comparables table below, compare the company with its peers on valuation (P/E, EV/EBITDA),
+margins, revenue growth and returns on capital, citing its percentile rank for each. Identify where it leads
+and lags its peers and what that implies about its competitive advantages. `
+
+// default valuation template used when the env file does not override it
+const defaultValuationTemplate = `You are writing the valuation section of a market research report.
+Using only the valuation below, state whether the stock looks cheap or expensive. Present the DCF fair value
+together with its assumptions, what growth the current price implies according to the reverse DCF, and the
+fair value ranges from peer multiples. Be explicit that the conclusions depend on the stated assumptions. `
+
+// appended to the equity description template so the model only narrates
+// profile fields that a source actually supplied
+const profileNarrationRule = ` Only narrate fields listed in the COMPANY PROFILE. If a field such as the CEO,
+year founded, headquarters or sector is listed as NOT AVAILABLE, leave it out rather than guessing. `
+
+// appended to the news template so the model's labels are grounded in the lexicon scores
+const sentimentReconcileRule = ` Each headline comes with a lexicon sentiment score and the data starts with a
+LEXICON SENTIMENT SIGNAL. Your sentiment labels must be consistent with these scores; where you disagree with
+a score, say so and explain why in one sentence. `
+
+// prompt templates and search annotation queries used for each report section
+type reportSectionSet struct {
+	AssetClass     string
+	StkTemplate    string
+	FinTemplate    string
+	NewsTemplate   string
+	DescTemplate   string
+	TaTemplate     string
+	StkAnnotation  string
+	FinAnnotation  string
+	DescAnnotation string
+	// optional sections, empty when the asset class does not support them
+	PeersTemplate     string
+	ValuationTemplate string
+}
+
+// picks the section set matching the ticker's asset class
+func getReportSectionSet(ticker string, currentYear string) reportSectionSet {
+	if isCryptoTicker(ticker) {
+		symbol := crypto.Symbol(ticker)
+		return reportSectionSet{
+			AssetClass:     "crypto",
+			StkTemplate:    os.Getenv("STK_TEMPLATE"),
+			FinTemplate:    getEnvDefault("CRYPTO_FIN_TEMPLATE", defaultCryptoFinTemplate),
+			NewsTemplate:   os.Getenv("NEWS_TEMPLATE") + sentimentReconcileRule,
+			DescTemplate:   getEnvDefault("CRYPTO_DESC_TEMPLATE", defaultCryptoDescTemplate),
+			TaTemplate:     os.Getenv("TA_TEMPLATE"),
+			StkAnnotation:  symbol + " crypto price information for " + currentYear,
+			FinAnnotation:  symbol + " tokenomics supply and on-chain activity for " + currentYear,
+			DescAnnotation: symbol + " crypto project description",
+		}
+	}
+
+	// a failing lookup only comes back for indexes, which use the fund sections
+	if holdings, err := getFundHoldings(ticker); holdings != nil || err != nil {
+		return reportSectionSet{
+			AssetClass:     "fund",
+			StkTemplate:    os.Getenv("STK_TEMPLATE"),
+			FinTemplate:    getEnvDefault("INDEX_FIN_TEMPLATE", defaultIndexFinTemplate),
+			NewsTemplate:   os.Getenv("NEWS_TEMPLATE") + sentimentReconcileRule,
+			DescTemplate:   getEnvDefault("INDEX_DESC_TEMPLATE", defaultIndexDescTemplate),
+			TaTemplate:     os.Getenv("TA_TEMPLATE"),
+			StkAnnotation:  ticker + " fund price performance for " + currentYear,
+			FinAnnotation:  ticker + " holdings valuation expense ratio and tracking for " + currentYear,
+			DescAnnotation: ticker + " index fund overview",
+		}
+	}
+
+	return reportSectionSet{
+		AssetClass:        "equity",
+		StkTemplate:       os.Getenv("STK_TEMPLATE"),
+		FinTemplate:       os.Getenv("FIN_TEMPLATE"),
+		NewsTemplate:      os.Getenv("NEWS_TEMPLATE") + sentimentReconcileRule,
+		DescTemplate:      os.Getenv("DESC_TEMPLATE") + profileNarrationRule,
+		TaTemplate:        os.Getenv("TA_TEMPLATE"),
+		StkAnnotation:     ticker + " financial price information for " + currentYear,
+		FinAnnotation:     ticker + " financials and 10k filings for " + currentYear,
+		DescAnnotation:    ticker + " company description",
+		PeersTemplate:     getEnvDefault("PEERS_TEMPLATE", defaultPeersTemplate),
+		ValuationTemplate: getEnvDefault("VALUATION_TEMPLATE", defaultValuationTemplate),
+	}
+}
+
+// reads an env variable, falling back to the default when unset
+func getEnvDefault(key string, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
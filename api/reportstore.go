@@ -0,0 +1,61 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// env file the aggregator loads, the golden tests point it at a fixture
+var aggregatorEnvFile = "../../.env"
+
+// clock of the aggregator for the report year and the day reports are
+// ingested under, the golden tests fix it
+var aggregatorNow = time.Now
+
+// reportStore keeps what the aggregator writes: the report of a ticker and
+// the request log
+type reportStore interface {
+	// ReplaceReport stores a ticker's report in place of the previous one
+	ReplaceReport(ctx context.Context, ticker string, report bson.M) error
+	InsertLog(ctx context.Context, entry interface{}) error
+	Close(ctx context.Context) error
+}
+
+// opens the aggregator's store, Mongo outside of tests
+var openReportStore = func(ctx context.Context) (reportStore, error) {
+	client, err := connectMongo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &mongoReportStore{client: client}, nil
+}
+
+// keeps reports in FinancialInformation.TickersList and logs in
+// MicroserviceLogs.AggregatorServiceLogs
+type mongoReportStore struct {
+	client *mongo.Client
+}
+
+func (s *mongoReportStore) ReplaceReport(ctx context.Context, ticker string, report bson.M) error {
+	collection := s.client.Database("FinancialInformation").Collection("TickersList")
+	filter := bson.M{"Ticker": ticker}
+	if collection.FindOne(ctx, filter).Err() == nil {
+		if _, err := collection.DeleteOne(ctx, filter); err != nil {
+			return err
+		}
+	}
+	_, err := collection.InsertOne(ctx, report)
+	return err
+}
+
+func (s *mongoReportStore) InsertLog(ctx context.Context, entry interface{}) error {
+	_, err := s.client.Database("MicroserviceLogs").Collection("AggregatorServiceLogs").InsertOne(ctx, entry)
+	return err
+}
+
+func (s *mongoReportStore) Close(ctx context.Context) error {
+	return s.client.Disconnect(ctx)
+}
@@ -20,6 +20,8 @@ type PostDataInfo struct {
 	NewsSummary       string `bson:"NewsSummary"`
 	CompanyDesc       string `bson:"CompanyDesc"`
 	TechnicalAnalysis string `bson:"TechnicalAnalysis"`
+	PeerComparison    string `bson:"PeerComparison,omitempty" json:",omitempty"`
+	Valuation         string `bson:"Valuation,omitempty" json:",omitempty"`
 }
 
 func RetrieveData(w http.ResponseWriter, r *http.Request) {
@@ -48,7 +50,8 @@ func RetrieveData(w http.ResponseWriter, r *http.Request) {
 
 	// Assuming the collection is named "tickerslist"
 	collection := client.Database("FinancialInformation").Collection("TickersList")
-	ctx, _ := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
 
 	// Find the document
 	err = collection.FindOne(ctx, bson.M{"Ticker": ticker}).Decode(&postDataInfo)
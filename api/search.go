@@ -1,43 +1,100 @@
 package api
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
-	"fmt"
 	"log"
 	"net/http"
-	"net/url"
 	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
+	"fineas/pkg/search"
 	"fineas/pkg/serviceauth"
+	"fineas/pkg/services"
 
 	"github.com/joho/godotenv"
 )
 
 // SearchResult defines the structure for a single search result
-type SearchResult struct {
-	Title   string `json:"title"`
-	URL     string `json:"url"`
-	Snippet string `json:"snippet"`
-	Date    string `json:"date"`
-}
+type SearchResult = search.Result
 
 // Response structure to include both the refined HTML and structured JSON
-type SearchResponse struct {
-	Results []SearchResult `json:"results"`
+type SearchResponse = services.SearchResponse
+
+// SearchRequest is the JSON body accepted by /search, declared in the service contract
+type SearchRequest = services.SearchRequest
+
+var (
+	searchProvider     *search.Cached
+	searchProviderOnce sync.Once
+)
+
+// returns the shared cached search provider. SEARCH_PROVIDER picks google,
+// brave, bing or fixture and SEARCH_CACHE_TTL_MINUTES sets the cache lifetime
+func getSearchProvider() *search.Cached {
+	searchProviderOnce.Do(func() {
+		var provider search.Provider
+		switch getEnvDefault("SEARCH_PROVIDER", "google") {
+		case "brave":
+			provider = search.NewBrave(os.Getenv("BRAVE_API_KEY"))
+		case "bing":
+			provider = search.NewBing(os.Getenv("BING_API_KEY"))
+		case "fixture":
+			provider = search.Fixture{Path: getEnvDefault("SEARCH_FIXTURE_PATH", "../../utils/data/search_fixture.json")}
+		default:
+			provider = search.NewGoogleCSE(os.Getenv("GOOGLE_API_KEY"), os.Getenv("GOOGLE_CSE_ID"))
+		}
+		ttlMinutes, err := strconv.Atoi(getEnvDefault("SEARCH_CACHE_TTL_MINUTES", "360"))
+		if err != nil {
+			ttlMinutes = 360
+		}
+		searchProvider = search.NewCached(provider, time.Duration(ttlMinutes)*time.Minute)
+	})
+	return searchProvider
+}
+
+// builds the provider request, applying the section's SEARCH_SITES_<SECTION>,
+// SEARCH_EXCLUDE_<SECTION> and SEARCH_DATE_<SECTION> settings. Request values
+// win over the section's, excluded sites are combined
+func buildSearchRequest(requestData SearchRequest) (search.Request, error) {
+	req := search.Request{
+		Query:        requestData.Query,
+		Page:         requestData.Page,
+		PageSize:     requestData.PageSize,
+		DateRestrict: requestData.DateRestrict,
+		Sites:        requestData.Sites,
+		ExcludeSites: requestData.ExcludeSites,
+	}
+	if requestData.Section != "" {
+		section := strings.ToUpper(requestData.Section)
+		if len(req.Sites) == 0 {
+			req.Sites = splitList(os.Getenv("SEARCH_SITES_" + section))
+		}
+		req.ExcludeSites = append(req.ExcludeSites, splitList(os.Getenv("SEARCH_EXCLUDE_"+section))...)
+		if req.DateRestrict == "" {
+			req.DateRestrict = os.Getenv("SEARCH_DATE_" + section)
+		}
+	}
+	return req.Normalize()
 }
 
-// GoogleAPIResponse represents the structure of the Google Custom Search API response
-type GoogleAPIResponse struct {
-	Items []struct {
-		Title   string `json:"title"`
-		Link    string `json:"link"`
-		Snippet string `json:"snippet"`
-	} `json:"items"`
+// splits a comma separated list, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
 }
 
-// SearchHandler handles the search request and fetches Google search results using the Custom Search API
+// SearchHandler handles the search request and fetches web search results from the configured provider
 func SearchHandler(w http.ResponseWriter, r *http.Request) {
 	// Load environment variables
 	err := godotenv.Load("../../.env")
@@ -54,7 +111,9 @@ func SearchHandler(w http.ResponseWriter, r *http.Request) {
 	hash.Write([]byte(PASS_KEY))
 	getPassHash := hash.Sum(nil)
 	passHash := hex.EncodeToString(getPassHash)
-	serviceauth.ServiceAuthMiddleware(w, r, eventSequenceArray, passHash)
+	if !serviceauth.ServiceAuthMiddleware(w, r, eventSequenceArray, passHash) {
+		return
+	}
 
 	// Ensure the request method is POST
 	if r.Method != http.MethodPost {
@@ -63,80 +122,41 @@ func SearchHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Parse the JSON body to get the query
-	var requestData struct {
-		Query string `json:"query"`
-	}
-	err = json.NewDecoder(r.Body).Decode(&requestData)
+	requestData, err := services.DecodeRequest(r, services.Search)
 	if err != nil || requestData.Query == "" {
+		log.Printf("Invalid search request: %v", err)
 		http.Error(w, "", http.StatusBadRequest)
 		return
 	}
 
-	query := requestData.Query
-	log.Printf("Received search query: %s", query)
-
-	// Set up Google Custom Search API parameters
-	apiKey := os.Getenv("GOOGLE_API_KEY") // Load from environment variables
-	cx := os.Getenv("GOOGLE_CSE_ID")      // Load Custom Search Engine ID from environment variables
-	numResults := 10                      // Number of results to fetch
-	apiURL := "https://customsearch.googleapis.com/customsearch/v1"
-
-	// Build the request URL
-	reqURL, err := url.Parse(apiURL)
+	req, err := buildSearchRequest(requestData)
 	if err != nil {
-		http.Error(w, "Error parsing API URL", http.StatusInternalServerError)
-		log.Fatalf("Error parsing API URL: %v", err)
-	}
-
-	// Add query parameters
-	queryParams := reqURL.Query()
-	queryParams.Set("key", apiKey)
-	queryParams.Set("cx", cx)
-	queryParams.Set("q", query)
-	queryParams.Set("num", fmt.Sprintf("%d", numResults))
-	reqURL.RawQuery = queryParams.Encode()
-
-	// Make the API request
-	resp, err := http.Get(reqURL.String())
-	if err != nil {
-		http.Error(w, "Error making API request", http.StatusInternalServerError)
-		log.Fatalf("Error making API request: %v", err)
-	}
-	defer resp.Body.Close()
-
-	// Check the response status
-	if resp.StatusCode != http.StatusOK {
-		http.Error(w, "Error fetching search results", http.StatusInternalServerError)
-		log.Printf("Error: Received status code %d from Google API", resp.StatusCode)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	log.Printf("Received search query: %s", req.Query)
 
-	// Parse the API response
-	var apiResponse GoogleAPIResponse
-	err = json.NewDecoder(resp.Body).Decode(&apiResponse)
+	provider := getSearchProvider()
+	ctx, cancel := context.WithTimeout(r.Context(), 20*time.Second)
+	defer cancel()
+	results, cached, err := provider.Lookup(ctx, req)
 	if err != nil {
-		http.Error(w, "Error parsing API response", http.StatusInternalServerError)
-		log.Printf("Error parsing API response: %v", err)
+		http.Error(w, "Error fetching search results", http.StatusBadGateway)
+		log.Printf("Error fetching search results from %s: %v", provider.Name(), err)
 		return
 	}
-
-	// Extract search results
-	var searchResults []SearchResult
-	for _, item := range apiResponse.Items {
-		searchResults = append(searchResults, SearchResult{
-			Title:   item.Title,
-			URL:     item.Link,
-			Snippet: item.Snippet,
-			Date:    "", // Date field is not provided by the API
-		})
-	}
+	searchResults := search.FilterSites(results, req.Sites, req.ExcludeSites)
 
 	// Log total number of results found
-	log.Printf("Total results found: %d", len(searchResults))
+	log.Printf("Total results found: %d (cached: %t)", len(searchResults), cached)
 
 	// Create the response structure with the results
 	response := SearchResponse{
-		Results: searchResults,
+		Results:  searchResults,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+		Provider: provider.Name(),
+		Cached:   cached,
 	}
 
 	// Convert the response to JSON
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"context"
+	"fineas/pkg/news"
+	"fineas/pkg/sentiment"
+	"log"
+	"os"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// builds the news sentiment scorer, SENTIMENT_LEXICON_PATH adds word,polarity
+// lines on top of the default finance lexicon
+func newSentimentScorer() *sentiment.Scorer {
+	path := os.Getenv("SENTIMENT_LEXICON_PATH")
+	if path == "" {
+		return sentiment.NewScorer(nil)
+	}
+	lexicon, err := sentiment.LoadLexicon(path)
+	if err != nil {
+		log.Println("could not load sentiment lexicon, using the default:", err)
+		return sentiment.NewScorer(nil)
+	}
+	return sentiment.NewScorer(lexicon)
+}
+
+// scores every article from its headline and its snippet or extracted text.
+// The observations for the daily series score the headline and snippet only,
+// so the stored value is the same with or without full_text
+func scoreArticles(scorer *sentiment.Scorer, articles []news.Article) []sentiment.Observation {
+	var observations []sentiment.Observation
+	for i := range articles {
+		score := scorer.Score(articles[i].Title, articles[i].Snippet)
+		observations = append(observations, sentiment.Observation{Time: articles[i].PublishedAt, Score: score})
+		if articles[i].Text != "" {
+			score = scorer.Score(articles[i].Title, articles[i].Text)
+		}
+		articles[i].Sentiment = &score
+	}
+	return observations
+}
+
+// upserts the daily sentiment points for a ticker into
+// FinancialInformation.NewsSentiment and returns the stored series since the
+// cutoff. The cutoff day is only partly inside the lookback window, so its
+// point is not stored over a value that may cover the whole day
+func storeNewsSentiment(client *mongo.Client, ticker string, points []sentiment.Point, cutoff time.Time) ([]sentiment.Point, error) {
+	collection := client.Database("FinancialInformation").Collection("NewsSentiment")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cutoffDay := cutoff.UTC().Format("2006-01-02")
+	for _, p := range points {
+		if p.Date <= cutoffDay {
+			continue
+		}
+		filter := bson.M{"ticker": p.Ticker, "date": p.Date}
+		update := bson.M{"$set": bson.M{"value": p.Value, "label": p.Label, "articles": p.Articles, "updated_at": time.Now()}}
+		if _, err := collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true)); err != nil {
+			return points, err
+		}
+	}
+
+	filter := bson.M{"ticker": ticker, "date": bson.M{"$gte": cutoffDay}}
+	cursor, err := collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "date", Value: 1}}))
+	if err != nil {
+		return points, err
+	}
+	var stored []sentiment.Point
+	if err := cursor.All(ctx, &stored); err != nil {
+		return points, err
+	}
+	return stored, nil
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fineas/pkg/services"
+	"log"
+	"os"
+	"strings"
+)
+
+// builds the typed service client for the aggregator. The *_SERVICE_URL
+// variables override the contract's default base URLs, report sections use
+// the LLM service at REPORT_LLM_SERVICE_URL
+func newServiceClient(passHash string) *services.Client {
+	return services.New(passHash, map[services.Service]string{
+		services.ServiceStk:       os.Getenv("STK_SERVICE_URL"),
+		services.ServiceFin:       os.Getenv("FIN_SERVICE_URL"),
+		services.ServiceNews:      os.Getenv("NEWS_SERVICE_URL"),
+		services.ServiceDesc:      os.Getenv("DESC_SERVICE_URL"),
+		services.ServiceTA:        os.Getenv("TA_SERVICE_URL"),
+		services.ServicePeers:     os.Getenv("PEERS_SERVICE_URL"),
+		services.ServiceValuation: os.Getenv("VALUATION_SERVICE_URL"),
+		services.ServiceSearch:    os.Getenv("SEARCH_SERVICE_URL"),
+		services.ServiceLLM:       getEnvDefault("REPORT_LLM_SERVICE_URL", "http://0.0.0.0:5432"),
+	})
+}
+
+// runs the search annotation query of a report section, returning the results
+// as JSON for the prompt or an empty string when the search failed
+func getSearchAnnotation(ctx context.Context, svc *services.Client, query string, section string) string {
+	if query == "" {
+		return ""
+	}
+	resp, err := services.Post(ctx, svc, services.Search, services.SearchRequest{Query: query, Section: section})
+	if err != nil {
+		log.Println("search annotation failed for", section, "section:", err)
+		return ""
+	}
+	annotations, err := json.Marshal(resp.Results)
+	if err != nil {
+		return ""
+	}
+	return string(annotations)
+}
+
+// writes a report section from the service data, empty when the service
+// call failed or the model could not be reached
+func getSectionInference(ctx context.Context, svc *services.Client, section string, serviceErr error, ticker string, data string, template string, eventSequenceArray []string) (string, []string) {
+	if serviceErr != nil {
+		log.Println(section, "service failed:", serviceErr)
+		return "", append(eventSequenceArray, section+" prompt inference failed \n")
+	}
+	inference, err := services.Post(ctx, svc, services.LLM, services.LLMRequest{Prompt: template + "For ASSET_NAME: " + ticker + "\n" + data})
+	if err != nil {
+		log.Println(section, "prompt inference failed:", err)
+		return "", append(eventSequenceArray, section+" prompt inference failed \n")
+	}
+	return strings.Trim(string(inference), "{}"), append(eventSequenceArray, "collected "+section+" prompt inference \n")
+}
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"context"
+
+	polygon "github.com/polygon-io/client-go/rest"
+	"github.com/polygon-io/client-go/rest/models"
+)
+
+// lists the most recent financial statements for the timeframe, newest first
+func getFinancialStatements(c *polygon.Client, ticker string, timeframe models.Timeframe, limit int) ([]models.StockFinancial, error) {
+	params := models.ListStockFinancialsParams{}.
+		WithTicker(ticker).
+		WithTimeframe(timeframe).
+		WithSort(models.PeriodOfReportDate).
+		WithOrder(models.Desc).
+		WithLimit(limit)
+
+	var statements []models.StockFinancial
+	iter := c.VX.ListStockFinancials(context.Background(), params)
+	for iter.Next() {
+		statements = append(statements, iter.Item())
+		if len(statements) >= limit {
+			break
+		}
+	}
+	if iter.Err() != nil {
+		return nil, iter.Err()
+	}
+	return statements, nil
+}
+
+// gets a line item such as revenues from a statement section such as income_statement
+func getStatementValue(statement models.StockFinancial, section string, key string) (float64, bool) {
+	items, ok := statement.Financials[section]
+	if !ok {
+		return 0, false
+	}
+	item, ok := items[key]
+	if !ok {
+		return 0, false
+	}
+	return item.Value, true
+}
+
+// gets the first line item present from a list of alternative keys
+func getFirstStatementValue(statement models.StockFinancial, section string, keys ...string) (float64, bool) {
+	for _, key := range keys {
+		if value, ok := getStatementValue(statement, section, key); ok {
+			return value, true
+		}
+	}
+	return 0, false
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fineas/pkg/serviceauth"
+	"fineas/pkg/services"
 	"fmt"
 	"log"
 	"math"
@@ -40,9 +41,7 @@ func STKService(w http.ResponseWriter, r *http.Request) {
 		EventSequence   []string
 	}
 
-	type stkOUTPUT struct {
-		Result string
-	}
+	type stkOUTPUT = services.ResultResponse
 
 	var stkLog stkLOG
 	var eventSequenceArray []string
@@ -6,6 +6,7 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fineas/pkg/serviceauth"
+	"fineas/pkg/services"
 	"fmt"
 	"io/ioutil"
 	"log"
@@ -42,9 +43,7 @@ func TechnicalAnalysisService(w http.ResponseWriter, r *http.Request) {
 		EventSequence   []string
 	}
 
-	type taOUTPUT struct {
-		Result string
-	}
+	type taOUTPUT = services.ResultResponse
 
 	var taLog taLOG
 	var eventSequenceArray []string
@@ -0,0 +1,397 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fineas/pkg/serviceauth"
+	"fineas/pkg/services"
+	"fineas/pkg/valuation"
+	"fmt"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	polygon "github.com/polygon-io/client-go/rest"
+	"github.com/polygon-io/client-go/rest/models"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// default valuation assumptions, each can be overridden by a query parameter
+const (
+	defaultProjectionYears   = 5
+	defaultTerminalGrowth    = 2.5
+	defaultRiskFreeRate      = 4.25
+	defaultEquityRiskPremium = 5.0
+	defaultCostOfDebt        = 5.5
+	defaultTaxRate           = 21.0
+	defaultBetaBenchmark     = "SPY"
+)
+
+// cash flow statement keys of capital expenditure, reported as an outflow
+var capitalExpenditureKeys = []string{
+	"capital_expenditure",
+	"payments_to_acquire_property_plant_and_equipment",
+	"purchase_of_property_plant_and_equipment",
+}
+
+// handles the valuation request
+func ValuationService(w http.ResponseWriter, r *http.Request) {
+
+	type VALUATIONLOG struct {
+		Timestamp       time.Time
+		ExecutionTimeMs float32
+		RequestIP       string
+		EventSequence   []string
+	}
+
+	type valuationOUTPUT = services.ValuationResponse
+
+	var valuationLog VALUATIONLOG
+	var eventSequenceArray []string
+	var output valuationOUTPUT
+
+	//load information structures
+	startTime := time.Now()
+	queryParams := r.URL.Query()
+	err := godotenv.Load("../../.env") // load the .env file
+	if err != nil {
+		log.Fatal("Error loading .env file")
+	}
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		fmt.Fprintf(w, "Error parsing IP address: %v", err)
+		return
+	}
+	eventSequenceArray = append(eventSequenceArray, "collected request ip \n")
+	valuationLog.RequestIP = ip
+
+	// secure service with pass key hash
+	PASS_KEY := os.Getenv("PASS_KEY")
+	hash := sha256.New()
+	hash.Write([]byte(PASS_KEY))
+	getPassHash := hash.Sum(nil)
+	passHash := hex.EncodeToString(getPassHash)
+	if !serviceauth.ServiceAuthMiddleware(w, r, eventSequenceArray, passHash) {
+		return
+	}
+
+	// connnect to mongodb
+	MONGO_DB_LOGGER_PASSWORD := os.Getenv("MONGO_DB_LOGGER_PASSWORD")
+	serverAPI := options.ServerAPI(options.ServerAPIVersion1)
+	mongoURI := "mongodb+srv://kobenaidun:" + MONGO_DB_LOGGER_PASSWORD + "@cluster0.z9znpv9.mongodb.net/?retryWrites=true&w=majority"
+	opts := options.Client().ApplyURI(mongoURI).SetServerAPIOptions(serverAPI)
+	client, err := mongo.Connect(context.TODO(), opts)
+	if err != nil {
+		eventSequenceArray = append(eventSequenceArray, "could not connect to database client \n")
+		panic(err)
+	}
+	defer func() {
+		if err = client.Disconnect(context.TODO()); err != nil {
+			eventSequenceArray = append(eventSequenceArray, "could not connect to database \n")
+			panic(err)
+		}
+	}()
+
+	// polygon API connection
+	API_KEY := os.Getenv("API_KEY")
+	c := polygon.New(API_KEY)
+
+	// ticker input checking
+	ticker := strings.ToUpper(queryParams.Get("ticker"))
+	if len(ticker) == 0 {
+		log.Println("Missing required parameter 'ticker' in the query string")
+		http.Error(w, "Error: Bad Request(400), Missing required parameter 'ticker' in the query string.", http.StatusBadRequest)
+		eventSequenceArray = append(eventSequenceArray, "missing ticker \n")
+		return
+	}
+	if isCryptoTicker(ticker) || strings.HasPrefix(ticker, "I:") {
+		http.Error(w, "Error: Bad Request(400), Valuation models are only available for equities.", http.StatusBadRequest)
+		return
+	}
+	eventSequenceArray = append(eventSequenceArray, "ticker collected \n")
+
+	report, err := getValuation(c, ticker, queryParams)
+	if err != nil {
+		log.Println("Error building valuation:", err)
+		eventSequenceArray = append(eventSequenceArray, "could not build valuation \n")
+		http.Error(w, "500 Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	output.Valuation = report
+	output.Result = report.Summary()
+	eventSequenceArray = append(eventSequenceArray, "built valuation \n")
+
+	valuationJson, err := json.Marshal(output)
+	if err != nil {
+		eventSequenceArray = append(eventSequenceArray, "Error: could not marshal valuation struct into json"+err.Error()+"\n")
+	}
+
+	endTime := time.Now()
+	elapsedTime := endTime.Sub(startTime)
+	valuationLog.ExecutionTimeMs = float32(elapsedTime.Milliseconds())
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(valuationJson)
+	valuationLog.Timestamp = time.Now()
+
+	// insert the log into the database
+	eventSequenceArray = append(eventSequenceArray, "successfully served valuation data \n")
+	valuationLog.EventSequence = eventSequenceArray
+	db := client.Database("MicroserviceLogs")
+	collection := db.Collection("ValuationServiceLogs")
+	_, err = collection.InsertOne(context.TODO(), valuationLog)
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// gathers model inputs from statements, prices and peers and runs the valuation models
+func getValuation(c *polygon.Client, ticker string, queryParams url.Values) (valuation.Report, error) {
+	ctx := context.Background()
+	sources := make(map[string]string)
+
+	// current price, as served by the stk service
+	prevParams := models.GetPreviousCloseAggParams{Ticker: ticker}.WithAdjusted(true)
+	prev, err := c.GetPreviousCloseAgg(ctx, prevParams)
+	if err != nil {
+		return valuation.Report{}, err
+	}
+	if len(prev.Results) == 0 {
+		return valuation.Report{}, fmt.Errorf("no previous close for %s", ticker)
+	}
+	price := prev.Results[0].Close
+	sources["current_price"] = "polygon previous close"
+
+	date := models.Date(time.Now())
+	details, err := c.GetTickerDetails(ctx, models.GetTickerDetailsParams{Ticker: ticker}.WithDate(date))
+	if err != nil {
+		return valuation.Report{}, err
+	}
+	shares := float64(details.Results.ShareClassSharesOutstanding)
+	if shares == 0 {
+		shares = float64(details.Results.WeightedSharesOutstanding)
+	}
+	sources["shares_outstanding"] = "polygon ticker details"
+
+	statements, err := getFinancialStatements(c, ticker, models.TFAnnual, 4)
+	if err != nil {
+		return valuation.Report{}, err
+	}
+	if len(statements) == 0 {
+		return valuation.Report{}, fmt.Errorf("no annual statements for %s", ticker)
+	}
+	latest := statements[0]
+	period := "FY" + latest.FiscalYear
+
+	// free cash flow: operating cash flow less capital expenditure. Investing
+	// outflows, which also count securities and acquisitions, stand in only
+	// when the statement has no capital expenditure line
+	operatingCF, _ := getStatementValue(latest, "cash_flow_statement", "net_cash_flow_from_operating_activities")
+	baseFCF := operatingCF
+	if capex, ok := getFirstStatementValue(latest, "cash_flow_statement", capitalExpenditureKeys...); ok {
+		baseFCF -= math.Abs(capex)
+		sources["base_fcf"] = "operating cash flow less capital expenditure, " + period
+	} else {
+		investingCF, _ := getStatementValue(latest, "cash_flow_statement", "net_cash_flow_from_investing_activities")
+		baseFCF += math.Min(investingCF, 0)
+		sources["base_fcf"] = "operating cash flow plus investing outflows, no capital expenditure reported, " + period
+	}
+
+	debt, _ := getStatementValue(latest, "balance_sheet", "long_term_debt")
+	cash, _ := getStatementValue(latest, "balance_sheet", "cash")
+	sources["net_debt"] = "long term debt less cash, " + period
+
+	// historical revenue CAGR seeds the first year growth
+	growth := 5.0
+	sources["growth_rate"] = "default"
+	if len(statements) > 1 {
+		first, okFirst := getStatementValue(statements[len(statements)-1], "income_statement", "revenues")
+		last, okLast := getStatementValue(latest, "income_statement", "revenues")
+		if okFirst && okLast && first > 0 && last > 0 {
+			years := float64(len(statements) - 1)
+			growth = (math.Pow(last/first, 1/years) - 1) * 100
+			growth = math.Max(-10, math.Min(25, growth))
+			sources["growth_rate"] = fmt.Sprintf("%d year revenue CAGR, clamped to -10%% to 25%%", len(statements)-1)
+		}
+	}
+
+	// effective tax rate from the income statement
+	taxRate := defaultTaxRate
+	sources["tax_rate"] = "default"
+	taxes, okTaxes := getStatementValue(latest, "income_statement", "income_tax_expense_benefit")
+	pretax, okPretax := getStatementValue(latest, "income_statement", "income_loss_from_continuing_operations_before_tax")
+	if okTaxes && okPretax && pretax > 0 {
+		taxRate = math.Max(0, math.Min(35, taxes/pretax*100))
+		sources["tax_rate"] = "effective tax rate, " + period
+	}
+
+	// cost of debt from interest expense over debt
+	costOfDebt := defaultCostOfDebt
+	sources["pre_tax_cost_of_debt"] = "default"
+	if interest, ok := getStatementValue(latest, "income_statement", "interest_expense_operating"); ok && debt > 0 && interest > 0 {
+		costOfDebt = interest / debt * 100
+		sources["pre_tax_cost_of_debt"] = "interest expense over long term debt, " + period
+	}
+
+	beta := 1.0
+	sources["beta"] = "default"
+	if b, err := getBeta(c, ticker, defaultBetaBenchmark); err != nil {
+		log.Println("Error computing beta for", ticker, err)
+	} else {
+		beta = b
+		sources["beta"] = "one year daily returns against " + defaultBetaBenchmark
+	}
+
+	marketCap := price * shares
+	in := valuation.DCFInputs{
+		BaseFCF:        baseFCF,
+		Years:          defaultProjectionYears,
+		GrowthRate:     growth,
+		TerminalGrowth: defaultTerminalGrowth,
+		WACC: valuation.WACCInputs{
+			RiskFreeRate:      defaultRiskFreeRate,
+			EquityRiskPremium: defaultEquityRiskPremium,
+			Beta:              beta,
+			PreTaxCostOfDebt:  costOfDebt,
+			TaxRate:           taxRate,
+			EquityValue:       marketCap,
+			DebtValue:         debt,
+		},
+		NetDebt:           debt - cash,
+		SharesOutstanding: shares,
+		CurrentPrice:      price,
+	}
+	sources["risk_free_rate"] = "default"
+	sources["equity_risk_premium"] = "default"
+	sources["terminal_growth"] = "default"
+	applyValuationOverrides(&in, queryParams, sources)
+
+	// multiples use the peer set from the peers service
+	netIncome, _ := getFirstStatementValue(latest, "income_statement", "net_income_loss_attributable_to_parent", "net_income_loss")
+	operatingIncome, _ := getStatementValue(latest, "income_statement", "operating_income_loss")
+	depreciation, _ := getStatementValue(latest, "income_statement", "depreciation_and_amortization")
+	revenue, _ := getStatementValue(latest, "income_statement", "revenues")
+	equity, _ := getFirstStatementValue(latest, "balance_sheet", "equity_attributable_to_parent", "equity")
+	multiples := valuation.MultiplesInputs{
+		EBITDA:            operatingIncome + depreciation,
+		Revenue:           revenue,
+		BookValue:         equity,
+		NetDebt:           in.NetDebt,
+		SharesOutstanding: shares,
+	}
+	if shares > 0 {
+		multiples.EPS = netIncome / shares
+	}
+	if queryParams.Get("multiples") != "false" {
+		if table, err := getPeerComparison(c, ticker, 5); err != nil {
+			log.Println("Error fetching peer multiples for", ticker, err)
+		} else {
+			for _, row := range table.Rows {
+				if strings.EqualFold(row.Ticker, ticker) {
+					continue
+				}
+				if row.PE != nil {
+					multiples.PeerPE = append(multiples.PeerPE, *row.PE)
+				}
+				if row.EVToEBITDA != nil {
+					multiples.PeerEVToEBITDA = append(multiples.PeerEVToEBITDA, *row.EVToEBITDA)
+				}
+				if row.PS != nil {
+					multiples.PeerPS = append(multiples.PeerPS, *row.PS)
+				}
+				if row.PB != nil {
+					multiples.PeerPB = append(multiples.PeerPB, *row.PB)
+				}
+			}
+			sources["peer_multiples"] = "peers " + strings.Join(table.Peers, ", ")
+		}
+	}
+
+	return valuation.Run(ticker, in, multiples, sources), nil
+}
+
+// applies assumption overrides from the query string and records them as user supplied
+func applyValuationOverrides(in *valuation.DCFInputs, queryParams url.Values, sources map[string]string) {
+	overrides := map[string]*float64{
+		"base_fcf":             &in.BaseFCF,
+		"growth_rate":          &in.GrowthRate,
+		"terminal_growth":      &in.TerminalGrowth,
+		"discount_rate":        &in.DiscountRate,
+		"risk_free_rate":       &in.WACC.RiskFreeRate,
+		"equity_risk_premium":  &in.WACC.EquityRiskPremium,
+		"beta":                 &in.WACC.Beta,
+		"pre_tax_cost_of_debt": &in.WACC.PreTaxCostOfDebt,
+		"tax_rate":             &in.WACC.TaxRate,
+	}
+	for key, target := range overrides {
+		value := queryParams.Get(key)
+		if value == "" {
+			continue
+		}
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			*target = f
+			sources[key] = "request override"
+		}
+	}
+	if years, err := strconv.Atoi(queryParams.Get("years")); err == nil && years > 0 && years <= 20 {
+		in.Years = years
+		sources["years"] = "request override"
+	}
+}
+
+// computes beta from a year of daily returns against the benchmark
+func getBeta(c *polygon.Client, ticker string, benchmark string) (float64, error) {
+	to := time.Now()
+	from := to.AddDate(-1, 0, 0)
+	stockCloses, err := getDailyCloses(c, ticker, from, to)
+	if err != nil {
+		return 0, err
+	}
+	benchCloses, err := getDailyCloses(c, benchmark, from, to)
+	if err != nil {
+		return 0, err
+	}
+
+	var stockReturns, benchReturns []float64
+	var prevStock, prevBench float64
+	for _, day := range sortedDays(stockCloses) {
+		benchClose, ok := benchCloses[day]
+		if !ok {
+			continue
+		}
+		if prevStock > 0 && prevBench > 0 {
+			stockReturns = append(stockReturns, stockCloses[day]/prevStock-1)
+			benchReturns = append(benchReturns, benchClose/prevBench-1)
+		}
+		prevStock, prevBench = stockCloses[day], benchClose
+	}
+	if len(stockReturns) < 20 {
+		return 0, fmt.Errorf("not enough price history to compute beta")
+	}
+
+	var meanStock, meanBench float64
+	for i := range stockReturns {
+		meanStock += stockReturns[i]
+		meanBench += benchReturns[i]
+	}
+	meanStock /= float64(len(stockReturns))
+	meanBench /= float64(len(benchReturns))
+	var covariance, variance float64
+	for i := range stockReturns {
+		covariance += (stockReturns[i] - meanStock) * (benchReturns[i] - meanBench)
+		variance += (benchReturns[i] - meanBench) * (benchReturns[i] - meanBench)
+	}
+	if variance == 0 {
+		return 0, fmt.Errorf("benchmark returns have no variance")
+	}
+	return roundDecimal(covariance/variance, 2), nil
+}
@@ -0,0 +1,42 @@
+package api
+
+import (
+	"fineas/pkg/vectorstore"
+	"os"
+	"sync"
+)
+
+var (
+	vectorStore     vectorstore.Store
+	vectorStoreErr  error
+	vectorStoreOnce sync.Once
+)
+
+// returns the shared vector store. VECTOR_STORE is "pinecone" for the
+// PINECONE_HOST index or "local" for the embedded store persisted at
+// VECTOR_STORE_PATH, searched with the VECTOR_STORE_INDEX "hnsw" graph or "flat" scan
+func getVectorStore() (vectorstore.Store, error) {
+	vectorStoreOnce.Do(func() {
+		switch getEnvDefault("VECTOR_STORE", "pinecone") {
+		case "local":
+			local, err := vectorstore.OpenLocal(vectorstore.LocalOptions{
+				Path:  getEnvDefault("VECTOR_STORE_PATH", "../../utils/data/vectors.json"),
+				Index: getEnvDefault("VECTOR_STORE_INDEX", vectorstore.IndexHNSW),
+				HNSW:  vectorstore.DefaultHNSWParams(),
+			})
+			if err != nil {
+				vectorStoreErr = err
+				return
+			}
+			vectorStore = local
+		default:
+			hosted, err := vectorstore.NewPinecone(os.Getenv("PINECONE_API_KEY"), os.Getenv("PINECONE_HOST"))
+			if err != nil {
+				vectorStoreErr = err
+				return
+			}
+			vectorStore = hosted
+		}
+	})
+	return vectorStore, vectorStoreErr
+}
@@ -17,49 +17,70 @@ func main() {
 	queryKeyFile := "../../utils/keys/query/privkey.pem"
 	router := gin.Default()
 
+	http.HandleFunc("/openapi.json", api.OpenAPIHandler)
+
 	go func() {
-		http.Handle("/", api.CorsMiddleware(http.HandlerFunc(api.HandleQuoteRequest)))
+		http.Handle("/", api.CorsMiddleware(api.ValidateRequest(http.HandlerFunc(api.HandleQuoteRequest))))
 		log.Println(http.ListenAndServe(":8080", nil))
 	}()
 
 	go func() {
-		http.Handle("/stk", api.CorsMiddleware(http.HandlerFunc(api.STKService)))
+		http.Handle("/stk", api.CorsMiddleware(api.ValidateRequest(http.HandlerFunc(api.STKService))))
 		log.Println(http.ListenAndServe(":8081", nil))
 	}()
 
 	go func() {
-		http.Handle("/fin", api.CorsMiddleware(http.HandlerFunc(api.FinService)))
+		http.Handle("/fin", api.CorsMiddleware(api.ValidateRequest(http.HandlerFunc(api.FinService))))
 		log.Println(http.ListenAndServe(":8082", nil))
 	}()
 
 	go func() {
-		http.Handle("/news", api.CorsMiddleware(http.HandlerFunc(api.NewsService)))
+		http.Handle("/news", api.CorsMiddleware(api.ValidateRequest(http.HandlerFunc(api.NewsService))))
 		log.Fatal(http.ListenAndServe(":8083", nil))
 	}()
 
 	go func() {
-		http.Handle("/desc", api.CorsMiddleware(http.HandlerFunc(api.DescriptionService)))
+		http.Handle("/desc", api.CorsMiddleware(api.ValidateRequest(http.HandlerFunc(api.DescriptionService))))
 		log.Println(http.ListenAndServe(":8084", nil))
 	}()
 
 	go func() {
-		http.Handle("/ta", api.CorsMiddleware(http.HandlerFunc(api.TechnicalAnalysisService)))
+		http.Handle("/peers", api.CorsMiddleware(api.ValidateRequest(http.HandlerFunc(api.PeersService))))
+		log.Println(http.ListenAndServe(":8085", nil))
+	}()
+
+	go func() {
+		http.Handle("/valuation", api.CorsMiddleware(api.ValidateRequest(http.HandlerFunc(api.ValuationService))))
+		log.Println(http.ListenAndServe(":8086", nil))
+	}()
+
+	go func() {
+		http.Handle("/ta", api.CorsMiddleware(api.ValidateRequest(http.HandlerFunc(api.TechnicalAnalysisService))))
 		log.Println(http.ListenAndServe(":8089", nil))
 	}()
 	go func() {
-		http.Handle("/ret", api.CorsMiddleware(http.HandlerFunc(api.RetrieveData)))
+		http.Handle("/ret", api.CorsMiddleware(api.ValidateRequest(http.HandlerFunc(api.RetrieveData))))
 		log.Println(http.ListenAndServeTLS(":8035", dataCertFile, dataKeyFile, nil))
 	}()
 	go func() {
-		http.Handle("/search", api.CorsMiddleware(http.HandlerFunc(api.SearchHandler)))
+		http.Handle("/search", api.CorsMiddleware(api.ValidateRequest(http.HandlerFunc(api.SearchHandler))))
+		http.Handle("/search/internal", api.CorsMiddleware(api.ValidateRequest(http.HandlerFunc(api.InternalSearchHandler))))
 		log.Println(http.ListenAndServe(":8070", nil))
 	}()
 	go func() {
+		router.Use(api.ValidateGinRequest())
 		api.LLMHandler(router)
 		log.Fatal(router.Run(":8090"))
 	}()
 	go func() {
-		http.Handle("/chat", api.CorsMiddleware(http.HandlerFunc(api.ChatbotQuery().ServeHTTP)))
+		http.Handle("/ingestor", api.CorsMiddleware(api.ValidateRequest(http.HandlerFunc(api.IngestorHandler))))
+		log.Println(http.ListenAndServe(":6001", nil))
+	}()
+	go func() {
+		chatHandler := api.CorsMiddleware(api.ValidateRequest(api.ChatbotQuery()))
+		http.Handle("/chat", chatHandler)
+		http.Handle("/chat/sessions", chatHandler)
+		http.Handle("/chat/sessions/", chatHandler)
 		log.Println(http.ListenAndServeTLS(":6002", queryCertFile, queryKeyFile, nil))
 	}()
 
@@ -21,14 +21,9 @@ if __name__ == '__main__':
         "gunicorn", "-w", "4", "-b", "0.0.0.0:5432", 
         "--max-requests", "200", "--limit-request-line", "8190", "--timeout", "120", "llm:app"
     ]
-    dataingestor_command = [
-        "gunicorn", "-w", "4", "-b", "0.0.0.0:6001", 
-        "--max-requests", "200", "--limit-request-line", "8190", "--timeout", "120", "chatbotdataingestor:app"
-    ]
 
     # Start LLM services without SSL
     subprocess.Popen(llm_command, cwd=llm_working_directory)
-    subprocess.Popen(dataingestor_command, cwd=llm_working_directory)
 
     # Chatbotquery (query process) with SSL
     #certfile_query, keyfile_query = get_ssl_paths('query')
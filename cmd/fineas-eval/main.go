@@ -0,0 +1,213 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fineas/api"
+	"fineas/pkg/embed"
+	"fineas/pkg/eval"
+	"fineas/pkg/ingest"
+	"fineas/pkg/services"
+	"fineas/pkg/vectorstore"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// prompt parts that change from day to day, ignored when matching cassette
+// requests so a recording stays valid
+var volatile = []*regexp.Regexp{
+	regexp.MustCompile(`\d{2}-\d{2}-\d{4}`),
+	regexp.MustCompile(`\d{4}-\d{2}-\d{2}`),
+	regexp.MustCompile(`\b20\d{6}\b`),
+}
+
+// runs an eval suite against the chat pipeline in process. The suite's
+// documents are ingested into a temporary local vector store with hashing
+// embeddings, the LLM and search services are a stub, a recorded cassette or
+// the configured services recorded into the cassette
+func main() {
+	suitePath := flag.String("suite", "suite.yaml", "YAML eval suite")
+	mode := flag.String("mode", "stub", "stub, replay or record")
+	cassettePath := flag.String("cassette", "cassette.json", "recorded service responses for replay and record")
+	out := flag.String("out", "report.json", "where the JSON report is written")
+	baselinePath := flag.String("baseline", "", "report to diff against")
+	failOnRegression := flag.Bool("fail-on-regression", false, "exit 1 when a metric regressed against the baseline")
+	verbose := flag.Bool("v", false, "keep the pipeline logs")
+	flag.Parse()
+
+	suite, err := eval.LoadSuite(*suitePath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	dir, err := os.MkdirTemp("", "fineas-eval")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	llm, search, cassette, err := backends(*mode, *cassettePath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	llmServer, searchServer := httptest.NewServer(llm), httptest.NewServer(search)
+	defer llmServer.Close()
+	defer searchServer.Close()
+
+	os.Setenv("LLM_SERVICE_URL", llmServer.URL)
+	os.Setenv("SEARCH_SERVICE_URL", searchServer.URL)
+	os.Setenv("VECTOR_STORE", "local")
+	os.Setenv("VECTOR_STORE_PATH", filepath.Join(dir, "vectors.json"))
+	os.Setenv("VECTOR_STORE_INDEX", vectorstore.IndexFlat)
+	os.Setenv("EMBEDDING_PROVIDER", "hashing")
+	os.Setenv("EMBEDDING_CACHE_DIR", "")
+	os.Setenv("CHAT_SESSION_STORE", "memory")
+	// keyword retrieval reads Mongo and the agent tools call live services,
+	// both stay off unless configured
+	setDefault("CHAT_RETRIEVERS", "dense")
+	setDefault("CHAT_AGENT_MAX_STEPS", "0")
+	setDefault("PASS_KEY", "fineas-eval")
+
+	if len(suite.Tickers) > 0 {
+		path := filepath.Join(dir, "tickerslist.json")
+		data, _ := json.Marshal(suite.Tickers)
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			log.Fatal(err)
+		}
+		os.Setenv("TICKERS_LIST_PATH", path)
+	}
+	if err := ingestDocuments(context.Background(), suite.Documents); err != nil {
+		log.Fatal(err)
+	}
+
+	if !*verbose {
+		log.SetOutput(io.Discard)
+		gin.SetMode(gin.ReleaseMode)
+		gin.DefaultWriter = io.Discard
+	}
+	report := eval.Run(context.Background(), suite, chatAsker(api.ChatbotQuery()), *mode)
+	log.SetOutput(os.Stderr)
+
+	if cassette != nil {
+		if err := cassette.Save(); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if err := report.Save(*out); err != nil {
+		log.Fatal(err)
+	}
+	report.WriteMarkdown(os.Stdout)
+
+	if *baselinePath == "" {
+		return
+	}
+	baseline, err := eval.LoadReport(*baselinePath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	diff := eval.Compare(baseline, report)
+	fmt.Println()
+	diff.Write(os.Stdout)
+	if *failOnRegression && diff.Regressed() {
+		os.Exit(1)
+	}
+}
+
+func setDefault(key string, value string) {
+	if os.Getenv(key) == "" {
+		os.Setenv(key, value)
+	}
+}
+
+// the LLM and search services of a mode. Recording forwards to the services
+// configured with LLM_SERVICE_URL and SEARCH_SERVICE_URL
+func backends(mode string, cassettePath string) (http.Handler, http.Handler, *eval.Cassette, error) {
+	switch mode {
+	case "stub":
+		return eval.StubLLM(), eval.StubSearch(), nil, nil
+	case "replay", "record":
+		cassette, err := eval.OpenCassette(cassettePath)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		cassette.Ignore = volatile
+		if mode == "record" {
+			cassette.Upstreams = map[string]string{
+				services.LLM.Path:    upstream("LLM_SERVICE_URL", services.ServiceLLM),
+				services.Search.Path: upstream("SEARCH_SERVICE_URL", services.ServiceSearch),
+			}
+		}
+		return cassette, cassette, cassette, nil
+	}
+	return nil, nil, nil, fmt.Errorf("unknown mode %q", mode)
+}
+
+// the configured base URL of a service, read before it is pointed at the eval
+// servers
+func upstream(key string, service services.Service) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return services.DefaultBaseURLs[service]
+}
+
+// ingests the suite documents into the local vector store the chat pipeline
+// opens, with the same hashing embedder
+func ingestDocuments(ctx context.Context, documents []eval.Document) error {
+	if len(documents) == 0 {
+		return nil
+	}
+	store, err := vectorstore.OpenLocal(vectorstore.LocalOptions{Path: os.Getenv("VECTOR_STORE_PATH"), Index: vectorstore.IndexFlat})
+	if err != nil {
+		return err
+	}
+	dimension, _ := strconv.Atoi(os.Getenv("EMBEDDING_DIMENSION"))
+	ingestor := &ingest.Ingestor{Embedder: embed.NewHashing(dimension), Store: store, Chunking: ingest.DefaultChunkOptions(), Now: time.Now}
+	records := make([]ingest.Record, 0, len(documents))
+	for _, document := range documents {
+		record := ingest.Record{Ticker: strings.ToUpper(document.Ticker), Section: document.Section, Text: document.Text, SourceURL: document.URL}
+		if document.Date != "" {
+			if record.Date, err = time.Parse("2006-01-02", document.Date); err != nil {
+				return fmt.Errorf("document of %s: %w", document.Ticker, err)
+			}
+		}
+		records = append(records, record)
+	}
+	_, err = ingestor.Ingest(ctx, records)
+	return err
+}
+
+// asks /chat through the handler with the pass key hash, asking for every
+// source so retrieval can be scored
+func chatAsker(handler http.Handler) eval.Asker {
+	sum := sha256.Sum256([]byte(os.Getenv("PASS_KEY")))
+	passHash := hex.EncodeToString(sum[:])
+	return eval.AskerFunc(func(ctx context.Context, question string) (eval.Answer, error) {
+		var answer eval.Answer
+		body, _ := json.Marshal(api.PromptPayload{Prompt: question, IncludeSources: true})
+		req := httptest.NewRequest("POST", "/chat", bytes.NewReader(body)).WithContext(ctx)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+passHash)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			return answer, fmt.Errorf("/chat returned %d: %s", rec.Code, strings.TrimSpace(rec.Body.String()))
+		}
+		return answer, json.Unmarshal(rec.Body.Bytes(), &answer)
+	})
+}
@@ -0,0 +1,226 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"fineas/pkg/chat"
+	"fineas/pkg/citation"
+)
+
+// Call is a tool call the model made, logged and returned with the answer
+type Call struct {
+	Step      int    `json:"step"`
+	Tool      string `json:"tool"`
+	Arguments Args   `json:"arguments,omitempty"`
+	// Sources are the numbers of the sources the call added
+	Sources    []int  `json:"sources,omitempty"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// Run is the outcome of an agent loop
+type Run struct {
+	Answer string
+	Calls  []Call
+	// Sources are the sources given to Run followed by the tool outputs
+	Sources []citation.Source
+}
+
+// Agent answers a prompt with a language model that can call tools. The
+// model replies with either a JSON tool call or its final answer, at most
+// MaxSteps tools are called before it must answer
+type Agent struct {
+	Complete chat.Complete
+	Tools    []Tool
+	MaxSteps int
+	// MaxSourceChars bounds each tool output in the prompt
+	MaxSourceChars int
+	// OnCall is called after every tool call
+	OnCall func(Call)
+}
+
+// NoAnswer opens the answer of a run whose model kept calling tools after
+// the last step, it is followed by the gathered sources so they are still cited
+const NoAnswer = "I could not write an answer from the data I gathered. These sources may help:"
+
+// request is the JSON a model replies with to call a tool
+type request struct {
+	Tool      string `json:"tool"`
+	Arguments Args   `json:"arguments"`
+}
+
+// parseCall reads a tool call from a reply, ok is false for a final answer
+func parseCall(reply string) (request, bool) {
+	text := strings.TrimSpace(reply)
+	if strings.HasPrefix(text, "```") {
+		text = strings.TrimPrefix(strings.TrimPrefix(text, "```json"), "```")
+		text = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(text), "```"))
+	}
+	if !strings.HasPrefix(text, "{") || !strings.HasSuffix(text, "}") {
+		return request{}, false
+	}
+	var req request
+	if err := json.Unmarshal([]byte(text), &req); err != nil || req.Tool == "" {
+		return request{}, false
+	}
+	if req.Arguments == nil {
+		req.Arguments = Args{}
+	}
+	return req, true
+}
+
+func (a *Agent) maxSteps() int {
+	if a.MaxSteps <= 0 {
+		return 4
+	}
+	return a.MaxSteps
+}
+
+func (a *Agent) tool(name string) (Tool, bool) {
+	for _, tool := range a.Tools {
+		if tool.Name == name {
+			return tool, true
+		}
+	}
+	return Tool{}, false
+}
+
+// instructions render the tool list for the prompt
+func (a *Agent) instructions() string {
+	var b strings.Builder
+	b.WriteString(`You can fetch live data with the tools below before answering. To call a tool reply with only a JSON
+object such as {"tool": "get_quote", "arguments": {"ticker": "AAPL"}} and nothing else. Each result is added to the
+SOURCES under a new number and is cited like them. Prefer tools over the SOURCES for current prices and recent
+figures. When you have what you need, reply with the final answer instead of a tool call.
+`)
+	for _, tool := range a.Tools {
+		b.WriteString("- ")
+		b.WriteString(tool.signature())
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// Run answers prompt, whose SOURCES section numbers sources from 1. Tool
+// errors are shown to the model and the run only fails when the model cannot
+// be reached. A model that keeps calling tools after the last step gets
+// NoAnswer with the gathered sources listed
+func (a *Agent) Run(ctx context.Context, prompt string, sources []citation.Source) (Run, error) {
+	run := Run{Sources: append([]citation.Source{}, sources...)}
+	if a.Complete == nil {
+		return run, errors.New("agent has no model")
+	}
+	var results strings.Builder
+	seen := make(map[string]Call)
+	retried := false
+	for step := 1; ; step++ {
+		final := step > a.maxSteps() || len(a.Tools) == 0
+		var p strings.Builder
+		p.WriteString(prompt)
+		if !final {
+			p.WriteString("\n\nTOOLS:\n")
+			p.WriteString(a.instructions())
+		}
+		if results.Len() > 0 {
+			p.WriteString("\nTOOL RESULTS:\n")
+			p.WriteString(results.String())
+		}
+		if final && (len(run.Calls) > 0 || retried) {
+			p.WriteString("\nNo more tools can be called, reply with the final answer.\n")
+		}
+		if retried {
+			p.WriteString("Do not reply with JSON, write the answer in prose.\n")
+		}
+
+		reply, err := a.Complete(ctx, p.String())
+		if err != nil {
+			return run, err
+		}
+		req, ok := parseCall(reply)
+		if !ok {
+			run.Answer = strings.TrimSpace(reply)
+			return run, nil
+		}
+		if final {
+			// the model is asked once more without tools, then the run ends
+			// with the sources gathered so far
+			if !retried {
+				retried = true
+				continue
+			}
+			run.Answer = gatheredAnswer(run.Sources)
+			return run, nil
+		}
+
+		call := Call{Step: step, Tool: req.Tool, Arguments: req.Arguments}
+		key, _ := json.Marshal(req)
+		fmt.Fprintf(&results, "CALL %d: %s\n", step, key)
+		if previous, ok := seen[string(key)]; ok {
+			call.Sources = previous.Sources
+			call.Error = "repeated call"
+			fmt.Fprintf(&results, "Already called, see sources %v.\n\n", previous.Sources)
+		} else {
+			start := time.Now()
+			added, err := a.call(ctx, req)
+			call.DurationMs = time.Since(start).Milliseconds()
+			if err != nil {
+				call.Error = err.Error()
+				fmt.Fprintf(&results, "ERROR: %s\n\n", err)
+			}
+			for _, source := range added {
+				run.Sources = append(run.Sources, source)
+				call.Sources = append(call.Sources, len(run.Sources))
+				results.WriteString(citation.Format(len(run.Sources), source, a.MaxSourceChars))
+			}
+			if err == nil && len(added) == 0 {
+				results.WriteString("No data.\n\n")
+			}
+			seen[string(key)] = call
+		}
+		run.Calls = append(run.Calls, call)
+		if a.OnCall != nil {
+			a.OnCall(call)
+		}
+	}
+}
+
+// call validates and runs a tool call
+func (a *Agent) call(ctx context.Context, req request) ([]citation.Source, error) {
+	tool, ok := a.tool(req.Tool)
+	if !ok {
+		return nil, fmt.Errorf("unknown tool %q", req.Tool)
+	}
+	if err := tool.check(req.Arguments); err != nil {
+		return nil, err
+	}
+	sources, err := tool.Run(ctx, req.Arguments)
+	for i := range sources {
+		sources[i].Tool = tool.Name
+	}
+	return sources, err
+}
+
+// the answer of a run whose model did not answer, one cited line per source
+func gatheredAnswer(sources []citation.Source) string {
+	if len(sources) == 0 {
+		return "I could not write an answer and found no data for the question."
+	}
+	var b strings.Builder
+	b.WriteString(NoAnswer)
+	for i, source := range sources {
+		title := source.Title
+		if title == "" {
+			title = source.Tool
+		}
+		if title == "" {
+			title = source.URL
+		}
+		fmt.Fprintf(&b, "\n- %s [%d]", title, i+1)
+	}
+	return b.String()
+}
@@ -0,0 +1,126 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"fineas/pkg/citation"
+)
+
+// Param describes a tool argument to the model
+type Param struct {
+	Name string
+	// Type is the JSON type the model should send: string, number or boolean
+	Type        string
+	Description string
+	Required    bool
+}
+
+// Args are the arguments of a tool call as decoded from the model's JSON
+type Args map[string]interface{}
+
+// String reads an argument as a string, numbers are formatted
+func (a Args) String(name string) string {
+	switch value := a[name].(type) {
+	case string:
+		return strings.TrimSpace(value)
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(value)
+	}
+	return ""
+}
+
+// Float reads a numeric argument, numbers sent as strings such as "1,234.5"
+// are parsed
+func (a Args) Float(name string) (float64, bool) {
+	switch value := a[name].(type) {
+	case float64:
+		return value, true
+	case string:
+		f, err := strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(value), ",", ""), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// Int reads an integer argument within [min, max], fallback when missing
+func (a Args) Int(name string, fallback int, min int, max int) int {
+	f, ok := a.Float(name)
+	if !ok {
+		return fallback
+	}
+	n := int(f)
+	if n < min {
+		return min
+	}
+	if n > max {
+		return max
+	}
+	return n
+}
+
+// Bool reads a boolean argument, "true" strings included
+func (a Args) Bool(name string) bool {
+	switch value := a[name].(type) {
+	case bool:
+		return value
+	case string:
+		b, _ := strconv.ParseBool(strings.TrimSpace(value))
+		return b
+	}
+	return false
+}
+
+// Tool is a function the model can call. Its sources are added to the ones
+// the answer may cite
+type Tool struct {
+	Name        string
+	Description string
+	Params      []Param
+	Run         func(ctx context.Context, args Args) ([]citation.Source, error)
+}
+
+// check validates the arguments against the declared params
+func (t Tool) check(args Args) error {
+	for _, param := range t.Params {
+		value, ok := args[param.Name]
+		if !ok || value == nil || value == "" {
+			if param.Required {
+				return fmt.Errorf("missing required argument %q", param.Name)
+			}
+			continue
+		}
+		switch param.Type {
+		case "number":
+			if _, ok := args.Float(param.Name); !ok {
+				return fmt.Errorf("argument %q must be a number", param.Name)
+			}
+		case "string":
+			if args.String(param.Name) == "" {
+				return fmt.Errorf("argument %q must be a string", param.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// signature renders the tool for the prompt, e.g.
+// get_quote(ticker: string, required): latest price
+func (t Tool) signature() string {
+	params := make([]string, 0, len(t.Params))
+	for _, param := range t.Params {
+		p := param.Name + ": " + param.Type
+		if param.Required {
+			p += ", required"
+		}
+		if param.Description != "" {
+			p += ", " + param.Description
+		}
+		params = append(params, p)
+	}
+	return fmt.Sprintf("%s(%s): %s", t.Name, strings.Join(params, "; "), t.Description)
+}
@@ -0,0 +1,116 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Complete sends a prompt to a language model and returns its answer
+type Complete func(ctx context.Context, prompt string) (string, error)
+
+// Conversation manages the history a session sends to the model. The last
+// Window messages are sent verbatim, older ones are summarized
+type Conversation struct {
+	Complete Complete
+	Window   int
+	// MaxSummaryChars bounds the summary kept for older turns
+	MaxSummaryChars int
+}
+
+func (c *Conversation) window() int {
+	if c.Window <= 0 {
+		return 6
+	}
+	return c.Window
+}
+
+// Recent is the part of the history sent verbatim
+func (c *Conversation) Recent(s *Session) []Message {
+	start := len(s.Messages) - c.window()
+	if start < s.Summarized {
+		start = s.Summarized
+	}
+	if start < 0 {
+		start = 0
+	}
+	return s.Messages[start:]
+}
+
+// History renders the summary and recent messages for a prompt, empty for a
+// new session
+func (c *Conversation) History(s *Session) string {
+	var b strings.Builder
+	if s.Summary != "" {
+		b.WriteString("Summary of the earlier conversation: ")
+		b.WriteString(s.Summary)
+		b.WriteString("\n")
+	}
+	for _, m := range c.Recent(s) {
+		fmt.Fprintf(&b, "%s: %s\n", m.Role, m.Content)
+	}
+	return b.String()
+}
+
+// Summarize folds the messages that fell out of the window into the summary.
+// It does nothing while the whole history fits the window
+func (c *Conversation) Summarize(ctx context.Context, s *Session) error {
+	end := len(s.Messages) - c.window()
+	if end <= s.Summarized {
+		return nil
+	}
+	var turns strings.Builder
+	for _, m := range s.Messages[s.Summarized:end] {
+		fmt.Fprintf(&turns, "%s: %s\n", m.Role, m.Content)
+	}
+	limit := c.MaxSummaryChars
+	if limit <= 0 {
+		limit = 2000
+	}
+	prompt := fmt.Sprintf(`Update the summary of a conversation between an investor and a market research assistant
+with the new turns below. Keep the companies, tickers, periods, figures and conclusions discussed, drop pleasantries.
+Answer with the summary only, in at most %d characters.
+
+CURRENT SUMMARY:
+%s
+
+NEW TURNS:
+%s`, limit, s.Summary, turns.String())
+	summary, err := c.Complete(ctx, prompt)
+	if err != nil {
+		return err
+	}
+	summary = strings.TrimSpace(summary)
+	if len(summary) > limit {
+		summary = truncate(summary, limit)
+	}
+	s.Summary = summary
+	s.Summarized = end
+	return nil
+}
+
+// Rewrite turns a follow-up such as "what about its debt?" into a question
+// that can be retrieved on its own, using the session history. The question is
+// returned unchanged for a new session or when the model gives no answer
+func (c *Conversation) Rewrite(ctx context.Context, s *Session, question string) (string, error) {
+	if len(s.Messages) == 0 && s.Summary == "" {
+		return question, nil
+	}
+	prompt := fmt.Sprintf(`Rewrite the follow-up question of an investor as a standalone question that can be
+understood without the conversation. Replace pronouns and vague references with the companies, tickers and
+periods they refer to. If it already stands on its own, repeat it unchanged. Answer with the question only.
+
+CONVERSATION:
+%s
+FOLLOW-UP QUESTION:
+%s`, c.History(s), question)
+	rewritten, err := c.Complete(ctx, prompt)
+	if err != nil {
+		return question, err
+	}
+	rewritten = strings.Trim(strings.TrimSpace(rewritten), `"`)
+	if rewritten == "" || strings.Contains(rewritten, "\n\n") {
+		return question, nil
+	}
+	return rewritten, nil
+}
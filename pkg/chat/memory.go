@@ -0,0 +1,74 @@
+package chat
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// Memory is an in process Repository, sessions are lost on restart
+type Memory struct {
+	mu       sync.RWMutex
+	sessions map[string]*Session
+}
+
+func NewMemory() *Memory {
+	return &Memory{sessions: make(map[string]*Session)}
+}
+
+// clone copies a session so callers never share its message slice
+func clone(s *Session) *Session {
+	copied := *s
+	copied.Messages = append([]Message{}, s.Messages...)
+	return &copied
+}
+
+func (m *Memory) Get(ctx context.Context, id string) (*Session, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	s, ok := m.sessions[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return clone(s), nil
+}
+
+func (m *Memory) List(ctx context.Context, opts ListOptions) ([]Info, error) {
+	m.mu.RLock()
+	infos := make([]Info, 0, len(m.sessions))
+	for _, s := range m.sessions {
+		infos = append(infos, s.Info())
+	}
+	m.mu.RUnlock()
+	sort.Slice(infos, func(i, j int) bool {
+		if !infos[i].UpdatedAt.Equal(infos[j].UpdatedAt) {
+			return infos[i].UpdatedAt.After(infos[j].UpdatedAt)
+		}
+		return infos[i].ID < infos[j].ID
+	})
+	if opts.Offset >= len(infos) {
+		return []Info{}, nil
+	}
+	infos = infos[opts.Offset:]
+	if opts.Limit > 0 && len(infos) > opts.Limit {
+		infos = infos[:opts.Limit]
+	}
+	return infos, nil
+}
+
+func (m *Memory) Save(ctx context.Context, s *Session) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sessions[s.ID] = clone(s)
+	return nil
+}
+
+func (m *Memory) Delete(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.sessions[id]; !ok {
+		return ErrNotFound
+	}
+	delete(m.sessions, id)
+	return nil
+}
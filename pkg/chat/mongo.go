@@ -0,0 +1,72 @@
+package chat
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Mongo stores sessions as documents of a collection, one per session
+type Mongo struct {
+	Collection *mongo.Collection
+}
+
+func NewMongo(collection *mongo.Collection) *Mongo {
+	return &Mongo{Collection: collection}
+}
+
+func (m *Mongo) Get(ctx context.Context, id string) (*Session, error) {
+	var s Session
+	err := m.Collection.FindOne(ctx, bson.M{"_id": id}).Decode(&s)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (m *Mongo) List(ctx context.Context, opts ListOptions) ([]Info, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$sort", Value: bson.D{{Key: "updated_at", Value: -1}, {Key: "_id", Value: 1}}}},
+		{{Key: "$skip", Value: int64(opts.Offset)}},
+	}
+	if opts.Limit > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$limit", Value: int64(opts.Limit)}})
+	}
+	pipeline = append(pipeline, bson.D{{Key: "$project", Value: bson.M{
+		"title":         1,
+		"created_at":    1,
+		"updated_at":    1,
+		"message_count": bson.M{"$size": bson.M{"$ifNull": bson.A{"$messages", bson.A{}}}},
+	}}})
+	cursor, err := m.Collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	infos := []Info{}
+	if err := cursor.All(ctx, &infos); err != nil {
+		return nil, err
+	}
+	return infos, nil
+}
+
+func (m *Mongo) Save(ctx context.Context, s *Session) error {
+	_, err := m.Collection.ReplaceOne(ctx, bson.M{"_id": s.ID}, s, options.Replace().SetUpsert(true))
+	return err
+}
+
+func (m *Mongo) Delete(ctx context.Context, id string) error {
+	res, err := m.Collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package chat
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+// Role is who wrote a message
+type Role string
+
+const (
+	User      Role = "user"
+	Assistant Role = "assistant"
+)
+
+// Message is one turn of a conversation
+type Message struct {
+	Role    Role   `json:"role" bson:"role"`
+	Content string `json:"content" bson:"content"`
+	// Standalone is a follow-up question rewritten to stand on its own, the
+	// form it was retrieved with
+	Standalone string    `json:"standalone,omitempty" bson:"standalone,omitempty"`
+	Time       time.Time `json:"time" bson:"time"`
+}
+
+// Session is a conversation. Messages before Summarized are folded into
+// Summary and no longer sent to the model verbatim
+type Session struct {
+	ID         string    `json:"id" bson:"_id"`
+	Title      string    `json:"title" bson:"title"`
+	Summary    string    `json:"summary,omitempty" bson:"summary,omitempty"`
+	Summarized int       `json:"summarized" bson:"summarized"`
+	Messages   []Message `json:"messages" bson:"messages"`
+	CreatedAt  time.Time `json:"created_at" bson:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+// Info describes a session without its messages, for listings
+type Info struct {
+	ID        string    `json:"id" bson:"_id"`
+	Title     string    `json:"title" bson:"title"`
+	Messages  int       `json:"messages" bson:"message_count"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+// Info returns the listing entry of the session
+func (s *Session) Info() Info {
+	return Info{ID: s.ID, Title: s.Title, Messages: len(s.Messages), CreatedAt: s.CreatedAt, UpdatedAt: s.UpdatedAt}
+}
+
+// NewSession starts an empty session with a random id
+func NewSession(title string, now time.Time) *Session {
+	id := make([]byte, 16)
+	rand.Read(id)
+	return &Session{ID: hex.EncodeToString(id), Title: title, Messages: []Message{}, CreatedAt: now, UpdatedAt: now}
+}
+
+// Append adds messages and, for a new session, titles it after the first question
+func (s *Session) Append(messages ...Message) {
+	for _, m := range messages {
+		if s.Title == "" && m.Role == User {
+			s.Title = truncate(m.Content, 80)
+		}
+		s.Messages = append(s.Messages, m)
+		if m.Time.After(s.UpdatedAt) {
+			s.UpdatedAt = m.Time
+		}
+	}
+}
+
+func truncate(text string, n int) string {
+	runes := []rune(text)
+	if len(runes) <= n {
+		return text
+	}
+	return string(runes[:n-1]) + "…"
+}
+
+// ErrNotFound is returned for unknown session ids
+var ErrNotFound = errors.New("session not found")
+
+// ListOptions page through sessions, most recently updated first
+type ListOptions struct {
+	Limit  int
+	Offset int
+}
+
+// Repository stores sessions. Memory keeps them in process and Mongo in the
+// ChatSessions collection
+type Repository interface {
+	Get(ctx context.Context, id string) (*Session, error)
+	List(ctx context.Context, opts ListOptions) ([]Info, error)
+	// Save creates or replaces a session
+	Save(ctx context.Context, s *Session) error
+	Delete(ctx context.Context, id string) error
+}
@@ -0,0 +1,205 @@
+package citation
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Source is a piece of context the model may cite: a retrieved chunk or a
+// web search result. Sources are numbered from 1 in the prompt
+type Source struct {
+	Title   string `json:"title,omitempty"`
+	URL     string `json:"url,omitempty"`
+	Snippet string `json:"snippet,omitempty"`
+	// ChunkID is the id of the retrieved chunk, empty for search results
+	ChunkID string `json:"chunk_id,omitempty"`
+	Ticker  string `json:"ticker,omitempty"`
+	Date    string `json:"date,omitempty"`
+	// Tool names the agent tool that fetched the source, empty otherwise
+	Tool string `json:"tool,omitempty"`
+	// Text is the full context shown to the model, not returned
+	Text string `json:"-"`
+}
+
+// Span is the part of the answer a citation supports, as byte offsets
+type Span struct {
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+	Text  string `json:"text"`
+}
+
+// Citation is a cited source with the answer spans that cite it
+type Citation struct {
+	// Index is the source number used in the answer's [n] markers
+	Index int `json:"index"`
+	Source
+	Spans []Span `json:"spans"`
+}
+
+// Result is a validated answer and its citations
+type Result struct {
+	Answer    string     `json:"answer"`
+	Citations []Citation `json:"citations"`
+	// RejectedURLs were in the model's answer but in no source, they are
+	// removed from Answer
+	RejectedURLs []string `json:"rejected_urls,omitempty"`
+}
+
+// Prompt renders the numbered sources for the model with snippets cut to
+// maxChars, 0 keeps the full text
+func Prompt(sources []Source, maxChars int) string {
+	var b strings.Builder
+	for i, s := range sources {
+		b.WriteString(Format(i+1, s, maxChars))
+	}
+	return b.String()
+}
+
+// Format renders one source under its number, for sources added after the
+// first Prompt
+func Format(index int, s Source, maxChars int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[%d]", index)
+	for _, field := range []struct{ label, value string }{
+		{"title", s.Title}, {"url", s.URL}, {"ticker", s.Ticker}, {"date", s.Date}, {"tool", s.Tool},
+	} {
+		if field.value != "" {
+			fmt.Fprintf(&b, " %s: %s;", field.label, field.value)
+		}
+	}
+	text := s.Text
+	if text == "" {
+		text = s.Snippet
+	}
+	if maxChars > 0 && len(text) > maxChars {
+		text = text[:maxChars] + "..."
+	}
+	b.WriteString("\n")
+	b.WriteString(text)
+	b.WriteString("\n\n")
+	return b.String()
+}
+
+// Instructions tell the model how to cite the numbered sources
+const Instructions = `Cite the numbered SOURCES for every factual claim by writing the source number in brackets
+right after the claim, for example "Revenue grew 12% [2]" or "[1, 3]" for several sources. Only cite the numbers listed,
+never invent sources and do not write URLs, they are attached to the citations for you.`
+
+var (
+	marker = regexp.MustCompile(`\[(\d+(?:\s*,\s*\d+)*)\]`)
+	// "[Title]https://..." annotations, markdown links and bare URLs
+	link = regexp.MustCompile(`(?:\[([^\]\n]*)\](\()?)?(https?://[^\s<>()\[\]"']+)(\))?`)
+	// claims end at sentence ends, line breaks and earlier markers
+	boundary = regexp.MustCompile(`[.!?](?:\s|$)|\n|\]`)
+)
+
+// normalizeURL compares URLs without scheme, www, trailing slash or fragment
+func normalizeURL(raw string) string {
+	raw = strings.TrimRight(raw, ".,;:!?")
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return strings.ToLower(raw)
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Host), "www.")
+	return host + strings.TrimRight(u.EscapedPath(), "/") + queryPart(u)
+}
+
+func queryPart(u *url.URL) string {
+	if u.RawQuery == "" {
+		return ""
+	}
+	return "?" + u.RawQuery
+}
+
+// Resolve validates an answer against the sources it was written from. URLs
+// that appear in no source are removed, an annotation "[Title]url" of a known
+// source becomes its [n] marker, markers of unknown sources are dropped, and
+// every remaining marker links its source to the claim before it
+func Resolve(answer string, sources []Source) Result {
+	byURL := make(map[string]int)
+	for i, s := range sources {
+		if s.URL != "" {
+			byURL[normalizeURL(s.URL)] = i + 1
+		}
+	}
+
+	result := Result{}
+	answer = link.ReplaceAllStringFunc(answer, func(match string) string {
+		parts := link.FindStringSubmatch(match)
+		title, raw := parts[1], parts[3]
+		trimmed := strings.TrimRight(raw, ".,;:!?")
+		trailing := raw[len(trimmed):]
+		// a closing parenthesis belongs to the link only in markdown
+		if parts[4] != "" && parts[2] == "" {
+			trailing += parts[4]
+		}
+		if index, ok := byURL[normalizeURL(trimmed)]; ok {
+			cited := fmt.Sprintf("[%d]", index)
+			if title != "" {
+				return title + " " + cited + trailing
+			}
+			return cited + trailing
+		}
+		result.RejectedURLs = append(result.RejectedURLs, trimmed)
+		return title + trailing
+	})
+	answer = marker.ReplaceAllStringFunc(answer, func(match string) string {
+		var kept []string
+		for _, n := range strings.Split(strings.Trim(match, "[]"), ",") {
+			index, _ := strconv.Atoi(strings.TrimSpace(n))
+			if index >= 1 && index <= len(sources) {
+				kept = append(kept, strconv.Itoa(index))
+			}
+		}
+		if len(kept) == 0 {
+			return ""
+		}
+		return "[" + strings.Join(kept, ", ") + "]"
+	})
+	result.Answer = answer
+
+	spans := make(map[int][]Span)
+	for _, loc := range marker.FindAllStringSubmatchIndex(answer, -1) {
+		span := claim(answer, loc[0])
+		for _, n := range strings.Split(answer[loc[2]:loc[3]], ",") {
+			index, _ := strconv.Atoi(strings.TrimSpace(n))
+			if list := spans[index]; len(list) == 0 || list[len(list)-1] != span {
+				spans[index] = append(list, span)
+			}
+		}
+	}
+	indexes := make([]int, 0, len(spans))
+	for index := range spans {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+	result.Citations = make([]Citation, 0, len(indexes))
+	for _, index := range indexes {
+		result.Citations = append(result.Citations, Citation{Index: index, Source: sources[index-1], Spans: spans[index]})
+	}
+	return result
+}
+
+// claim is the text before a marker back to the previous sentence end, line
+// break or marker
+func claim(answer string, end int) Span {
+	start := 0
+	for _, loc := range boundary.FindAllStringIndex(answer[:end], -1) {
+		// a sentence end directly before the marker belongs to the claim
+		if strings.TrimSpace(answer[loc[1]:end]) != "" {
+			start = loc[1]
+		}
+	}
+	for start < end && (answer[start] == ' ' || answer[start] == '\t' || answer[start] == '\n') {
+		start++
+	}
+	stop := end
+	for stop > start && answer[stop-1] == ' ' {
+		stop--
+	}
+	return Span{Start: start, End: stop, Text: answer[start:stop]}
+}
@@ -0,0 +1,101 @@
+package citation
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var sources = []Source{
+	{Title: "Apple 10-K", URL: "https://www.sec.gov/aapl-10k/", ChunkID: "AAPL-fin-1"},
+	{Title: "Reuters", URL: "https://reuters.com/apple-earnings?id=7"},
+	{Title: "Internal report", ChunkID: "AAPL-stk-2"},
+}
+
+func TestResolveSpans(t *testing.T) {
+	answer := "Revenue grew 12% [1]. Shares rose 3% after earnings [2, 3].\nMargins held [1]"
+	result := Resolve(answer, sources)
+	if result.Answer != answer {
+		t.Fatalf("answer changed to %q", result.Answer)
+	}
+	if len(result.RejectedURLs) != 0 {
+		t.Errorf("rejected %v from an answer without URLs", result.RejectedURLs)
+	}
+
+	want := map[int][]string{
+		1: {"Revenue grew 12%", "Margins held"},
+		2: {"Shares rose 3% after earnings"},
+		3: {"Shares rose 3% after earnings"},
+	}
+	if len(result.Citations) != len(want) {
+		t.Fatalf("got %d citations, want %d", len(result.Citations), len(want))
+	}
+	for i, citation := range result.Citations {
+		if citation.Index != i+1 || citation.Source != sources[i] {
+			t.Errorf("citation %d is source %d %+v", i, citation.Index, citation.Source)
+		}
+		var texts []string
+		for _, span := range citation.Spans {
+			if answer[span.Start:span.End] != span.Text {
+				t.Errorf("span %+v does not match the answer text %q", span, answer[span.Start:span.End])
+			}
+			texts = append(texts, span.Text)
+		}
+		if !reflect.DeepEqual(texts, want[citation.Index]) {
+			t.Errorf("source %d cited for %q, want %q", citation.Index, texts, want[citation.Index])
+		}
+	}
+}
+
+func TestResolveDropsUnknownMarkers(t *testing.T) {
+	result := Resolve("Profit doubled [4]. Sales fell [0, 2].", sources)
+	if result.Answer != "Profit doubled . Sales fell [2]." {
+		t.Errorf("got %q", result.Answer)
+	}
+	if len(result.Citations) != 1 || result.Citations[0].Index != 2 {
+		t.Errorf("got citations %+v, want only source 2", result.Citations)
+	}
+}
+
+func TestResolveURLs(t *testing.T) {
+	cases := []struct {
+		answer   string
+		want     string
+		rejected []string
+	}{
+		// annotations and links of known sources become markers, however the URL is written
+		{"See [Apple 10-K]https://sec.gov/aapl-10k.", "See Apple 10-K [1].", nil},
+		{"Read [the story](http://www.reuters.com/apple-earnings?id=7) now", "Read the story [2] now", nil},
+		{"Source: https://www.sec.gov/aapl-10k/#page=3", "Source: [1]", nil},
+		// invented URLs are removed and reported
+		{"Details at https://example.com/fake-report.", "Details at .", []string{"https://example.com/fake-report"}},
+		{"See [Fake](https://example.com/x) and (https://example.org/y)", "See Fake and ()", []string{"https://example.com/x", "https://example.org/y"}},
+		// a query string is part of the URL
+		{"More at https://reuters.com/apple-earnings?id=8", "More at ", []string{"https://reuters.com/apple-earnings?id=8"}},
+	}
+	for _, c := range cases {
+		result := Resolve(c.answer, sources)
+		if result.Answer != c.want {
+			t.Errorf("%q: got %q, want %q", c.answer, result.Answer, c.want)
+		}
+		if !reflect.DeepEqual(result.RejectedURLs, c.rejected) {
+			t.Errorf("%q: rejected %v, want %v", c.answer, result.RejectedURLs, c.rejected)
+		}
+		if strings.Contains(result.Answer, "http") {
+			t.Errorf("%q: a URL is left in %q", c.answer, result.Answer)
+		}
+	}
+}
+
+func TestPromptNumbersSources(t *testing.T) {
+	sources := []Source{
+		{Title: "Apple 10-K", URL: "https://sec.gov/a", Ticker: "AAPL", Text: "Revenue was $383 billion in fiscal 2023."},
+		{Title: "Quote", Tool: "get_quote", Snippet: "AAPL closed at $189.98"},
+	}
+	got := Prompt(sources, 20)
+	want := "[1] title: Apple 10-K; url: https://sec.gov/a; ticker: AAPL;\nRevenue was $383 bil...\n\n" +
+		"[2] title: Quote; tool: get_quote;\nAAPL closed at $189....\n\n"
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
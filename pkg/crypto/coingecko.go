@@ -0,0 +1,212 @@
+package crypto
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// CoinGecko reads crypto profiles and market data from the CoinGecko v3 API
+type CoinGecko struct {
+	BaseURL string
+	APIKey  string
+	Client  *http.Client
+	// number of exchanges reported in the volume dominance breakdown
+	TopExchanges int
+}
+
+// NewCoinGecko returns a CoinGecko source, baseURL defaults to the public API
+func NewCoinGecko(baseURL, apiKey string) *CoinGecko {
+	if baseURL == "" {
+		baseURL = "https://api.coingecko.com/api/v3"
+	}
+	return &CoinGecko{
+		BaseURL:      strings.TrimSuffix(baseURL, "/"),
+		APIKey:       apiKey,
+		Client:       &http.Client{Timeout: 15 * time.Second},
+		TopExchanges: 5,
+	}
+}
+
+type geckoCoin struct {
+	ID          string   `json:"id"`
+	Symbol      string   `json:"symbol"`
+	Name        string   `json:"name"`
+	GenesisDate string   `json:"genesis_date"`
+	Hashing     string   `json:"hashing_algorithm"`
+	Categories  []string `json:"categories"`
+	Description struct {
+		En string `json:"en"`
+	} `json:"description"`
+	Links struct {
+		Homepage []string `json:"homepage"`
+	} `json:"links"`
+	MarketData struct {
+		CurrentPrice      map[string]float64 `json:"current_price"`
+		MarketCap         map[string]float64 `json:"market_cap"`
+		TotalVolume       map[string]float64 `json:"total_volume"`
+		CirculatingSupply float64            `json:"circulating_supply"`
+		TotalSupply       float64            `json:"total_supply"`
+		MaxSupply         float64            `json:"max_supply"`
+	} `json:"market_data"`
+	Tickers []struct {
+		Market struct {
+			Name string `json:"name"`
+		} `json:"market"`
+		ConvertedVolume map[string]float64 `json:"converted_volume"`
+	} `json:"tickers"`
+}
+
+func (g *CoinGecko) Profile(ctx context.Context, symbol string) (*Profile, error) {
+	coin, err := g.coin(ctx, symbol, false)
+	if err != nil {
+		return nil, err
+	}
+
+	profile := &Profile{
+		Symbol:           strings.ToUpper(coin.Symbol),
+		Name:             coin.Name,
+		Description:      coin.Description.En,
+		GenesisDate:      coin.GenesisDate,
+		HashingAlgorithm: coin.Hashing,
+		Categories:       coin.Categories,
+		Source:           "coingecko",
+	}
+	for _, link := range coin.Links.Homepage {
+		if link != "" {
+			profile.Homepage = link
+			break
+		}
+	}
+	return profile, nil
+}
+
+func (g *CoinGecko) Market(ctx context.Context, symbol string) (*MarketData, error) {
+	coin, err := g.coin(ctx, symbol, true)
+	if err != nil {
+		return nil, err
+	}
+
+	market := &MarketData{
+		Symbol:            strings.ToUpper(coin.Symbol),
+		Price:             coin.MarketData.CurrentPrice["usd"],
+		MarketCap:         coin.MarketData.MarketCap["usd"],
+		Volume24h:         coin.MarketData.TotalVolume["usd"],
+		CirculatingSupply: coin.MarketData.CirculatingSupply,
+		TotalSupply:       coin.MarketData.TotalSupply,
+		MaxSupply:         coin.MarketData.MaxSupply,
+		Source:            "coingecko",
+	}
+
+	// aggregate ticker volume per exchange to get exchange dominance
+	volumes := make(map[string]float64)
+	var total float64
+	for _, t := range coin.Tickers {
+		volumes[t.Market.Name] += t.ConvertedVolume["usd"]
+		total += t.ConvertedVolume["usd"]
+	}
+	if total > 0 {
+		for name, v := range volumes {
+			market.Exchanges = append(market.Exchanges, ExchangeShare{Exchange: name, VolumeShare: v / total * 100})
+		}
+		sort.Slice(market.Exchanges, func(i, j int) bool {
+			return market.Exchanges[i].VolumeShare > market.Exchanges[j].VolumeShare
+		})
+		if len(market.Exchanges) > g.TopExchanges {
+			market.Exchanges = market.Exchanges[:g.TopExchanges]
+		}
+	}
+
+	// market dominance comes from the global endpoint
+	var global struct {
+		Data struct {
+			MarketCapPercentage map[string]float64 `json:"market_cap_percentage"`
+		} `json:"data"`
+	}
+	if err := g.get(ctx, "/global", nil, &global); err == nil {
+		market.MarketDominance = global.Data.MarketCapPercentage[strings.ToLower(coin.Symbol)]
+	}
+
+	return market, nil
+}
+
+// resolves the symbol to a CoinGecko id and fetches the coin document
+func (g *CoinGecko) coin(ctx context.Context, symbol string, withMarket bool) (*geckoCoin, error) {
+	id, err := g.resolveID(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	params := url.Values{}
+	params.Set("localization", "false")
+	params.Set("community_data", "false")
+	params.Set("developer_data", "false")
+	params.Set("market_data", fmt.Sprint(withMarket))
+	params.Set("tickers", fmt.Sprint(withMarket))
+
+	var coin geckoCoin
+	if err := g.get(ctx, "/coins/"+url.PathEscape(id), params, &coin); err != nil {
+		return nil, err
+	}
+	return &coin, nil
+}
+
+// picks the highest ranked coin whose symbol matches exactly
+func (g *CoinGecko) resolveID(ctx context.Context, symbol string) (string, error) {
+	var res struct {
+		Coins []struct {
+			ID            string `json:"id"`
+			Symbol        string `json:"symbol"`
+			MarketCapRank int    `json:"market_cap_rank"`
+		} `json:"coins"`
+	}
+	params := url.Values{}
+	params.Set("query", symbol)
+	if err := g.get(ctx, "/search", params, &res); err != nil {
+		return "", err
+	}
+
+	id, rank := "", 0
+	for _, c := range res.Coins {
+		if !strings.EqualFold(c.Symbol, symbol) || c.MarketCapRank == 0 {
+			continue
+		}
+		if id == "" || c.MarketCapRank < rank {
+			id, rank = c.ID, c.MarketCapRank
+		}
+	}
+	if id == "" {
+		return "", fmt.Errorf("no coingecko asset found for symbol %s", symbol)
+	}
+	return id, nil
+}
+
+func (g *CoinGecko) get(ctx context.Context, path string, params url.Values, out interface{}) error {
+	reqURL := g.BaseURL + path
+	if len(params) > 0 {
+		reqURL += "?" + params.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
+	if err != nil {
+		return err
+	}
+	if g.APIKey != "" {
+		req.Header.Set("x-cg-demo-api-key", g.APIKey)
+	}
+
+	resp, err := g.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("coingecko returned status %d for %s", resp.StatusCode, path)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,153 @@
+package crypto
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Profile describes a crypto asset in place of a company profile
+type Profile struct {
+	Symbol           string   `json:"symbol"`
+	Name             string   `json:"name"`
+	Description      string   `json:"description,omitempty"`
+	Homepage         string   `json:"homepage,omitempty"`
+	GenesisDate      string   `json:"genesis_date,omitempty"`
+	HashingAlgorithm string   `json:"hashing_algorithm,omitempty"`
+	Categories       []string `json:"categories,omitempty"`
+	Source           string   `json:"source"`
+}
+
+// ExchangeShare is the share of an asset's 24h volume traded on one exchange
+type ExchangeShare struct {
+	Exchange    string  `json:"exchange"`
+	VolumeShare float64 `json:"volume_share"`
+}
+
+// MarketData holds supply and market statistics for a crypto asset
+type MarketData struct {
+	Symbol            string          `json:"symbol"`
+	Price             float64         `json:"price"`
+	MarketCap         float64         `json:"market_cap"`
+	Volume24h         float64         `json:"volume_24h"`
+	CirculatingSupply float64         `json:"circulating_supply"`
+	TotalSupply       float64         `json:"total_supply,omitempty"`
+	MaxSupply         float64         `json:"max_supply,omitempty"`
+	MarketDominance   float64         `json:"market_dominance,omitempty"`
+	Exchanges         []ExchangeShare `json:"exchanges,omitempty"`
+	Source            string          `json:"source"`
+}
+
+// OnChainStats is a flat set of named on-chain metrics such as active addresses or hash rate
+type OnChainStats map[string]float64
+
+// Fundamentals is the crypto replacement for the financial statements section
+type Fundamentals struct {
+	Market  MarketData   `json:"market"`
+	OnChain OnChainStats `json:"on_chain,omitempty"`
+	AsOf    time.Time    `json:"as_of"`
+}
+
+// Source supplies profile and market data for crypto assets
+type Source interface {
+	Profile(ctx context.Context, symbol string) (*Profile, error)
+	Market(ctx context.Context, symbol string) (*MarketData, error)
+}
+
+// OnChainSource supplies on-chain statistics for crypto assets
+type OnChainSource interface {
+	Stats(ctx context.Context, symbol string) (OnChainStats, error)
+}
+
+// NoOnChain is the default on-chain source when none is configured
+type NoOnChain struct{}
+
+func (NoOnChain) Stats(ctx context.Context, symbol string) (OnChainStats, error) {
+	return nil, nil
+}
+
+// Symbol converts a polygon crypto ticker such as X:BTCUSD into its base symbol
+func Symbol(ticker string) string {
+	symbol := strings.TrimPrefix(strings.ToUpper(ticker), "X:")
+	for _, quote := range []string{"USDT", "USDC", "USD"} {
+		if strings.HasSuffix(symbol, quote) && len(symbol) > len(quote) {
+			return strings.TrimSuffix(symbol, quote)
+		}
+	}
+	return symbol
+}
+
+// Summary renders the profile as plain text for prompt templates
+func (p *Profile) Summary() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "ASSET: %s (%s)", p.Name, p.Symbol)
+	if p.Description != "" {
+		fmt.Fprintf(&b, " DESCRIPTION: %s", p.Description)
+	}
+	if p.GenesisDate != "" {
+		fmt.Fprintf(&b, " GENESIS DATE: %s", p.GenesisDate)
+	}
+	if p.HashingAlgorithm != "" {
+		fmt.Fprintf(&b, " HASHING ALGORITHM: %s", p.HashingAlgorithm)
+	}
+	if len(p.Categories) > 0 {
+		fmt.Fprintf(&b, " CATEGORIES: %s", strings.Join(p.Categories, ", "))
+	}
+	if p.Homepage != "" {
+		fmt.Fprintf(&b, " HOMEPAGE: %s", p.Homepage)
+	}
+	return b.String()
+}
+
+// Summary renders the fundamentals as plain text for prompt templates
+func (f *Fundamentals) Summary() string {
+	m := f.Market
+	var b strings.Builder
+	fmt.Fprintf(&b, "The crypto fundamentals for %s are PRICE: %s, MARKET CAP: %s, 24H VOLUME: %s, CIRCULATING SUPPLY: %s",
+		m.Symbol, formatAmount(m.Price, true), formatAmount(m.MarketCap, true), formatAmount(m.Volume24h, true), formatAmount(m.CirculatingSupply, false))
+	if m.TotalSupply > 0 {
+		fmt.Fprintf(&b, ", TOTAL SUPPLY: %s", formatAmount(m.TotalSupply, false))
+	}
+	if m.MaxSupply > 0 {
+		fmt.Fprintf(&b, ", MAX SUPPLY: %s, PERCENT OF MAX SUPPLY CIRCULATING: %.2f%%", formatAmount(m.MaxSupply, false), m.CirculatingSupply/m.MaxSupply*100)
+	} else {
+		b.WriteString(", MAX SUPPLY: uncapped")
+	}
+	if m.MarketDominance > 0 {
+		fmt.Fprintf(&b, ", MARKET DOMINANCE: %.2f%%", m.MarketDominance)
+	}
+	if len(m.Exchanges) > 0 {
+		shares := make([]string, 0, len(m.Exchanges))
+		for _, e := range m.Exchanges {
+			shares = append(shares, fmt.Sprintf("%s %.2f%%", e.Exchange, e.VolumeShare))
+		}
+		fmt.Fprintf(&b, ", EXCHANGE VOLUME DOMINANCE: %s", strings.Join(shares, ", "))
+	}
+	if len(f.OnChain) > 0 {
+		keys := make([]string, 0, len(f.OnChain))
+		for k := range f.OnChain {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		stats := make([]string, 0, len(keys))
+		for _, k := range keys {
+			stats = append(stats, fmt.Sprintf("%s %s", strings.ToUpper(strings.ReplaceAll(k, "_", " ")), formatAmount(f.OnChain[k], false)))
+		}
+		fmt.Fprintf(&b, ", ON-CHAIN: %s", strings.Join(stats, ", "))
+	}
+	return b.String()
+}
+
+// formats large amounts without scientific notation
+func formatAmount(v float64, dollars bool) string {
+	s := fmt.Sprintf("%.2f", v)
+	if v >= 1000 {
+		s = fmt.Sprintf("%.0f", v)
+	}
+	if dollars {
+		return "$" + s
+	}
+	return s
+}
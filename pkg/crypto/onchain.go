@@ -0,0 +1,55 @@
+package crypto
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// HTTPOnChain reads on-chain statistics from a JSON endpoint. The URL template
+// may contain {symbol}, the response must be a flat object of numeric metrics
+type HTTPOnChain struct {
+	URLTemplate string
+	Client      *http.Client
+}
+
+func NewHTTPOnChain(urlTemplate string) *HTTPOnChain {
+	return &HTTPOnChain{
+		URLTemplate: urlTemplate,
+		Client:      &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+func (h *HTTPOnChain) Stats(ctx context.Context, symbol string) (OnChainStats, error) {
+	reqURL := strings.ReplaceAll(h.URLTemplate, "{symbol}", strings.ToLower(symbol))
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := h.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("on-chain source returned status %d", resp.StatusCode)
+	}
+
+	// keep only numeric fields, anything else is not a statistic
+	var raw map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return nil, err
+	}
+	stats := make(OnChainStats)
+	for k, v := range raw {
+		if f, ok := v.(float64); ok {
+			stats[k] = f
+		}
+	}
+	return stats, nil
+}
@@ -0,0 +1,471 @@
+package daterange
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Range is an inclusive span of days
+type Range struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+	// Matched is the text the range was read from
+	Matched string `json:"matched,omitempty"`
+}
+
+// End is the last instant of the To day, for comparing timestamps
+func (r Range) End() time.Time {
+	return r.To.AddDate(0, 0, 1).Add(-time.Nanosecond)
+}
+
+// Keys are the From and To days as YYYYMMDD ints
+func (r Range) Keys() (int, int) {
+	return dayKey(r.From), dayKey(r.To)
+}
+
+func dayKey(t time.Time) int {
+	return t.Year()*10000 + int(t.Month())*100 + t.Day()
+}
+
+// Parser reads date ranges from questions relative to Now
+type Parser struct {
+	Now time.Time
+	// FiscalYearStart is the first month of the fiscal year. Fiscal year N
+	// ends in calendar year N, so with October FY2024 starts in October 2023
+	FiscalYearStart time.Month
+}
+
+// Parse reads the dates a question is about with the calendar fiscal year
+func Parse(text string, now time.Time) (Range, bool) {
+	return Parser{Now: now}.Parse(text)
+}
+
+func day(year int, month time.Month, d int, loc *time.Location) time.Time {
+	return time.Date(year, month, d, 0, 0, 0, 0, loc)
+}
+
+func (p Parser) today() time.Time {
+	now := p.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	return day(now.Year(), now.Month(), now.Day(), now.Location())
+}
+
+func (p Parser) fiscalStart() time.Month {
+	if p.FiscalYearStart < time.January || p.FiscalYearStart > time.December {
+		return time.January
+	}
+	return p.FiscalYearStart
+}
+
+// span is a period starting at from and lasting months months
+func span(from time.Time, months int) Range {
+	return Range{From: from, To: from.AddDate(0, months, -1)}
+}
+
+func yearRange(year int, loc *time.Location) Range {
+	return span(day(year, time.January, 1, loc), 12)
+}
+
+func quarterRange(year int, quarter int, loc *time.Location) Range {
+	return span(day(year, time.Month(3*(quarter-1)+1), 1, loc), 3)
+}
+
+// fiscalYear is the range of fiscal year N
+func (p Parser) fiscalYear(year int, loc *time.Location) Range {
+	start := p.fiscalStart()
+	if start == time.January {
+		return yearRange(year, loc)
+	}
+	return span(day(year-1, start, 1, loc), 12)
+}
+
+// fiscalPeriod is the fiscal year or quarter containing t
+func (p Parser) fiscalPeriod(t time.Time, unit string) Range {
+	year := t.Year()
+	if start := p.fiscalStart(); start != time.January && t.Month() >= start {
+		year++
+	}
+	fy := p.fiscalYear(year, t.Location())
+	if unit != "quarter" {
+		return fy
+	}
+	quarter := (int(t.Month()) - int(p.fiscalStart()) + 12) % 12 / 3
+	return span(fy.From.AddDate(0, 3*quarter, 0), 3)
+}
+
+// trailing is the window of n units ending today
+func (p Parser) trailing(n int, unit string) Range {
+	today := p.today()
+	var from time.Time
+	switch unit {
+	case "day":
+		from = today.AddDate(0, 0, -n+1)
+	case "week":
+		from = today.AddDate(0, 0, -7*n+1)
+	case "month":
+		from = today.AddDate(0, -n, 1)
+	case "quarter":
+		from = today.AddDate(0, -3*n, 1)
+	default:
+		from = today.AddDate(-n, 0, 1)
+	}
+	return Range{From: from, To: today}
+}
+
+// period is the calendar unit containing t, weeks start on Monday
+func period(t time.Time, unit string) Range {
+	switch unit {
+	case "day":
+		return Range{From: t, To: t}
+	case "week":
+		from := t.AddDate(0, 0, -((int(t.Weekday()) + 6) % 7))
+		return Range{From: from, To: from.AddDate(0, 0, 6)}
+	case "month":
+		return span(day(t.Year(), t.Month(), 1, t.Location()), 1)
+	case "quarter":
+		return quarterRange(t.Year(), (int(t.Month())-1)/3+1, t.Location())
+	default:
+		return yearRange(t.Year(), t.Location())
+	}
+}
+
+// shift moves t by n units
+func shift(t time.Time, n int, unit string) time.Time {
+	switch unit {
+	case "day":
+		return t.AddDate(0, 0, n)
+	case "week":
+		return t.AddDate(0, 0, 7*n)
+	case "month":
+		return t.AddDate(0, n, 0)
+	case "quarter":
+		return t.AddDate(0, 3*n, 0)
+	default:
+		return t.AddDate(n, 0, 0)
+	}
+}
+
+var months = map[string]time.Month{
+	"jan": time.January, "january": time.January, "feb": time.February, "february": time.February,
+	"mar": time.March, "march": time.March, "apr": time.April, "april": time.April, "may": time.May,
+	"jun": time.June, "june": time.June, "jul": time.July, "july": time.July,
+	"aug": time.August, "august": time.August, "sep": time.September, "sept": time.September,
+	"september": time.September, "oct": time.October, "october": time.October,
+	"nov": time.November, "november": time.November, "dec": time.December, "december": time.December,
+}
+
+var numbers = map[string]int{
+	"a": 1, "an": 1, "one": 1, "two": 2, "three": 3, "four": 4, "five": 5, "six": 6,
+	"seven": 7, "eight": 8, "nine": 9, "ten": 10, "eleven": 11, "twelve": 12,
+	"couple": 2, "couple of": 2, "few": 3, "several": 3,
+}
+
+var ordinals = map[string]int{
+	"1": 1, "2": 2, "3": 3, "4": 4, "first": 1, "second": 2, "third": 3, "fourth": 4,
+	"1st": 1, "2nd": 2, "3rd": 3, "4th": 4,
+}
+
+const (
+	monthNames = `jan(?:uary)?|feb(?:ruary)?|mar(?:ch)?|apr(?:il)?|may|june?|july?|aug(?:ust)?|sept?(?:ember)?|oct(?:ober)?|nov(?:ember)?|dec(?:ember)?`
+	units      = `day|week|month|quarter|year`
+	count      = `\d{1,3}|a|an|one|two|three|four|five|six|seven|eight|nine|ten|eleven|twelve|couple(?: of)?|few|several`
+	yearPat    = `(?:19|20)\d{2}`
+	fiscalPat  = `(?:fy|fiscal(?: year)?)\s*'?`
+)
+
+// a rule reads one date expression, match holds the submatches
+type rule struct {
+	pattern *regexp.Regexp
+	read    func(p Parser, match []string) (Range, bool)
+}
+
+func parseYear(s string) int {
+	s = strings.TrimPrefix(s, "'")
+	year, _ := strconv.Atoi(s)
+	if len(s) == 2 {
+		year += 2000
+	}
+	return year
+}
+
+func parseCount(s string) int {
+	if n, err := strconv.Atoi(s); err == nil {
+		return n
+	}
+	return numbers[s]
+}
+
+// latestYear is the year of the most recent occurrence of a month or quarter
+// starting at month, this year unless that is still ahead
+func (p Parser) latestYear(month time.Month, strictlyBefore bool) int {
+	today := p.today()
+	year := today.Year()
+	if month > today.Month() || strictlyBefore && month == today.Month() {
+		year--
+	}
+	return year
+}
+
+func validDay(year int, month time.Month, d int, loc *time.Location) (Range, bool) {
+	if month < time.January || month > time.December || d < 1 || d > 31 {
+		return Range{}, false
+	}
+	t := day(year, month, d, loc)
+	if t.Month() != month {
+		return Range{}, false
+	}
+	return Range{From: t, To: t}, true
+}
+
+// rules are tried in order, a later rule only matches text no earlier rule took
+var rules = []rule{
+	// 2024-03-05
+	{regexp.MustCompile(`\b(` + yearPat + `)-(\d{1,2})-(\d{1,2})\b`), func(p Parser, m []string) (Range, bool) {
+		month, _ := strconv.Atoi(m[2])
+		d, _ := strconv.Atoi(m[3])
+		return validDay(parseYear(m[1]), time.Month(month), d, p.today().Location())
+	}},
+	// 03/05/2024, month first
+	{regexp.MustCompile(`\b(\d{1,2})/(\d{1,2})/(` + yearPat + `)\b`), func(p Parser, m []string) (Range, bool) {
+		month, _ := strconv.Atoi(m[1])
+		d, _ := strconv.Atoi(m[2])
+		return validDay(parseYear(m[3]), time.Month(month), d, p.today().Location())
+	}},
+	// March 5, 2024
+	{regexp.MustCompile(`\b(` + monthNames + `)\.?\s+(\d{1,2})(?:st|nd|rd|th)?,?\s+(` + yearPat + `)\b`), func(p Parser, m []string) (Range, bool) {
+		d, _ := strconv.Atoi(m[2])
+		return validDay(parseYear(m[3]), months[m[1]], d, p.today().Location())
+	}},
+	// 5 March 2024
+	{regexp.MustCompile(`\b(\d{1,2})(?:st|nd|rd|th)?\s+(?:of\s+)?(` + monthNames + `)\.?,?\s+(` + yearPat + `)\b`), func(p Parser, m []string) (Range, bool) {
+		d, _ := strconv.Atoi(m[1])
+		return validDay(parseYear(m[3]), months[m[2]], d, p.today().Location())
+	}},
+	// March 2024
+	{regexp.MustCompile(`\b(` + monthNames + `)\.?\s+(?:of\s+)?(` + yearPat + `)\b`), func(p Parser, m []string) (Range, bool) {
+		return span(day(parseYear(m[2]), months[m[1]], 1, p.today().Location()), 1), true
+	}},
+	// Q3 FY2024, fiscal Q3 2024, Q3 fiscal 2024
+	{regexp.MustCompile(`\b(?:fiscal\s+)?q([1-4])\s*(?:of\s+)?` + fiscalPat + `(` + yearPat + `|\d{2})\b|\bfiscal\s+q([1-4])\s*(?:of\s+)?'?(` + yearPat + `|\d{2})\b|\b` + fiscalPat + `(` + yearPat + `|\d{2})\s*q([1-4])\b`), func(p Parser, m []string) (Range, bool) {
+		quarter, year := ordinals[m[1]+m[3]+m[6]], parseYear(m[2]+m[4]+m[5])
+		fy := p.fiscalYear(year, p.today().Location())
+		return span(fy.From.AddDate(0, 3*(quarter-1), 0), 3), true
+	}},
+	// Q2 2025, Q2 '25, 2025 Q2, second quarter of 2025
+	{regexp.MustCompile(`\bq([1-4])\s*(?:of\s+)?(` + yearPat + `|'\d{2})\b|\b(` + yearPat + `)\s*q([1-4])\b|\b(first|second|third|fourth|1st|2nd|3rd|4th)\s+quarter\s+(?:of\s+)?(` + yearPat + `)\b`), func(p Parser, m []string) (Range, bool) {
+		quarter, year := ordinals[m[1]+m[4]+m[5]], parseYear(m[2]+m[3]+m[6])
+		return quarterRange(year, quarter, p.today().Location()), true
+	}},
+	// H1 2024, first half of 2024
+	{regexp.MustCompile(`\b(?:h([12])|(first|second|1st|2nd)\s+half)\s*(?:of\s+)?(` + yearPat + `)\b`), func(p Parser, m []string) (Range, bool) {
+		half := ordinals[m[1]+m[2]]
+		return span(day(parseYear(m[3]), time.Month(6*(half-1)+1), 1, p.today().Location()), 6), true
+	}},
+	// FY2024, fiscal 2024, FY24
+	{regexp.MustCompile(`\b` + fiscalPat + `(` + yearPat + `|\d{2})\b`), func(p Parser, m []string) (Range, bool) {
+		return p.fiscalYear(parseYear(m[1]), p.today().Location()), true
+	}},
+	// fiscal year to date, FYTD, fiscal quarter to date
+	{regexp.MustCompile(`\b(?:f(y|q)td|fiscal\s+(y|q)td|fiscal\s+(year|quarter)[- ]to[- ]date)\b`), func(p Parser, m []string) (Range, bool) {
+		unit := "year"
+		if m[1]+m[2] == "q" || m[3] == "quarter" {
+			unit = "quarter"
+		}
+		today := p.today()
+		return Range{From: p.fiscalPeriod(today, unit).From, To: today}, true
+	}},
+	// last two fiscal quarters: the fiscal periods before the current one
+	{regexp.MustCompile(`\b(?:past|last|previous|prior|trailing)\s+(` + count + `)\s+fiscal\s+(year|quarter)s?\b`), func(p Parser, m []string) (Range, bool) {
+		n := parseCount(m[1])
+		if n <= 0 {
+			return Range{}, false
+		}
+		current := p.fiscalPeriod(p.today(), m[2])
+		return Range{From: shift(current.From, -n, m[2]), To: current.From.AddDate(0, 0, -1)}, true
+	}},
+	// this fiscal year, last fiscal quarter
+	{regexp.MustCompile(`\b(this|current|last|previous|prior)\s+fiscal\s+(year|quarter)\b`), func(p Parser, m []string) (Range, bool) {
+		current := p.fiscalPeriod(p.today(), m[2])
+		if m[1] == "this" || m[1] == "current" {
+			return current, true
+		}
+		return p.fiscalPeriod(current.From.AddDate(0, 0, -1), m[2]), true
+	}},
+	// year to date, quarter to date, month to date
+	{regexp.MustCompile(`\b(ytd|qtd|mtd|(year|quarter|month)[- ]to[- ]date)\b`), func(p Parser, m []string) (Range, bool) {
+		unit := map[string]string{"ytd": "year", "qtd": "quarter", "mtd": "month"}[m[1]]
+		if unit == "" {
+			unit = m[2]
+		}
+		today := p.today()
+		return Range{From: period(today, unit).From, To: today}, true
+	}},
+	// trailing twelve months
+	{regexp.MustCompile(`\b(ttm|ltm|trailing (?:twelve|12) months|last (?:twelve|12) months)\b`), func(p Parser, m []string) (Range, bool) {
+		return p.trailing(12, "month"), true
+	}},
+	// past 30 days, last two quarters, previous 6 months
+	{regexp.MustCompile(`\b(?:past|last|previous|prior|trailing)\s+(` + count + `)\s+(` + units + `)s?\b`), func(p Parser, m []string) (Range, bool) {
+		n := parseCount(m[1])
+		if n <= 0 {
+			return Range{}, false
+		}
+		return p.trailing(n, m[2]), true
+	}},
+	// 3 months ago
+	{regexp.MustCompile(`\b(` + count + `)\s+(` + units + `)s?\s+ago\b`), func(p Parser, m []string) (Range, bool) {
+		return period(shift(p.today(), -parseCount(m[1]), m[2]), m[2]), true
+	}},
+	// past week, over the past year: a trailing window
+	{regexp.MustCompile(`\bpast\s+(` + units + `)\b`), func(p Parser, m []string) (Range, bool) {
+		return p.trailing(1, m[1]), true
+	}},
+	// last quarter, previous year: the calendar period before the current one
+	{regexp.MustCompile(`\b(?:last|previous|prior)\s+(` + units + `)\b`), func(p Parser, m []string) (Range, bool) {
+		return period(shift(p.today(), -1, m[1]), m[1]), true
+	}},
+	// this quarter, current year
+	{regexp.MustCompile(`\b(?:this|current)\s+(` + units + `)\b`), func(p Parser, m []string) (Range, bool) {
+		return period(p.today(), m[1]), true
+	}},
+	{regexp.MustCompile(`\b(today|yesterday)\b`), func(p Parser, m []string) (Range, bool) {
+		if m[1] == "yesterday" {
+			return period(p.today().AddDate(0, 0, -1), "day"), true
+		}
+		return period(p.today(), "day"), true
+	}},
+	// Q2, the second quarter: the latest one that has started
+	{regexp.MustCompile(`\bq([1-4])\b|\b(first|second|third|fourth|1st|2nd|3rd|4th)\s+quarter\b`), func(p Parser, m []string) (Range, bool) {
+		quarter := ordinals[m[1]+m[2]]
+		return quarterRange(p.latestYear(time.Month(3*(quarter-1)+1), false), quarter, p.today().Location()), true
+	}},
+	// in March, last March: the latest March that has started. Month names
+	// need a preposition so "may" is not read as a month
+	{regexp.MustCompile(`\b(in|since|during|from|until|through|last|this|early|late|mid)[\s-]+(` + monthNames + `)\b`), func(p Parser, m []string) (Range, bool) {
+		month := months[m[2]]
+		rng := span(day(p.latestYear(month, m[1] == "last"), month, 1, p.today().Location()), 1)
+		if m[1] == "since" || m[1] == "from" {
+			rng.To = p.today()
+		}
+		return rng, true
+	}},
+	// 2024
+	{regexp.MustCompile(`\b(` + yearPat + `)\b`), func(p Parser, m []string) (Range, bool) {
+		return yearRange(parseYear(m[1]), p.today().Location()), true
+	}},
+}
+
+// open ended ranges run until today
+var sincePrefix = regexp.MustCompile(`\b(since|after|starting|from)\s+(?:the\s+)?(?:start\s+of\s+|beginning\s+of\s+)?$`)
+
+// joins the two ends of "from X to Y"
+var rangeJoin = regexp.MustCompile(`^\s*(to|through|until|till|and|-|–)\s*$`)
+
+// a found expression and where it is in the text
+type found struct {
+	Range
+	start, end int
+}
+
+// Parse reads the dates a question is about. Several expressions, as in
+// "between Q1 2024 and Q3 2024" or "compare 2023 with 2024", cover every one
+// of them; "since X" runs from X to today. It reports false when the text
+// names no date it understands
+func (p Parser) Parse(text string) (Range, bool) {
+	lower := strings.ToLower(text)
+	taken := make([]bool, len(lower))
+	var hits []found
+	for _, r := range rules {
+		for _, loc := range r.pattern.FindAllStringSubmatchIndex(lower, -1) {
+			if overlaps(taken, loc[0], loc[1]) {
+				continue
+			}
+			match := make([]string, len(loc)/2)
+			for i := range match {
+				if loc[2*i] >= 0 {
+					match[i] = lower[loc[2*i]:loc[2*i+1]]
+				}
+			}
+			rng, ok := r.read(p, match)
+			if !ok {
+				continue
+			}
+			for i := loc[0]; i < loc[1]; i++ {
+				taken[i] = true
+			}
+			rng.Matched = text[loc[0]:loc[1]]
+			hits = append(hits, found{Range: rng, start: loc[0], end: loc[1]})
+		}
+	}
+	if len(hits) == 0 {
+		return Range{}, false
+	}
+
+	sort.Slice(hits, func(i, j int) bool { return hits[i].start < hits[j].start })
+	today := p.today()
+	var out Range
+	var matched []string
+	for i, hit := range hits {
+		before := lower[:hit.start]
+		// "from X to Y" is a closed range, "from X" alone is open ended
+		isFromTo := i+1 < len(hits) && rangeJoin.MatchString(lower[hit.end:hits[i+1].start])
+		if sincePrefix.MatchString(before) && !isFromTo && hit.To.Before(today) {
+			hit.To = today
+		}
+		if out.From.IsZero() || hit.From.Before(out.From) {
+			out.From = hit.From
+		}
+		if out.To.IsZero() || hit.To.After(out.To) {
+			out.To = hit.To
+		}
+		matched = append(matched, hit.Matched)
+	}
+	out.Matched = strings.Join(matched, ", ")
+	return out, true
+}
+
+func overlaps(taken []bool, start int, end int) bool {
+	for i := start; i < end; i++ {
+		if taken[i] {
+			return true
+		}
+	}
+	return false
+}
+
+// temporal words the parser may not resolve, a question using them is worth
+// asking the language model about
+var temporal = regexp.MustCompile(`(?i)\b(ago|f?ytd|f?qtd|mtd|ttm|ltm|quarters?|fiscal|years?|months?|weeks?|days?|decades?|since|until|before|after|season|earnings|q[1-4]|h[12]|` + yearPat + `|january|february|march|april|june|july|august|september|october|november|december)\b`)
+
+// MentionsTime reports whether text talks about a time at all
+func MentionsTime(text string) bool {
+	return temporal.MatchString(text)
+}
+
+var isoDate = regexp.MustCompile(`\b(` + yearPat + `)-(\d{2})-(\d{2})\b`)
+
+// ParseDates reads the YYYY-MM-DD dates in a language model answer, which
+// may come with extra text, and returns the range they span
+func ParseDates(text string, loc *time.Location) (Range, bool) {
+	var out Range
+	for _, m := range isoDate.FindAllStringSubmatch(text, -1) {
+		month, _ := strconv.Atoi(m[2])
+		d, _ := strconv.Atoi(m[3])
+		rng, ok := validDay(parseYear(m[1]), time.Month(month), d, loc)
+		if !ok {
+			continue
+		}
+		if out.From.IsZero() || rng.From.Before(out.From) {
+			out.From = rng.From
+		}
+		if out.To.IsZero() || rng.To.After(out.To) {
+			out.To = rng.To
+		}
+	}
+	return out, !out.From.IsZero()
+}
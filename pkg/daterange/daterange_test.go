@@ -0,0 +1,53 @@
+package daterange
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseFiscal(t *testing.T) {
+	now := time.Date(2026, time.October, 19, 15, 0, 0, 0, time.UTC)
+	october := Parser{Now: now, FiscalYearStart: time.October}
+	calendar := Parser{Now: now}
+
+	cases := []struct {
+		parser   Parser
+		text     string
+		from, to string
+	}{
+		{october, "revenue fiscal year to date", "2026-10-01", "2026-10-19"},
+		{october, "How is FYTD looking?", "2026-10-01", "2026-10-19"},
+		{october, "fiscal ytd margins", "2026-10-01", "2026-10-19"},
+		{october, "fiscal quarter to date", "2026-10-01", "2026-10-19"},
+		{october, "guidance for this fiscal year", "2026-10-01", "2027-09-30"},
+		{october, "current fiscal quarter", "2026-10-01", "2026-12-31"},
+		{october, "results of last fiscal year", "2025-10-01", "2026-09-30"},
+		{october, "last fiscal quarter", "2026-07-01", "2026-09-30"},
+		{october, "the past two fiscal quarters", "2026-04-01", "2026-09-30"},
+		{october, "FY2024", "2023-10-01", "2024-09-30"},
+		{october, "Q1 FY2027", "2026-10-01", "2026-12-31"},
+		{october, "year to date", "2026-01-01", "2026-10-19"},
+		{calendar, "fiscal year to date", "2026-01-01", "2026-10-19"},
+		{calendar, "last fiscal year", "2025-01-01", "2025-12-31"},
+		{calendar, "last fiscal quarter", "2026-07-01", "2026-09-30"},
+	}
+	for _, c := range cases {
+		rng, ok := c.parser.Parse(c.text)
+		if !ok {
+			t.Errorf("%q: not parsed", c.text)
+			continue
+		}
+		from, to := rng.From.Format("2006-01-02"), rng.To.Format("2006-01-02")
+		if from != c.from || to != c.to {
+			t.Errorf("%q with fiscal year from %s: got %s to %s, want %s to %s", c.text, c.parser.fiscalStart(), from, to, c.from, c.to)
+		}
+	}
+}
+
+func TestMentionsTimeAbbreviations(t *testing.T) {
+	for _, text := range []string{"FYTD revenue", "ytd return", "TTM EPS"} {
+		if !MentionsTime(text) {
+			t.Errorf("%q should mention a time", text)
+		}
+	}
+}
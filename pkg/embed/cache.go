@@ -0,0 +1,164 @@
+package embed
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Cache stores embeddings by key
+type Cache interface {
+	Get(key string) ([]float32, bool)
+	Set(key string, vector []float32)
+}
+
+// Key identifies an embedding by model, input kind and a hash of the text
+func Key(model string, kind InputKind, text string) string {
+	sum := sha256.Sum256([]byte(model + "\x00" + string(kind) + "\x00" + text))
+	return hex.EncodeToString(sum[:])
+}
+
+// LRU is an in-memory cache holding the most recently used Size embeddings
+type LRU struct {
+	Size int
+
+	mu    sync.Mutex
+	order *list.List
+	items map[string]*list.Element
+}
+
+type lruEntry struct {
+	key    string
+	vector []float32
+}
+
+// NewLRU returns an LRU cache of the given size
+func NewLRU(size int) *LRU {
+	return &LRU{Size: size, order: list.New(), items: make(map[string]*list.Element)}
+}
+
+func (c *LRU) Get(key string) ([]float32, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.items[key]; ok {
+		c.order.MoveToFront(element)
+		return element.Value.(*lruEntry).vector, true
+	}
+	return nil, false
+}
+
+func (c *LRU) Set(key string, vector []float32) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.items[key]; ok {
+		element.Value.(*lruEntry).vector = vector
+		c.order.MoveToFront(element)
+		return
+	}
+	c.items[key] = c.order.PushFront(&lruEntry{key: key, vector: vector})
+	for c.Size > 0 && c.order.Len() > c.Size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*lruEntry).key)
+	}
+}
+
+// DiskCache stores each embedding as little endian float32 values in a file
+// named by its key
+type DiskCache struct {
+	Dir string
+}
+
+func (c DiskCache) path(key string) string {
+	return filepath.Join(c.Dir, key[:2], key+".f32")
+}
+
+func (c DiskCache) Get(key string) ([]float32, bool) {
+	data, err := os.ReadFile(c.path(key))
+	if err != nil || len(data)%4 != 0 {
+		return nil, false
+	}
+	vector := make([]float32, len(data)/4)
+	for i := range vector {
+		vector[i] = math.Float32frombits(binary.LittleEndian.Uint32(data[i*4:]))
+	}
+	return vector, true
+}
+
+func (c DiskCache) Set(key string, vector []float32) {
+	data := make([]byte, len(vector)*4)
+	for i, x := range vector {
+		binary.LittleEndian.PutUint32(data[i*4:], math.Float32bits(x))
+	}
+	path := c.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err == nil {
+		os.Rename(tmp, path)
+	}
+}
+
+// Cached wraps an embedder with caches checked in order, the memory cache
+// first. Only texts missing from every cache are sent to the embedder, and
+// repeated texts in one call are embedded once
+type Cached struct {
+	Embedder
+	Caches []Cache
+}
+
+func (c *Cached) lookup(key string) ([]float32, bool) {
+	for i, cache := range c.Caches {
+		if vector, ok := cache.Get(key); ok && len(vector) == c.Dimension() {
+			// promote to the faster caches
+			for _, faster := range c.Caches[:i] {
+				faster.Set(key, vector)
+			}
+			return vector, true
+		}
+	}
+	return nil, false
+}
+
+func (c *Cached) Embed(ctx context.Context, texts []string, kind InputKind) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	keys := make([]string, len(texts))
+	pending := make(map[string][]int)
+	var missing []string
+	for i, text := range texts {
+		keys[i] = Key(c.Model(), kind, text)
+		if vector, ok := c.lookup(keys[i]); ok {
+			vectors[i] = vector
+			continue
+		}
+		if _, queued := pending[keys[i]]; !queued {
+			missing = append(missing, text)
+		}
+		pending[keys[i]] = append(pending[keys[i]], i)
+	}
+	if len(missing) == 0 {
+		return vectors, nil
+	}
+
+	embedded, err := Batch(ctx, c.Embedder, missing, kind)
+	if err != nil {
+		return nil, err
+	}
+	for j, text := range missing {
+		key := Key(c.Model(), kind, text)
+		for _, cache := range c.Caches {
+			cache.Set(key, embedded[j])
+		}
+		for _, i := range pending[key] {
+			vectors[i] = embedded[j]
+		}
+	}
+	return vectors, nil
+}
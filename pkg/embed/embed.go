@@ -0,0 +1,72 @@
+package embed
+
+import (
+	"context"
+	"fmt"
+)
+
+// InputKind tells asymmetric models whether a text is a search query or a
+// stored passage
+type InputKind string
+
+const (
+	Query   InputKind = "query"
+	Passage InputKind = "passage"
+)
+
+// Embedder turns texts into vectors of a fixed dimension
+type Embedder interface {
+	// Model names the model, cached vectors are keyed by it
+	Model() string
+	Dimension() int
+	// MaxBatch is the most texts a single Embed call accepts
+	MaxBatch() int
+	Embed(ctx context.Context, texts []string, kind InputKind) ([][]float32, error)
+}
+
+// Batch embeds any number of texts in MaxBatch sized calls
+func Batch(ctx context.Context, e Embedder, texts []string, kind InputKind) ([][]float32, error) {
+	size := e.MaxBatch()
+	if size <= 0 {
+		size = len(texts)
+	}
+	vectors := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += size {
+		end := start + size
+		if end > len(texts) {
+			end = len(texts)
+		}
+		batch, err := e.Embed(ctx, texts[start:end], kind)
+		if err != nil {
+			return nil, err
+		}
+		if len(batch) != end-start {
+			return nil, fmt.Errorf("%s returned %d embeddings for %d texts", e.Model(), len(batch), end-start)
+		}
+		for _, v := range batch {
+			if len(v) != e.Dimension() {
+				return nil, fmt.Errorf("%s returned a %d dimension embedding, expected %d", e.Model(), len(v), e.Dimension())
+			}
+		}
+		vectors = append(vectors, batch...)
+	}
+	return vectors, nil
+}
+
+// One embeds a single text
+func One(ctx context.Context, e Embedder, text string, kind InputKind) ([]float32, error) {
+	vectors, err := Batch(ctx, e, []string{text}, kind)
+	if err != nil {
+		return nil, err
+	}
+	return vectors[0], nil
+}
+
+// CheckDimension reports an embedder whose vectors do not fit a store of the
+// given dimension. A dimension of 0 is an empty store that accepts any
+func CheckDimension(e Embedder, storeDimension int) error {
+	if storeDimension != 0 && storeDimension != e.Dimension() {
+		return fmt.Errorf("%s embeds %d dimensions but the vector store holds %d", e.Model(), e.Dimension(), storeDimension)
+	}
+	return nil
+}
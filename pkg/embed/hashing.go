@@ -0,0 +1,68 @@
+package embed
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+
+	"fineas/pkg/fulltext"
+)
+
+// Hashing is a deterministic local embedder for tests and offline use. Terms
+// and adjacent term pairs are hashed into signed buckets, so texts sharing
+// words have similar vectors without any model or network
+type Hashing struct {
+	Dim int
+}
+
+// NewHashing returns a hashing embedder, 256 dimensions by default
+func NewHashing(dim int) *Hashing {
+	if dim <= 0 {
+		dim = 256
+	}
+	return &Hashing{Dim: dim}
+}
+
+func (h *Hashing) Model() string  { return "hashing" }
+func (h *Hashing) Dimension() int { return h.Dim }
+func (h *Hashing) MaxBatch() int  { return 0 }
+
+func (h *Hashing) Embed(ctx context.Context, texts []string, kind InputKind) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vectors[i] = h.vector(text)
+	}
+	return vectors, nil
+}
+
+func (h *Hashing) vector(text string) []float32 {
+	v := make([]float32, h.Dim)
+	terms := fulltext.Terms(text)
+	add := func(feature string, weight float32) {
+		hash := fnv.New64a()
+		hash.Write([]byte(feature))
+		sum := hash.Sum64()
+		sign := float32(1)
+		if sum&1 == 1 {
+			sign = -1
+		}
+		v[(sum>>1)%uint64(h.Dim)] += sign * weight
+	}
+	for i, term := range terms {
+		add(term, 1)
+		if i > 0 {
+			add(terms[i-1]+" "+term, 0.5)
+		}
+	}
+	var norm float64
+	for _, x := range v {
+		norm += float64(x) * float64(x)
+	}
+	if norm > 0 {
+		scale := float32(1 / math.Sqrt(norm))
+		for i := range v {
+			v[i] *= scale
+		}
+	}
+	return v
+}
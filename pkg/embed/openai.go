@@ -0,0 +1,98 @@
+package embed
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// OpenAIEmbeddingsURL is the OpenAI embeddings endpoint
+const OpenAIEmbeddingsURL = "https://api.openai.com/v1/embeddings"
+
+// OpenAI embeds with the OpenAI embeddings API. The text-embedding-3 models
+// shorten their vectors to Dim when it is below their native size
+type OpenAI struct {
+	URL       string
+	APIKey    string
+	ModelName string
+	Dim       int
+	HTTP      *http.Client
+}
+
+var openAIDimensions = map[string]int{
+	"text-embedding-3-small": 1536,
+	"text-embedding-3-large": 3072,
+	"text-embedding-ada-002": 1536,
+}
+
+// NewOpenAI returns an OpenAI embedder, text-embedding-3-small at its native
+// dimension unless dim is set
+func NewOpenAI(apiKey string, model string, dim int) (*OpenAI, error) {
+	if model == "" {
+		model = "text-embedding-3-small"
+	}
+	native, ok := openAIDimensions[model]
+	if !ok {
+		return nil, fmt.Errorf("unknown OpenAI embedding model %s", model)
+	}
+	if dim <= 0 || dim > native {
+		dim = native
+	}
+	return &OpenAI{URL: OpenAIEmbeddingsURL, APIKey: apiKey, ModelName: model, Dim: dim, HTTP: &http.Client{Timeout: 30 * time.Second}}, nil
+}
+
+func (o *OpenAI) Model() string  { return o.ModelName }
+func (o *OpenAI) Dimension() int { return o.Dim }
+func (o *OpenAI) MaxBatch() int  { return 256 }
+
+type openAIRequest struct {
+	Model      string   `json:"model"`
+	Input      []string `json:"input"`
+	Dimensions int      `json:"dimensions,omitempty"`
+}
+
+type openAIResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+}
+
+func (o *OpenAI) Embed(ctx context.Context, texts []string, kind InputKind) ([][]float32, error) {
+	body := openAIRequest{Model: o.ModelName, Input: texts}
+	if o.Dim != openAIDimensions[o.ModelName] {
+		body.Dimensions = o.Dim
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.URL, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+o.APIKey)
+	resp, err := o.HTTP.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("OpenAI embeddings returned %s", resp.Status)
+	}
+	var decoded openAIResponse
+	if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil {
+		return nil, err
+	}
+	sort.Slice(decoded.Data, func(i, j int) bool { return decoded.Data[i].Index < decoded.Data[j].Index })
+	vectors := make([][]float32, len(decoded.Data))
+	for i, d := range decoded.Data {
+		vectors[i] = d.Embedding
+	}
+	return vectors, nil
+}
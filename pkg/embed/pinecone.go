@@ -0,0 +1,66 @@
+package embed
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pinecone-io/go-pinecone/pinecone"
+)
+
+// pineconeDimensions are the dimensions of the Pinecone hosted models
+var pineconeDimensions = map[string]int{
+	"multilingual-e5-large": 1024,
+	"llama-text-embed-v2":   1024,
+}
+
+// Pinecone embeds with Pinecone inference. The client is created once and
+// reused across calls
+type Pinecone struct {
+	Client    *pinecone.Client
+	ModelName string
+	Dim       int
+	// Truncate is END to cut long inputs or NONE to reject them
+	Truncate string
+}
+
+// NewPinecone returns a Pinecone embedder, multilingual-e5-large by default
+func NewPinecone(apiKey string, model string) (*Pinecone, error) {
+	if model == "" {
+		model = "multilingual-e5-large"
+	}
+	dim, ok := pineconeDimensions[model]
+	if !ok {
+		return nil, fmt.Errorf("unknown Pinecone embedding model %s", model)
+	}
+	client, err := pinecone.NewClient(pinecone.NewClientParams{ApiKey: apiKey})
+	if err != nil {
+		return nil, err
+	}
+	return &Pinecone{Client: client, ModelName: model, Dim: dim, Truncate: "END"}, nil
+}
+
+func (p *Pinecone) Model() string  { return p.ModelName }
+func (p *Pinecone) Dimension() int { return p.Dim }
+func (p *Pinecone) MaxBatch() int  { return 96 }
+
+func (p *Pinecone) Embed(ctx context.Context, texts []string, kind InputKind) ([][]float32, error) {
+	res, err := p.Client.Inference.Embed(ctx, &pinecone.EmbedRequest{
+		Model:      p.ModelName,
+		TextInputs: texts,
+		Parameters: pinecone.EmbedParameters{InputType: string(kind), Truncate: p.Truncate},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if res.Data == nil {
+		return nil, fmt.Errorf("no embedding data in response")
+	}
+	vectors := make([][]float32, 0, len(*res.Data))
+	for _, embedding := range *res.Data {
+		if embedding.Values == nil {
+			return nil, fmt.Errorf("embedding without values in response")
+		}
+		vectors = append(vectors, *embedding.Values)
+	}
+	return vectors, nil
+}
@@ -0,0 +1,170 @@
+package entity
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"fineas/pkg/registry"
+)
+
+// Mention is a tracked ticker found in a text
+type Mention struct {
+	Ticker string `json:"ticker"`
+	// Text is the symbol or company name as written
+	Text string `json:"text"`
+	// Offset is the byte offset of the first mention
+	Offset int `json:"offset"`
+}
+
+// Extractor finds tracked tickers in free text by symbol or company name
+type Extractor struct {
+	symbols map[string]bool
+	names   []name
+}
+
+type name struct {
+	ticker  string
+	pattern *regexp.Regexp
+	// single word names such as Target or Visa must be capitalized
+	capitalized bool
+}
+
+// acronyms that are also listed symbols but are far more likely to be plain
+// words in a question. They still match when written as $SYMBOL
+var acronyms = map[string]bool{
+	"AI": true, "CEO": true, "CFO": true, "EPS": true, "ETF": true, "GDP": true,
+	"IPO": true, "PE": true, "USA": true, "US": true, "IT": true, "ON": true,
+	"ALL": true, "NOW": true, "ARE": true, "BIG": true, "CAN": true, "FOR": true,
+	"HAS": true, "ONE": true, "OUT": true, "SEE": true, "TA": true, "YTD": true,
+	"EV": true, "FCF": true, "ROE": true, "ROI": true, "SEC": true, "FED": true,
+	"Q1": true, "Q2": true, "Q3": true, "Q4": true, "FY": true, "DCF": true,
+}
+
+// corporate suffixes and share classes dropped from labels so "Apple Inc."
+// matches "Apple"
+var suffix = regexp.MustCompile(`(?i)[\s,]+(inc|incorporated|corp|corporation|co|company|ltd|limited|plc|llc|lp|l\.p|n\.v|s\.a|ag|se|holdings?|group|class [a-c]|common stock|ordinary shares|the)\.?$`)
+
+// labels are either the company name or "SYMBOL - Company Name"
+var symbolPrefix = regexp.MustCompile(`^[A-Z0-9.\-]+\s+[-–:]\s+`)
+
+// New builds an extractor over the tracked tickers
+func New(tickers []registry.Ticker) *Extractor {
+	e := &Extractor{symbols: make(map[string]bool, len(tickers))}
+	for _, t := range tickers {
+		symbol := strings.ToUpper(strings.TrimSpace(t.Value))
+		if symbol == "" {
+			continue
+		}
+		e.symbols[symbol] = true
+		if company := CompanyName(t.Label); len(company) >= 3 && !strings.EqualFold(company, symbol) {
+			e.names = append(e.names, name{
+				ticker:      symbol,
+				pattern:     regexp.MustCompile(`(?i)\b` + regexp.QuoteMeta(company) + `(?:'s)?\b`),
+				capitalized: !strings.Contains(company, " "),
+			})
+		}
+	}
+	return e
+}
+
+// CompanyName strips a label down to the name people write
+func CompanyName(label string) string {
+	label = strings.TrimSpace(symbolPrefix.ReplaceAllString(strings.TrimSpace(label), ""))
+	for {
+		stripped := strings.TrimSpace(suffix.ReplaceAllString(label, ""))
+		if stripped == label || stripped == "" {
+			return strings.TrimRight(label, ",. ")
+		}
+		label = stripped
+	}
+}
+
+// Extract returns the tickers mentioned in text in order of first mention
+func (e *Extractor) Extract(text string) []Mention {
+	if e == nil {
+		return nil
+	}
+	found := map[string]Mention{}
+	add := func(ticker string, written string, offset int) {
+		if m, ok := found[ticker]; !ok || offset < m.Offset {
+			found[ticker] = Mention{Ticker: ticker, Text: written, Offset: offset}
+		}
+	}
+
+	for _, token := range tokens(text) {
+		word := token.text
+		dollar := strings.HasPrefix(word, "$")
+		symbol := strings.ToUpper(strings.TrimPrefix(word, "$"))
+		if !e.symbols[symbol] {
+			continue
+		}
+		// bare symbols must be written in capitals and not be a common acronym
+		if !dollar && (word != symbol || len(symbol) < 2 || acronyms[symbol]) {
+			continue
+		}
+		add(symbol, word, token.offset)
+	}
+	for _, n := range e.names {
+		for _, loc := range n.pattern.FindAllStringIndex(text, -1) {
+			if n.capitalized && !unicode.IsUpper([]rune(text[loc[0]:loc[1]])[0]) {
+				continue
+			}
+			add(n.ticker, text[loc[0]:loc[1]], loc[0])
+			break
+		}
+	}
+
+	mentions := make([]Mention, 0, len(found))
+	for _, m := range found {
+		mentions = append(mentions, m)
+	}
+	sort.Slice(mentions, func(i, j int) bool {
+		if mentions[i].Offset != mentions[j].Offset {
+			return mentions[i].Offset < mentions[j].Offset
+		}
+		return mentions[i].Ticker < mentions[j].Ticker
+	})
+	return mentions
+}
+
+// Tickers returns the tickers mentioned in text in order of first mention
+func (e *Extractor) Tickers(text string) []string {
+	var tickers []string
+	for _, m := range e.Extract(text) {
+		tickers = append(tickers, m.Ticker)
+	}
+	return tickers
+}
+
+type token struct {
+	text   string
+	offset int
+}
+
+// tokens splits text into symbol shaped words, keeping a leading $ and inner
+// dots and dashes as in BRK.B
+func tokens(text string) []token {
+	var out []token
+	start := -1
+	flush := func(end int) {
+		if start >= 0 {
+			word := strings.TrimRight(text[start:end], ".-")
+			if word != "" && word != "$" {
+				out = append(out, token{text: word, offset: start})
+			}
+			start = -1
+		}
+	}
+	for i, r := range text {
+		inWord := unicode.IsLetter(r) || unicode.IsDigit(r) || r == '.' || r == '-' || (r == '$' && start < 0)
+		if inWord && start < 0 {
+			start = i
+		} else if !inWord {
+			flush(i)
+		}
+	}
+	flush(len(text))
+	return out
+}
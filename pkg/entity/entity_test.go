@@ -0,0 +1,71 @@
+package entity
+
+import (
+	"reflect"
+	"testing"
+
+	"fineas/pkg/registry"
+)
+
+var tracked = []registry.Ticker{
+	{Value: "AAPL", Label: "Apple Inc."},
+	{Value: "MSFT", Label: "MSFT - Microsoft Corporation"},
+	{Value: "TGT", Label: "Target Corp"},
+	{Value: "BRK.B", Label: "Berkshire Hathaway Inc. Class B"},
+	{Value: "ON", Label: "ON Semiconductor Corporation"},
+	{Value: "AI", Label: "C3.ai, Inc."},
+	{Value: "BAC", Label: "Bank of America Corporation"},
+}
+
+func TestCompanyName(t *testing.T) {
+	cases := map[string]string{
+		"Apple Inc.":                         "Apple",
+		"MSFT - Microsoft Corporation":       "Microsoft",
+		"Berkshire Hathaway Inc. Class B":    "Berkshire Hathaway",
+		"The Walt Disney Company":            "The Walt Disney",
+		"Alphabet Inc. Class A Common Stock": "Alphabet",
+		"Holdings":                           "Holdings",
+	}
+	for label, want := range cases {
+		if got := CompanyName(label); got != want {
+			t.Errorf("CompanyName(%q) = %q, want %q", label, got, want)
+		}
+	}
+}
+
+func TestExtract(t *testing.T) {
+	e := New(tracked)
+	cases := []struct {
+		text string
+		want []string
+	}{
+		{"Compare AAPL and MSFT margins", []string{"AAPL", "MSFT"}},
+		{"How did Microsoft's cloud do versus Apple?", []string{"MSFT", "AAPL"}},
+		{"Is BRK.B cheaper than Bank of America?", []string{"BRK.B", "BAC"}},
+		// lowercase symbols and single word names written in lowercase are words
+		{"should i buy aapl or hit my target price", nil},
+		{"Target raised guidance", []string{"TGT"}},
+		// acronyms only count with a dollar sign
+		{"What does the AI boom mean ON a CEO level?", nil},
+		{"Thoughts on $AI and $on?", []string{"AI", "ON"}},
+		// a later mention by name does not move the first mention
+		{"Apple, then MSFT, then AAPL again", []string{"AAPL", "MSFT"}},
+		{"", nil},
+	}
+	for _, c := range cases {
+		if got := e.Tickers(c.text); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("Tickers(%q) = %v, want %v", c.text, got, c.want)
+		}
+	}
+
+	mentions := e.Extract("Is $msft ahead of Apple's?")
+	want := []Mention{{Ticker: "MSFT", Text: "$msft", Offset: 3}, {Ticker: "AAPL", Text: "Apple's", Offset: 18}}
+	if !reflect.DeepEqual(mentions, want) {
+		t.Errorf("got %+v, want %+v", mentions, want)
+	}
+
+	var none *Extractor
+	if got := none.Tickers("AAPL"); got != nil {
+		t.Errorf("a nil extractor found %v", got)
+	}
+}
@@ -0,0 +1,151 @@
+package eval
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Interaction is a recorded request and its response
+type Interaction struct {
+	Key     string `json:"key"`
+	Method  string `json:"method"`
+	Path    string `json:"path"`
+	Request string `json:"request,omitempty"`
+	Status  int    `json:"status"`
+	Body    string `json:"body"`
+}
+
+// Cassette replays recorded service responses. A request it has no
+// response for is recorded by forwarding it to the Upstreams base URL of its
+// path, without one it fails so a stale cassette is noticed
+type Cassette struct {
+	Path string
+	// Upstreams map request paths such as /llm to the base URL recorded from
+	Upstreams map[string]string
+	// Ignore are removed from request bodies before matching, such as the
+	// current date of a prompt
+	Ignore []*regexp.Regexp
+	HTTP   *http.Client
+
+	mu           sync.Mutex
+	interactions map[string]Interaction
+	dirty        bool
+}
+
+// OpenCassette loads the cassette at path, empty when the file does not exist
+func OpenCassette(path string) (*Cassette, error) {
+	c := &Cassette{Path: path, HTTP: http.DefaultClient, interactions: make(map[string]Interaction)}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var recorded []Interaction
+	if err := json.Unmarshal(data, &recorded); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	for _, interaction := range recorded {
+		c.interactions[interaction.Key] = interaction
+	}
+	return c, nil
+}
+
+func (c *Cassette) key(method string, path string, query string, body []byte) string {
+	for _, pattern := range c.Ignore {
+		body = pattern.ReplaceAll(body, nil)
+		query = pattern.ReplaceAllString(query, "")
+	}
+	sum := sha256.Sum256([]byte(method + " " + path + "?" + query + "\n" + string(body)))
+	return hex.EncodeToString(sum[:])
+}
+
+func (c *Cassette) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	key := c.key(r.Method, r.URL.Path, r.URL.RawQuery, body)
+
+	c.mu.Lock()
+	interaction, ok := c.interactions[key]
+	c.mu.Unlock()
+	if !ok {
+		upstream := c.Upstreams[r.URL.Path]
+		if upstream == "" {
+			http.Error(w, "no recorded response for "+r.Method+" "+r.URL.Path, http.StatusNotFound)
+			return
+		}
+		interaction, err = c.record(r, upstream, key, body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+	}
+	w.WriteHeader(interaction.Status)
+	io.WriteString(w, interaction.Body)
+}
+
+// record forwards a request upstream and keeps its response
+func (c *Cassette) record(r *http.Request, upstream string, key string, body []byte) (Interaction, error) {
+	target := strings.TrimSuffix(upstream, "/") + r.URL.Path
+	if r.URL.RawQuery != "" {
+		target += "?" + r.URL.RawQuery
+	}
+	req, err := http.NewRequestWithContext(r.Context(), r.Method, target, bytes.NewReader(body))
+	if err != nil {
+		return Interaction{}, err
+	}
+	req.Header = r.Header.Clone()
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return Interaction{}, err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return Interaction{}, err
+	}
+	interaction := Interaction{Key: key, Method: r.Method, Path: r.URL.Path, Request: string(body), Status: resp.StatusCode, Body: string(respBody)}
+	c.mu.Lock()
+	c.interactions[key] = interaction
+	c.dirty = true
+	c.mu.Unlock()
+	return interaction, nil
+}
+
+// Save writes the cassette when it recorded something, sorted by key so
+// re-recording only shows the changed interactions in a diff
+func (c *Cassette) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.dirty {
+		return nil
+	}
+	recorded := make([]Interaction, 0, len(c.interactions))
+	for _, interaction := range c.interactions {
+		recorded = append(recorded, interaction)
+	}
+	sort.Slice(recorded, func(i, j int) bool { return recorded[i].Key < recorded[j].Key })
+	data, err := json.MarshalIndent(recorded, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(c.Path, append(data, '\n'), 0o644); err != nil {
+		return err
+	}
+	c.dirty = false
+	return nil
+}
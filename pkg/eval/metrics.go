@@ -0,0 +1,188 @@
+package eval
+
+import (
+	"math"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"fineas/pkg/citation"
+)
+
+var (
+	number = regexp.MustCompile(`-?\d[\d,]*(?:\.\d+)?`)
+	scale  = regexp.MustCompile(`^\s*(thousand|million|billion|trillion|[kmbt]n?)\b`)
+)
+
+var scales = map[string]float64{
+	"thousand": 1e3, "k": 1e3,
+	"million": 1e6, "m": 1e6, "mn": 1e6,
+	"billion": 1e9, "b": 1e9, "bn": 1e9,
+	"trillion": 1e12, "t": 1e12, "tn": 1e12,
+}
+
+// Numbers reads the figures of a text. A figure followed by a scale such as
+// "1.2 billion" is read both as written and scaled
+func Numbers(text string) []float64 {
+	var numbers []float64
+	for _, loc := range number.FindAllStringIndex(text, -1) {
+		n, err := strconv.ParseFloat(strings.ReplaceAll(text[loc[0]:loc[1]], ",", ""), 64)
+		if err != nil {
+			continue
+		}
+		numbers = append(numbers, n)
+		if m := scale.FindStringSubmatch(strings.ToLower(text[loc[1]:])); m != nil {
+			numbers = append(numbers, n*scales[m[1]])
+		}
+	}
+	return numbers
+}
+
+// Matches reports whether one of the numbers is the fact's value within its
+// tolerance
+func (f Fact) Matches(numbers []float64) bool {
+	tolerance := f.Tolerance
+	if tolerance <= 0 {
+		tolerance = 0.01
+	}
+	for _, n := range numbers {
+		if f.Value == 0 {
+			if math.Abs(n) <= tolerance {
+				return true
+			}
+			continue
+		}
+		if math.Abs(n-f.Value) <= tolerance*math.Abs(f.Value) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizes text for passage matching
+func fold(text string) string {
+	return strings.ToLower(strings.Join(strings.Fields(text), " "))
+}
+
+// Retrieved are the first k sources that are retrieved chunks, search results
+// and tool outputs are not retrieval
+func Retrieved(sources []citation.Source, k int) []citation.Source {
+	var retrieved []citation.Source
+	for _, source := range sources {
+		if source.ChunkID == "" || source.Tool != "" {
+			continue
+		}
+		retrieved = append(retrieved, source)
+		if len(retrieved) == k {
+			break
+		}
+	}
+	return retrieved
+}
+
+// RecallAtK is the share of relevant passages found in the first k
+// retrieved chunks
+func RecallAtK(relevant []string, sources []citation.Source, k int) float64 {
+	if len(relevant) == 0 {
+		return 0
+	}
+	retrieved := Retrieved(sources, k)
+	found := 0
+	for _, passage := range relevant {
+		passage = fold(passage)
+		for _, source := range retrieved {
+			if strings.Contains(fold(source.Text+" "+source.Snippet), passage) {
+				found++
+				break
+			}
+		}
+	}
+	return float64(found) / float64(len(relevant))
+}
+
+// host of a URL without www
+func host(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+}
+
+// CitationProblems lists why citations break the constraints. A citation is
+// invalid when it supports no span or its URL is outside the allowed domains
+// or forbidden, every URL the validator removed counts as an invalid citation
+func CitationProblems(result citation.Result, constraints SourceConstraints) (valid int, total int, problems []string) {
+	for _, c := range result.Citations {
+		total++
+		switch {
+		case len(c.Spans) == 0 || strings.TrimSpace(c.Spans[0].Text) == "":
+			problems = append(problems, "citation ["+strconv.Itoa(c.Index)+"] supports no text")
+		case c.URL != "" && len(constraints.Domains) > 0 && !allowedHost(host(c.URL), constraints.Domains):
+			problems = append(problems, "citation ["+strconv.Itoa(c.Index)+"] is outside the allowed domains: "+c.URL)
+		case c.URL != "" && forbidden(c.URL, constraints.Forbidden):
+			problems = append(problems, "citation ["+strconv.Itoa(c.Index)+"] is forbidden: "+c.URL)
+		default:
+			valid++
+		}
+	}
+	for _, u := range result.RejectedURLs {
+		total++
+		problems = append(problems, "answer linked a URL that is in no source: "+u)
+	}
+	return valid, total, problems
+}
+
+func allowedHost(h string, domains []string) bool {
+	for _, domain := range domains {
+		domain = strings.TrimPrefix(strings.ToLower(domain), "www.")
+		if h == domain || strings.HasSuffix(h, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+func forbidden(u string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if pattern != "" && strings.Contains(u, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// TickerRecall is the share of expected tickers some cited or retrieved
+// source is about
+func TickerRecall(tickers []string, result citation.Result, sources []citation.Source) float64 {
+	if len(tickers) == 0 {
+		return 0
+	}
+	seen := make(map[string]bool)
+	for _, source := range sources {
+		seen[strings.ToUpper(source.Ticker)] = true
+	}
+	for _, c := range result.Citations {
+		seen[strings.ToUpper(c.Ticker)] = true
+	}
+	found := 0
+	for _, ticker := range tickers {
+		if seen[strings.ToUpper(ticker)] {
+			found++
+		}
+	}
+	return float64(found) / float64(len(tickers))
+}
+
+// percentile of sorted values, p in [0, 1]
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if i < 0 {
+		i = 0
+	}
+	return sorted[i]
+}
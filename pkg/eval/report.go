@@ -0,0 +1,196 @@
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Save writes the report as indented JSON
+func (r *Report) Save(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// LoadReport reads a report written by Save
+func LoadReport(path string) (*Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var report Report
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	return &report, nil
+}
+
+// metric is a summary figure compared between runs
+type metric struct {
+	name  string
+	value func(Summary) float64
+	// latency metrics regress when they grow, the others when they drop
+	latency bool
+}
+
+var metrics = []metric{
+	{name: "recall@k", value: func(s Summary) float64 { return s.RecallAtK }},
+	{name: "citation validity", value: func(s Summary) float64 { return s.CitationValidity }},
+	{name: "fact accuracy", value: func(s Summary) float64 { return s.FactAccuracy }},
+	{name: "ticker recall", value: func(s Summary) float64 { return s.TickerRecall }},
+	{name: "pass rate", value: func(s Summary) float64 {
+		if s.Cases == 0 {
+			return 0
+		}
+		return float64(s.Passed) / float64(s.Cases)
+	}},
+	{name: "latency p50 ms", value: func(s Summary) float64 { return s.LatencyP50Ms }, latency: true},
+	{name: "latency p95 ms", value: func(s Summary) float64 { return s.LatencyP95Ms }, latency: true},
+}
+
+func formatMetric(m metric, v float64) string {
+	if m.latency {
+		return fmt.Sprintf("%.0f", v)
+	}
+	return fmt.Sprintf("%.3f", v)
+}
+
+// WriteMarkdown writes the summary and a row per case
+func (r *Report) WriteMarkdown(w io.Writer) {
+	fmt.Fprintf(w, "# Eval %s (%s, k=%d)\n\n", r.Suite, r.Mode, r.K)
+	fmt.Fprintf(w, "%d of %d cases passed, %d errors\n\n", r.Summary.Passed, r.Summary.Cases, r.Summary.Errors)
+	fmt.Fprintln(w, "| metric | value |")
+	fmt.Fprintln(w, "| --- | --- |")
+	for _, m := range metrics {
+		fmt.Fprintf(w, "| %s | %s |\n", m.name, formatMetric(m, m.value(r.Summary)))
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "| case | passed | recall@k | citations valid | facts | latency ms |")
+	fmt.Fprintln(w, "| --- | --- | --- | --- | --- | --- |")
+	for _, c := range r.Cases {
+		fmt.Fprintf(w, "| %s | %t | %s | %s | %s | %d |\n", c.ID, c.Passed(), optional(c.RecallAtK), optional(c.CitationValidity), optional(c.FactAccuracy), c.LatencyMs)
+	}
+	for _, c := range r.Cases {
+		if c.Passed() {
+			continue
+		}
+		fmt.Fprintf(w, "\n## %s\n\n", c.ID)
+		if c.Error != "" {
+			fmt.Fprintf(w, "- error: %s\n", c.Error)
+		}
+		for _, failure := range c.Failures {
+			fmt.Fprintf(w, "- %s\n", failure)
+		}
+	}
+}
+
+func optional(v *float64) string {
+	if v == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%.2f", *v)
+}
+
+// Delta is the change of a summary metric against the baseline
+type Delta struct {
+	Metric    string
+	Baseline  string
+	Current   string
+	Change    float64
+	Regressed bool
+}
+
+// Diff compares a run with a baseline run of the same suite
+type Diff struct {
+	Deltas []Delta
+	// NewlyFailing and NewlyPassing are case ids whose outcome changed
+	NewlyFailing []string
+	NewlyPassing []string
+	// Added and Removed are case ids only in the current or baseline run
+	Added   []string
+	Removed []string
+}
+
+// Compare diffs current against baseline. Quality metrics regress on any
+// drop, latency when it grows by more than 25% and 50ms
+func Compare(baseline *Report, current *Report) Diff {
+	var diff Diff
+	for _, m := range metrics {
+		before, after := m.value(baseline.Summary), m.value(current.Summary)
+		delta := Delta{Metric: m.name, Baseline: formatMetric(m, before), Current: formatMetric(m, after), Change: after - before}
+		if m.latency {
+			delta.Regressed = after > before*1.25 && after-before > 50
+		} else {
+			delta.Regressed = after < before-1e-9
+		}
+		diff.Deltas = append(diff.Deltas, delta)
+	}
+
+	previous := make(map[string]CaseResult, len(baseline.Cases))
+	for _, c := range baseline.Cases {
+		previous[c.ID] = c
+	}
+	for _, c := range current.Cases {
+		before, ok := previous[c.ID]
+		if !ok {
+			diff.Added = append(diff.Added, c.ID)
+			continue
+		}
+		delete(previous, c.ID)
+		switch {
+		case before.Passed() && !c.Passed():
+			diff.NewlyFailing = append(diff.NewlyFailing, c.ID)
+		case !before.Passed() && c.Passed():
+			diff.NewlyPassing = append(diff.NewlyPassing, c.ID)
+		}
+	}
+	for _, c := range baseline.Cases {
+		if _, ok := previous[c.ID]; ok {
+			diff.Removed = append(diff.Removed, c.ID)
+		}
+	}
+	return diff
+}
+
+// Regressed reports whether a metric regressed or a case started failing
+func (d Diff) Regressed() bool {
+	if len(d.NewlyFailing) > 0 {
+		return true
+	}
+	for _, delta := range d.Deltas {
+		if delta.Regressed {
+			return true
+		}
+	}
+	return false
+}
+
+// Write renders the diff as markdown
+func (d Diff) Write(w io.Writer) {
+	fmt.Fprintln(w, "# Diff against the baseline")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "| metric | baseline | current | change |")
+	fmt.Fprintln(w, "| --- | --- | --- | --- |")
+	for _, delta := range d.Deltas {
+		change := fmt.Sprintf("%+.3f", delta.Change)
+		if delta.Regressed {
+			change += " REGRESSED"
+		}
+		fmt.Fprintf(w, "| %s | %s | %s | %s |\n", delta.Metric, delta.Baseline, delta.Current, change)
+	}
+	for _, list := range []struct {
+		label string
+		ids   []string
+	}{
+		{"newly failing", d.NewlyFailing}, {"newly passing", d.NewlyPassing}, {"added", d.Added}, {"removed", d.Removed},
+	} {
+		if len(list.ids) > 0 {
+			fmt.Fprintf(w, "\n%s: %s\n", list.label, strings.Join(list.ids, ", "))
+		}
+	}
+}
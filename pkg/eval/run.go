@@ -0,0 +1,194 @@
+package eval
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"fineas/pkg/citation"
+)
+
+// Answer is the part of a /chat response the metrics read
+type Answer struct {
+	citation.Result
+	// Sources are all sources given to the model, in prompt order
+	Sources []citation.Source `json:"sources"`
+}
+
+// Asker asks the chat pipeline a question
+type Asker interface {
+	Ask(ctx context.Context, question string) (Answer, error)
+}
+
+// AskerFunc adapts a function to an Asker
+type AskerFunc func(ctx context.Context, question string) (Answer, error)
+
+func (f AskerFunc) Ask(ctx context.Context, question string) (Answer, error) {
+	return f(ctx, question)
+}
+
+// CaseResult are the metrics of one case, a metric is nil when the case
+// does not define what it needs
+type CaseResult struct {
+	ID               string   `json:"id"`
+	Question         string   `json:"question"`
+	Answer           string   `json:"answer,omitempty"`
+	RecallAtK        *float64 `json:"recall_at_k,omitempty"`
+	CitationValidity *float64 `json:"citation_validity,omitempty"`
+	FactAccuracy     *float64 `json:"fact_accuracy,omitempty"`
+	TickerRecall     *float64 `json:"ticker_recall,omitempty"`
+	Citations        int      `json:"citations"`
+	LatencyMs        int64    `json:"latency_ms"`
+	// Failures are the expectations the answer missed
+	Failures []string `json:"failures,omitempty"`
+	Error    string   `json:"error,omitempty"`
+}
+
+// Passed reports whether the case met every expectation
+func (c CaseResult) Passed() bool {
+	return c.Error == "" && len(c.Failures) == 0
+}
+
+// Summary averages the case metrics over the cases that define them
+type Summary struct {
+	Cases            int     `json:"cases"`
+	Passed           int     `json:"passed"`
+	Errors           int     `json:"errors"`
+	RecallAtK        float64 `json:"recall_at_k"`
+	CitationValidity float64 `json:"citation_validity"`
+	FactAccuracy     float64 `json:"fact_accuracy"`
+	TickerRecall     float64 `json:"ticker_recall"`
+	LatencyMeanMs    float64 `json:"latency_mean_ms"`
+	LatencyP50Ms     float64 `json:"latency_p50_ms"`
+	LatencyP95Ms     float64 `json:"latency_p95_ms"`
+}
+
+// Report is the outcome of a suite run
+type Report struct {
+	Suite     string       `json:"suite"`
+	K         int          `json:"k"`
+	Mode      string       `json:"mode"`
+	CreatedAt time.Time    `json:"created_at"`
+	Summary   Summary      `json:"summary"`
+	Cases     []CaseResult `json:"cases"`
+}
+
+func ratio(n int, d int) *float64 {
+	r := float64(n) / float64(d)
+	return &r
+}
+
+// Evaluate scores an answer against its case
+func Evaluate(c Case, answer Answer, k int) CaseResult {
+	result := CaseResult{ID: c.ID, Question: c.Question, Answer: answer.Answer, Citations: len(answer.Citations)}
+
+	if len(c.Relevant) > 0 {
+		recall := RecallAtK(c.Relevant, answer.Sources, k)
+		result.RecallAtK = &recall
+		retrieved := Retrieved(answer.Sources, k)
+		for _, passage := range c.Relevant {
+			if RecallAtK([]string{passage}, retrieved, k) == 0 {
+				result.Failures = append(result.Failures, fmt.Sprintf("relevant passage not in the top %d: %q", k, passage))
+			}
+		}
+	}
+
+	valid, total, problems := CitationProblems(answer.Result, c.Sources)
+	if total > 0 {
+		result.CitationValidity = ratio(valid, total)
+	}
+	result.Failures = append(result.Failures, problems...)
+	if len(answer.Citations) < c.Sources.MinCitations {
+		result.Failures = append(result.Failures, fmt.Sprintf("%d citations, expected at least %d", len(answer.Citations), c.Sources.MinCitations))
+	}
+
+	if len(c.Facts) > 0 {
+		numbers := Numbers(answer.Answer)
+		correct := 0
+		for _, fact := range c.Facts {
+			if fact.Matches(numbers) {
+				correct++
+				continue
+			}
+			result.Failures = append(result.Failures, fmt.Sprintf("fact %s = %g not in the answer", fact.Name, fact.Value))
+		}
+		result.FactAccuracy = ratio(correct, len(c.Facts))
+	}
+
+	if len(c.Tickers) > 0 {
+		recall := TickerRecall(c.Tickers, answer.Result, answer.Sources)
+		result.TickerRecall = &recall
+		if recall < 1 {
+			result.Failures = append(result.Failures, "sources miss some of "+strings.Join(c.Tickers, ", "))
+		}
+	}
+	return result
+}
+
+// Run asks every case of the suite in order and scores the answers
+func Run(ctx context.Context, suite *Suite, asker Asker, mode string) *Report {
+	report := &Report{Suite: suite.Name, K: suite.K, Mode: mode, CreatedAt: time.Now().UTC()}
+	for _, c := range suite.Cases {
+		start := time.Now()
+		answer, err := asker.Ask(ctx, c.Question)
+		latency := time.Since(start).Milliseconds()
+		var result CaseResult
+		if err != nil {
+			result = CaseResult{ID: c.ID, Question: c.Question, Error: err.Error()}
+		} else {
+			result = Evaluate(c, answer, suite.K)
+		}
+		result.LatencyMs = latency
+		report.Cases = append(report.Cases, result)
+	}
+	report.Summary = summarize(report.Cases)
+	return report
+}
+
+// mean of the defined values
+func mean(values []*float64) float64 {
+	sum, n := 0.0, 0
+	for _, v := range values {
+		if v != nil {
+			sum += *v
+			n++
+		}
+	}
+	if n == 0 {
+		return 0
+	}
+	return sum / float64(n)
+}
+
+func summarize(cases []CaseResult) Summary {
+	summary := Summary{Cases: len(cases)}
+	var recall, validity, facts, tickers []*float64
+	latencies := make([]float64, 0, len(cases))
+	for _, c := range cases {
+		if c.Passed() {
+			summary.Passed++
+		}
+		if c.Error != "" {
+			summary.Errors++
+		}
+		recall = append(recall, c.RecallAtK)
+		validity = append(validity, c.CitationValidity)
+		facts = append(facts, c.FactAccuracy)
+		tickers = append(tickers, c.TickerRecall)
+		latencies = append(latencies, float64(c.LatencyMs))
+		summary.LatencyMeanMs += float64(c.LatencyMs)
+	}
+	summary.RecallAtK = mean(recall)
+	summary.CitationValidity = mean(validity)
+	summary.FactAccuracy = mean(facts)
+	summary.TickerRecall = mean(tickers)
+	if len(cases) > 0 {
+		summary.LatencyMeanMs /= float64(len(cases))
+	}
+	sort.Float64s(latencies)
+	summary.LatencyP50Ms = percentile(latencies, 0.5)
+	summary.LatencyP95Ms = percentile(latencies, 0.95)
+	return summary
+}
@@ -0,0 +1,127 @@
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"fineas/pkg/services"
+)
+
+var (
+	sourceHeader = regexp.MustCompile(`(?m)^\s*\[(\d+)\][^\n]*\n`)
+	sentenceEnd  = regexp.MustCompile(`[.!?](?:\s+|$)`)
+	word         = regexp.MustCompile(`[a-z0-9$%.]+`)
+)
+
+// StubLLM is a deterministic /llm service for evals. A chat prompt is
+// answered with the sentences of the sources that best match the question,
+// cited with their source number. Other prompts such as follow-up rewrites
+// and summaries get an empty answer, which the pipeline treats as no change
+func StubLLM() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req services.LLMRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprint(w, stubAnswer(req.Prompt))
+	})
+}
+
+// StubSearch is a /search service without results
+func StubSearch() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(services.SearchResponse{Results: []services.SearchResult{}, Page: 1, Provider: "stub"})
+	})
+}
+
+// section returns the prompt text between a header and the next one
+func section(prompt string, header string, next ...string) string {
+	start := strings.Index(prompt, header)
+	if start < 0 {
+		return ""
+	}
+	text := prompt[start+len(header):]
+	for _, n := range next {
+		if end := strings.Index(text, n); end >= 0 {
+			text = text[:end]
+		}
+	}
+	return text
+}
+
+func terms(text string) map[string]bool {
+	set := make(map[string]bool)
+	for _, t := range word.FindAllString(strings.ToLower(text), -1) {
+		t = strings.Trim(t, ".")
+		if len(t) > 2 {
+			set[t] = true
+		}
+	}
+	return set
+}
+
+type stubSentence struct {
+	index int
+	text  string
+	score int
+}
+
+func stubAnswer(prompt string) string {
+	if !strings.Contains(prompt, "SOURCES:") {
+		return ""
+	}
+	question := terms(section(prompt, "PROMPT:", "SOURCES:"))
+	sources := section(prompt, "SOURCES:", "\nTOOLS:", "\nTOOL RESULTS:")
+
+	var best []stubSentence
+	headers := sourceHeader.FindAllStringSubmatchIndex(sources, -1)
+	for i, loc := range headers {
+		end := len(sources)
+		if i+1 < len(headers) {
+			end = headers[i+1][0]
+		}
+		var index int
+		fmt.Sscanf(sources[loc[2]:loc[3]], "%d", &index)
+		var picks []stubSentence
+		for _, sentence := range sentenceEnd.Split(strings.TrimSpace(sources[loc[1]:end]), -1) {
+			sentence = strings.Join(strings.Fields(sentence), " ")
+			if sentence == "" {
+				continue
+			}
+			pick := stubSentence{index: index, text: sentence}
+			for t := range terms(sentence) {
+				if question[t] {
+					pick.score++
+				}
+			}
+			if pick.score > 0 {
+				picks = append(picks, pick)
+			}
+		}
+		// the two best sentences of every source
+		sort.SliceStable(picks, func(i, j int) bool { return picks[i].score > picks[j].score })
+		if len(picks) > 2 {
+			picks = picks[:2]
+		}
+		best = append(best, picks...)
+	}
+	if len(best) == 0 {
+		return "The sources do not answer the question."
+	}
+	sort.SliceStable(best, func(i, j int) bool { return best[i].score > best[j].score })
+	if len(best) > 6 {
+		best = best[:6]
+	}
+	lines := make([]string, 0, len(best))
+	for _, s := range best {
+		lines = append(lines, fmt.Sprintf("%s [%d].", strings.TrimRight(s.text, ".!?"), s.index))
+	}
+	return strings.Join(lines, "\n")
+}
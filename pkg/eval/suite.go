@@ -0,0 +1,96 @@
+package eval
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Suite is a set of questions asked to the chat pipeline, with the documents
+// it should retrieve them from
+type Suite struct {
+	Name string `yaml:"name"`
+	// K is the retrieval depth recall is measured at, 5 by default
+	K int `yaml:"k"`
+	// Tickers are written to the tracked tickers list so prompts are scoped
+	Tickers []Ticker `yaml:"tickers"`
+	// Documents are ingested into an empty vector store before the cases run
+	Documents []Document `yaml:"documents"`
+	Cases     []Case     `yaml:"cases"`
+}
+
+// Ticker is a tracked ticker and its company name
+type Ticker struct {
+	Value string `yaml:"value"`
+	Label string `yaml:"label"`
+}
+
+// Document is a report section ingested for the suite, dated today unless
+// Date (YYYY-MM-DD) is set
+type Document struct {
+	Ticker  string `yaml:"ticker"`
+	Section string `yaml:"section"`
+	Text    string `yaml:"text"`
+	URL     string `yaml:"url"`
+	Date    string `yaml:"date"`
+}
+
+// Case is one question and what a good answer looks like
+type Case struct {
+	ID       string `yaml:"id"`
+	Question string `yaml:"question"`
+	// Tickers are the companies the sources should be about
+	Tickers []string `yaml:"tickers"`
+	// Relevant are passages, a retrieved chunk containing one of them is
+	// relevant
+	Relevant []string `yaml:"relevant"`
+	// Facts are the figures the answer should state
+	Facts   []Fact            `yaml:"facts"`
+	Sources SourceConstraints `yaml:"sources"`
+}
+
+// Fact is a figure expected in the answer. Tolerance is relative, 1% by
+// default
+type Fact struct {
+	Name      string  `yaml:"name"`
+	Value     float64 `yaml:"value"`
+	Tolerance float64 `yaml:"tolerance"`
+}
+
+// SourceConstraints restrict what the answer cites
+type SourceConstraints struct {
+	MinCitations int `yaml:"min_citations"`
+	// Domains, when set, are the only hosts cited URLs may be on
+	Domains []string `yaml:"domains"`
+	// Forbidden are URL substrings that must never be cited
+	Forbidden []string `yaml:"forbidden"`
+}
+
+// LoadSuite reads a YAML suite
+func LoadSuite(path string) (*Suite, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var suite Suite
+	if err := yaml.Unmarshal(data, &suite); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	if len(suite.Cases) == 0 {
+		return nil, errors.New("suite has no cases")
+	}
+	if suite.K <= 0 {
+		suite.K = 5
+	}
+	for i := range suite.Cases {
+		if suite.Cases[i].ID == "" {
+			suite.Cases[i].ID = fmt.Sprintf("case-%d", i+1)
+		}
+		if suite.Cases[i].Question == "" {
+			return nil, fmt.Errorf("case %s has no question", suite.Cases[i].ID)
+		}
+	}
+	return &suite, nil
+}
@@ -0,0 +1,84 @@
+package extract
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Cache stores extracted articles by URL
+type Cache interface {
+	Get(key string) (*Result, bool)
+	Put(key string, result *Result)
+}
+
+// MemoryCache keeps results in memory until they are older than TTL
+type MemoryCache struct {
+	TTL     time.Duration
+	mu      sync.Mutex
+	entries map[string]*Result
+}
+
+func NewMemoryCache(ttl time.Duration) *MemoryCache {
+	return &MemoryCache{TTL: ttl, entries: make(map[string]*Result)}
+}
+
+func (c *MemoryCache) Get(key string) (*Result, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	result, ok := c.entries[key]
+	if !ok || (c.TTL > 0 && time.Since(result.ExtractedAt) > c.TTL) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return result, true
+}
+
+func (c *MemoryCache) Put(key string, result *Result) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = result
+}
+
+// DirCache stores each result as a JSON file named by the hash of its key, so
+// the cache survives restarts
+type DirCache struct {
+	Dir string
+	TTL time.Duration
+}
+
+func (c DirCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.Dir, hex.EncodeToString(sum[:])+".json")
+}
+
+func (c DirCache) Get(key string) (*Result, bool) {
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+	var result Result
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, false
+	}
+	if c.TTL > 0 && time.Since(result.ExtractedAt) > c.TTL {
+		return nil, false
+	}
+	return &result, true
+}
+
+// Put is best effort, a failed write only costs a later refetch
+func (c DirCache) Put(key string, result *Result) {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(c.Dir, 0o755); err != nil {
+		return
+	}
+	os.WriteFile(c.path(key), data, 0o644)
+}
@@ -0,0 +1,217 @@
+package extract
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// ErrDisallowed is returned when robots.txt does not allow fetching a URL
+var ErrDisallowed = errors.New("fetching is disallowed by robots.txt")
+
+// Result is the main text of an article page
+type Result struct {
+	URL         string    `json:"url" bson:"url"`
+	FinalURL    string    `json:"final_url" bson:"final_url"`
+	Title       string    `json:"title" bson:"title"`
+	Text        string    `json:"text" bson:"text"`
+	ExtractedAt time.Time `json:"extracted_at" bson:"extracted_at"`
+}
+
+// Extractor fetches pages and extracts their article text. It follows
+// redirects, checks robots.txt for every host it visits, waits MinInterval
+// between requests to the same host and caches results by URL
+type Extractor struct {
+	Client      *http.Client
+	UserAgent   string
+	MinInterval time.Duration
+	MaxBytes    int64
+	Cache       Cache
+
+	mu       sync.Mutex
+	robots   map[string]robotsRules
+	lastSeen map[string]time.Time
+}
+
+func New(userAgent string, minInterval time.Duration, cache Cache) *Extractor {
+	e := &Extractor{
+		UserAgent:   userAgent,
+		MinInterval: minInterval,
+		MaxBytes:    5 << 20,
+		Cache:       cache,
+		robots:      make(map[string]robotsRules),
+		lastSeen:    make(map[string]time.Time),
+	}
+	e.Client = &http.Client{
+		Timeout: 20 * time.Second,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 10 {
+				return errors.New("stopped after 10 redirects")
+			}
+			if !e.allowed(req.Context(), req.URL) {
+				return ErrDisallowed
+			}
+			e.wait(req.Context(), req.URL.Host)
+			req.Header.Set("User-Agent", e.UserAgent)
+			return nil
+		},
+	}
+	return e
+}
+
+// Extract returns the article text at rawURL, from the cache when possible
+func (e *Extractor) Extract(ctx context.Context, rawURL string) (*Result, error) {
+	if e.Cache != nil {
+		if result, ok := e.Cache.Get(rawURL); ok {
+			return result, nil
+		}
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, fmt.Errorf("not an http url: %s", rawURL)
+	}
+	if !e.allowed(ctx, u) {
+		return nil, ErrDisallowed
+	}
+	e.wait(ctx, u.Host)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", e.UserAgent)
+	resp, err := e.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status code error: %d %s", resp.StatusCode, resp.Status)
+	}
+	if contentType := resp.Header.Get("Content-Type"); contentType != "" && !strings.Contains(contentType, "html") {
+		return nil, fmt.Errorf("unsupported content type %s", contentType)
+	}
+
+	result, err := Parse(io.LimitReader(resp.Body, e.MaxBytes))
+	if err != nil {
+		return nil, err
+	}
+	result.URL = rawURL
+	result.FinalURL = resp.Request.URL.String()
+	result.ExtractedAt = time.Now()
+
+	if e.Cache != nil {
+		e.Cache.Put(rawURL, result)
+	}
+	return result, nil
+}
+
+// allowed fetches and caches the host's robots.txt. A missing or unreadable
+// robots.txt allows everything
+func (e *Extractor) allowed(ctx context.Context, u *url.URL) bool {
+	origin := u.Scheme + "://" + u.Host
+
+	e.mu.Lock()
+	rules, ok := e.robots[origin]
+	e.mu.Unlock()
+
+	if !ok {
+		rules = robotsRules{}
+		req, err := http.NewRequestWithContext(ctx, "GET", origin+"/robots.txt", nil)
+		if err == nil {
+			req.Header.Set("User-Agent", e.UserAgent)
+			client := &http.Client{Timeout: 10 * time.Second}
+			if resp, err := client.Do(req); err == nil {
+				if resp.StatusCode == http.StatusOK {
+					rules = parseRobots(io.LimitReader(resp.Body, 512<<10), e.UserAgent)
+				}
+				resp.Body.Close()
+			}
+		}
+		e.mu.Lock()
+		e.robots[origin] = rules
+		e.mu.Unlock()
+	}
+
+	path := u.EscapedPath()
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	return rules.allowed(path)
+}
+
+// wait blocks until MinInterval has passed since the last request to host
+func (e *Extractor) wait(ctx context.Context, host string) {
+	e.mu.Lock()
+	next := e.lastSeen[host].Add(e.MinInterval)
+	now := time.Now()
+	if next.Before(now) {
+		next = now
+	}
+	e.lastSeen[host] = next
+	e.mu.Unlock()
+
+	select {
+	case <-time.After(time.Until(next)):
+	case <-ctx.Done():
+	}
+}
+
+// ExtractAll extracts up to workers URLs at a time, failed URLs are left out
+// of the result map
+func (e *Extractor) ExtractAll(ctx context.Context, urls []string, workers int) (map[string]*Result, map[string]error) {
+	if workers < 1 {
+		workers = 1
+	}
+	results := make(map[string]*Result)
+	failures := make(map[string]error)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	queue := make(chan string)
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for u := range queue {
+				result, err := e.Extract(ctx, u)
+				mu.Lock()
+				if err != nil {
+					failures[u] = err
+				} else {
+					results[u] = result
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, u := range urls {
+		queue <- u
+	}
+	close(queue)
+	wg.Wait()
+	return results, failures
+}
+
+// Parse extracts the title and main text from an HTML document
+func Parse(r io.Reader) (*Result, error) {
+	doc, err := goquery.NewDocumentFromReader(r)
+	if err != nil {
+		return nil, err
+	}
+	title := strings.TrimSpace(doc.Find("meta[property='og:title']").AttrOr("content", ""))
+	if title == "" {
+		title = strings.TrimSpace(doc.Find("title").First().Text())
+	}
+	return &Result{Title: title, Text: mainText(doc)}, nil
+}
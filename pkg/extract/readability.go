@@ -0,0 +1,115 @@
+package extract
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+// elements that never hold article text
+const boilerplateTags = "script, style, noscript, iframe, form, nav, header, footer, aside, svg, button, figure"
+
+// class and id fragments of navigation, sharing, ads and other chrome
+var boilerplatePattern = regexp.MustCompile(`(?i)comment|footer|sidebar|\bnav|menu|share|social|promo|subscribe|newsletter|cookie|consent|related|recommend|advert|\bads?\b|sponsor|popup|modal|breadcrumb|byline|caption`)
+
+// paragraph fragments that are boilerplate even inside the article body
+var boilerplateLines = regexp.MustCompile(`(?i)^(advertisement|read more|sign up|subscribe|click here|follow us|share this|all rights reserved|©)`)
+
+// mainText scores the containers of paragraphs the way readability does: each
+// paragraph adds to its parent, and half as much to its grandparent, by its
+// length and number of commas. The best container, discounted by its link
+// density, is taken as the article body
+func mainText(doc *goquery.Document) string {
+	doc.Find(boilerplateTags).Remove()
+	doc.Find("[class], [id]").Each(func(i int, s *goquery.Selection) {
+		if s.Is("html, body, article, main") {
+			return
+		}
+		if boilerplatePattern.MatchString(s.AttrOr("class", "") + " " + s.AttrOr("id", "")) {
+			s.Remove()
+		}
+	})
+
+	type candidate struct {
+		selection *goquery.Selection
+		score     float64
+	}
+	var candidates []*candidate
+	nodes := make(map[*html.Node]*candidate)
+
+	add := func(s *goquery.Selection, score float64) {
+		if s.Length() == 0 {
+			return
+		}
+		node := s.Get(0)
+		c, ok := nodes[node]
+		if !ok {
+			c = &candidate{selection: s}
+			if s.Is("article, main") {
+				c.score += 10
+			}
+			nodes[node] = c
+			candidates = append(candidates, c)
+		}
+		c.score += score
+	}
+
+	doc.Find("p").Each(func(i int, p *goquery.Selection) {
+		text := strings.TrimSpace(p.Text())
+		if len(text) < 25 {
+			return
+		}
+		score := 1 + float64(strings.Count(text, ","))
+		if bonus := float64(len(text)) / 100; bonus < 3 {
+			score += bonus
+		} else {
+			score += 3
+		}
+		add(p.Parent(), score)
+		add(p.Parent().Parent(), score/2)
+	})
+
+	var best *candidate
+	for _, c := range candidates {
+		c.score *= 1 - linkDensity(c.selection)
+		if best == nil || c.score > best.score {
+			best = c
+		}
+	}
+	if best == nil {
+		return paragraphs(doc.Find("body"))
+	}
+	return paragraphs(best.selection)
+}
+
+// linkDensity is the share of a selection's text that sits inside links
+func linkDensity(s *goquery.Selection) float64 {
+	total := len(strings.TrimSpace(s.Text()))
+	if total == 0 {
+		return 1
+	}
+	linked := 0
+	s.Find("a").Each(func(i int, a *goquery.Selection) {
+		linked += len(strings.TrimSpace(a.Text()))
+	})
+	return float64(linked) / float64(total)
+}
+
+// paragraphs joins the paragraph, heading and list text of a selection,
+// dropping short fragments and boilerplate lines
+func paragraphs(s *goquery.Selection) string {
+	var blocks []string
+	s.Find("p, h2, h3, li, blockquote").Each(func(i int, block *goquery.Selection) {
+		text := strings.Join(strings.Fields(block.Text()), " ")
+		if len(text) < 25 && !block.Is("h2, h3") {
+			return
+		}
+		if text == "" || boilerplateLines.MatchString(text) {
+			return
+		}
+		blocks = append(blocks, text)
+	})
+	return strings.Join(blocks, "\n\n")
+}
@@ -0,0 +1,118 @@
+package extract
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// robotsRules are the Allow and Disallow path prefixes that apply to us
+type robotsRules struct {
+	allow    []string
+	disallow []string
+}
+
+// parseRobots reads a robots.txt and keeps the group for the most specific
+// matching user agent, falling back to the * group
+func parseRobots(r io.Reader, userAgent string) robotsRules {
+	agent := strings.ToLower(userAgent)
+	if i := strings.IndexAny(agent, "/ "); i > 0 {
+		agent = agent[:i]
+	}
+
+	groups := make(map[string]*robotsRules)
+	var current []string
+	inRules := false
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			// consecutive user-agent lines share one group
+			if inRules {
+				current = nil
+				inRules = false
+			}
+			name := strings.ToLower(value)
+			current = append(current, name)
+			if groups[name] == nil {
+				groups[name] = &robotsRules{}
+			}
+		case "allow", "disallow":
+			inRules = true
+			for _, name := range current {
+				if key == "allow" {
+					groups[name].allow = append(groups[name].allow, value)
+				} else if value != "" {
+					groups[name].disallow = append(groups[name].disallow, value)
+				}
+			}
+		}
+	}
+
+	if agent != "" {
+		if rules, ok := groups[agent]; ok {
+			return *rules
+		}
+	}
+	if rules, ok := groups["*"]; ok {
+		return *rules
+	}
+	return robotsRules{}
+}
+
+// allowed applies the longest matching rule, Allow wins ties
+func (r robotsRules) allowed(path string) bool {
+	if path == "" {
+		path = "/"
+	}
+	longestAllow, longestDisallow := -1, -1
+	for _, prefix := range r.allow {
+		if matchRobots(prefix, path) && len(prefix) > longestAllow {
+			longestAllow = len(prefix)
+		}
+	}
+	for _, prefix := range r.disallow {
+		if matchRobots(prefix, path) && len(prefix) > longestDisallow {
+			longestDisallow = len(prefix)
+		}
+	}
+	return longestDisallow < 0 || longestAllow >= longestDisallow
+}
+
+// matchRobots supports the * wildcard and $ end anchor
+func matchRobots(pattern string, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+	parts := strings.Split(pattern, "*")
+
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	rest := path[len(parts[0]):]
+	for _, part := range parts[1:] {
+		i := strings.Index(rest, part)
+		if i < 0 {
+			return false
+		}
+		rest = rest[i+len(part):]
+	}
+	if !anchored {
+		return true
+	}
+	if len(parts) == 1 {
+		return rest == ""
+	}
+	return strings.HasSuffix(path, parts[len(parts)-1])
+}
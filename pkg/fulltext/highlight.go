@@ -0,0 +1,107 @@
+package fulltext
+
+import (
+	"sort"
+	"strings"
+)
+
+// Highlighter marks query terms in fragments of a document
+type Highlighter struct {
+	Pre          string
+	Post         string
+	FragmentSize int
+	MaxFragments int
+}
+
+// DefaultHighlighter wraps matches in <em> tags, up to three 160 byte fragments
+var DefaultHighlighter = Highlighter{Pre: "<em>", Post: "</em>", FragmentSize: 160, MaxFragments: 3}
+
+type span struct {
+	start int
+	end   int
+	hits  int
+}
+
+// Highlight returns the fragments of text with the most query term matches,
+// in document order
+func (h Highlighter) Highlight(text string, query string) []string {
+	terms := make(map[string]bool)
+	for _, term := range Terms(query) {
+		terms[term] = true
+	}
+	if len(terms) == 0 {
+		return nil
+	}
+	var matches []Token
+	for _, token := range Tokenize(text) {
+		if terms[token.Term] {
+			matches = append(matches, token)
+		}
+	}
+	if len(matches) == 0 {
+		return nil
+	}
+
+	// one fragment per cluster of matches that fits in FragmentSize
+	var spans []span
+	for _, m := range matches {
+		if n := len(spans); n > 0 && m.End-spans[n-1].start <= h.FragmentSize {
+			spans[n-1].end = m.End
+			spans[n-1].hits++
+			continue
+		}
+		spans = append(spans, span{start: m.Start, end: m.End, hits: 1})
+	}
+	sort.SliceStable(spans, func(i, j int) bool { return spans[i].hits > spans[j].hits })
+	if h.MaxFragments > 0 && len(spans) > h.MaxFragments {
+		spans = spans[:h.MaxFragments]
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+
+	var fragments []string
+	for _, s := range spans {
+		start, end := h.widen(text, s)
+		var b strings.Builder
+		if start > 0 {
+			b.WriteString("…")
+		}
+		last := start
+		for _, m := range matches {
+			if m.Start < start || m.End > end {
+				continue
+			}
+			b.WriteString(text[last:m.Start])
+			b.WriteString(h.Pre)
+			b.WriteString(text[m.Start:m.End])
+			b.WriteString(h.Post)
+			last = m.End
+		}
+		b.WriteString(text[last:end])
+		if end < len(text) {
+			b.WriteString("…")
+		}
+		fragments = append(fragments, strings.Join(strings.Fields(b.String()), " "))
+	}
+	return fragments
+}
+
+// widen pads a span with context up to FragmentSize, ending on word boundaries
+func (h Highlighter) widen(text string, s span) (int, int) {
+	pad := (h.FragmentSize - (s.end - s.start)) / 2
+	if pad < 0 {
+		pad = 0
+	}
+	start := s.start - pad
+	if start <= 0 {
+		start = 0
+	} else if i := strings.IndexByte(text[start:s.start], ' '); i >= 0 {
+		start += i + 1
+	}
+	end := s.end + pad
+	if end >= len(text) {
+		end = len(text)
+	} else if i := strings.LastIndexByte(text[s.end:end], ' '); i >= 0 {
+		end = s.end + i
+	}
+	return start, end
+}
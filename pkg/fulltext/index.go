@@ -0,0 +1,254 @@
+package fulltext
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Sources of indexed documents
+const (
+	SourceReport = "report"
+	SourceRaw    = "raw"
+	SourceNews   = "news"
+)
+
+// Document is a unit of indexed text: a report section, a raw service output
+// or a news article
+type Document struct {
+	ID      string    `json:"id"`
+	Ticker  string    `json:"ticker,omitempty"`
+	Section string    `json:"section,omitempty"`
+	Source  string    `json:"source"`
+	Title   string    `json:"title,omitempty"`
+	Text    string    `json:"text"`
+	URL     string    `json:"url,omitempty"`
+	Date    time.Time `json:"date,omitempty"`
+}
+
+// Query is a keyword search with optional filters. Empty filters match everything
+type Query struct {
+	Text     string
+	Tickers  []string
+	Sections []string
+	Sources  []string
+	From     time.Time
+	To       time.Time
+	Limit    int
+	Offset   int
+}
+
+// Hit is a matching document with its BM25 score and highlighted fragments
+type Hit struct {
+	Document
+	Score      float64  `json:"score"`
+	Highlights []string `json:"highlights,omitempty"`
+}
+
+// Results is a page of hits and the number of matching documents
+type Results struct {
+	Total int   `json:"total"`
+	Hits  []Hit `json:"hits"`
+}
+
+// titleBoost counts title terms more than body terms
+const titleBoost = 2
+
+type entry struct {
+	doc    Document
+	length int
+	terms  []string
+}
+
+// Index is an in-memory BM25 inverted index, safe for concurrent use
+type Index struct {
+	K1 float64
+	B  float64
+
+	mu       sync.RWMutex
+	docs     map[string]*entry
+	postings map[string]map[string]int
+	totalLen int
+}
+
+// New returns an empty index with the usual BM25 parameters
+func New() *Index {
+	return &Index{
+		K1:       1.2,
+		B:        0.75,
+		docs:     make(map[string]*entry),
+		postings: make(map[string]map[string]int),
+	}
+}
+
+// Len is the number of indexed documents
+func (idx *Index) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return len(idx.docs)
+}
+
+// Add indexes a document, replacing any document with the same ID
+func (idx *Index) Add(docs ...Document) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	for _, doc := range docs {
+		idx.remove(doc.ID)
+		frequencies := make(map[string]int)
+		length := 0
+		for _, term := range Terms(doc.Title) {
+			frequencies[term] += titleBoost
+			length += titleBoost
+		}
+		for _, term := range Terms(doc.Text) {
+			frequencies[term]++
+			length++
+		}
+		e := &entry{doc: doc, length: length}
+		for term, n := range frequencies {
+			if idx.postings[term] == nil {
+				idx.postings[term] = make(map[string]int)
+			}
+			idx.postings[term][doc.ID] = n
+			e.terms = append(e.terms, term)
+		}
+		idx.docs[doc.ID] = e
+		idx.totalLen += length
+	}
+}
+
+// Remove drops a document from the index
+func (idx *Index) Remove(id string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.remove(id)
+}
+
+func (idx *Index) remove(id string) {
+	e, ok := idx.docs[id]
+	if !ok {
+		return
+	}
+	for _, term := range e.terms {
+		delete(idx.postings[term], id)
+		if len(idx.postings[term]) == 0 {
+			delete(idx.postings, term)
+		}
+	}
+	idx.totalLen -= e.length
+	delete(idx.docs, id)
+}
+
+// Search scores the documents matching the filters against the query text.
+// Without query text the filtered documents are returned newest first
+func (idx *Index) Search(q Query) Results {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	terms := uniqueTerms(q.Text)
+	var hits []Hit
+	if len(terms) == 0 {
+		for _, e := range idx.docs {
+			if q.matches(e.doc) {
+				hits = append(hits, Hit{Document: e.doc})
+			}
+		}
+		sort.Slice(hits, func(i, j int) bool {
+			if !hits[i].Date.Equal(hits[j].Date) {
+				return hits[i].Date.After(hits[j].Date)
+			}
+			return hits[i].ID < hits[j].ID
+		})
+	} else {
+		scores := idx.score(terms)
+		for id, score := range scores {
+			doc := idx.docs[id].doc
+			if q.matches(doc) {
+				hits = append(hits, Hit{Document: doc, Score: score})
+			}
+		}
+		sort.Slice(hits, func(i, j int) bool {
+			if hits[i].Score != hits[j].Score {
+				return hits[i].Score > hits[j].Score
+			}
+			return hits[i].ID < hits[j].ID
+		})
+	}
+
+	results := Results{Total: len(hits)}
+	if q.Offset >= len(hits) {
+		return results
+	}
+	hits = hits[q.Offset:]
+	if q.Limit > 0 && len(hits) > q.Limit {
+		hits = hits[:q.Limit]
+	}
+	results.Hits = hits
+	return results
+}
+
+// score sums the BM25 contribution of every query term per document
+func (idx *Index) score(terms []string) map[string]float64 {
+	scores := make(map[string]float64)
+	n := float64(len(idx.docs))
+	if n == 0 {
+		return scores
+	}
+	avgLen := float64(idx.totalLen) / n
+	for _, term := range terms {
+		posting := idx.postings[term]
+		if len(posting) == 0 {
+			continue
+		}
+		df := float64(len(posting))
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+		for id, tf := range posting {
+			length := float64(idx.docs[id].length)
+			f := float64(tf)
+			scores[id] += idf * f * (idx.K1 + 1) / (f + idx.K1*(1-idx.B+idx.B*length/avgLen))
+		}
+	}
+	return scores
+}
+
+func (q Query) matches(doc Document) bool {
+	if len(q.Tickers) > 0 && !containsFold(q.Tickers, doc.Ticker) {
+		return false
+	}
+	if len(q.Sections) > 0 && !containsFold(q.Sections, doc.Section) {
+		return false
+	}
+	if len(q.Sources) > 0 && !containsFold(q.Sources, doc.Source) {
+		return false
+	}
+	if !q.From.IsZero() && doc.Date.Before(q.From) {
+		return false
+	}
+	if !q.To.IsZero() && doc.Date.After(q.To) {
+		return false
+	}
+	return true
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func uniqueTerms(text string) []string {
+	seen := make(map[string]bool)
+	var terms []string
+	for _, term := range Terms(text) {
+		if !seen[term] {
+			seen[term] = true
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
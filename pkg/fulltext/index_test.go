@@ -0,0 +1,134 @@
+package fulltext
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func day(d int) time.Time {
+	return time.Date(2024, time.March, d, 0, 0, 0, 0, time.UTC)
+}
+
+func testIndex() *Index {
+	idx := New()
+	idx.Add(
+		Document{ID: "aapl-fin", Ticker: "AAPL", Section: "fin", Source: SourceReport, Text: "Gross margin expanded to 46 percent while services revenue grew.", Date: day(1)},
+		Document{ID: "aapl-news", Ticker: "AAPL", Source: SourceNews, Title: "Apple margins beat estimates", Text: "Analysts cheered the results.", Date: day(5)},
+		Document{ID: "msft-fin", Ticker: "MSFT", Section: "fin", Source: SourceReport, Text: "Cloud revenue grew 20 percent and operating margin held steady at 44 percent for the quarter.", Date: day(3)},
+		Document{ID: "brk-raw", Ticker: "BRK.B", Source: SourceRaw, Text: "BRK.B holds cash of 3.5 billion dollars.", Date: day(2)},
+	)
+	return idx
+}
+
+func hitIDs(results Results) []string {
+	var ids []string
+	for _, hit := range results.Hits {
+		ids = append(ids, hit.ID)
+	}
+	return ids
+}
+
+func TestTokenize(t *testing.T) {
+	tokens := Tokenize("The companies' margins, BRK.B and 3.5% growth.")
+	var terms []string
+	for _, token := range tokens {
+		terms = append(terms, token.Term)
+	}
+	if want := []string{"company", "margin", "brk.b", "3.5", "growth"}; !reflect.DeepEqual(terms, want) {
+		t.Errorf("got %v, want %v", terms, want)
+	}
+	if last := tokens[len(tokens)-1]; last.Start != 39 || last.End != 45 {
+		t.Errorf("growth spans %d to %d, want 39 to 45", last.Start, last.End)
+	}
+}
+
+func TestSearchRanking(t *testing.T) {
+	idx := testIndex()
+	results := idx.Search(Query{Text: "margins"})
+	if results.Total != 3 {
+		t.Fatalf("%d documents match margins, want 3", results.Total)
+	}
+	// the title match counts double and the news document is short
+	if ids := hitIDs(results); ids[0] != "aapl-news" {
+		t.Errorf("got %v, want the title match first", ids)
+	}
+	for i := 1; i < len(results.Hits); i++ {
+		if results.Hits[i].Score > results.Hits[i-1].Score {
+			t.Errorf("hits are not sorted by score: %v", results.Hits)
+		}
+	}
+	if got := idx.Search(Query{Text: "brk.b cash"}); !reflect.DeepEqual(hitIDs(got), []string{"brk-raw"}) {
+		t.Errorf("got %v for a dotted ticker", hitIDs(got))
+	}
+	if got := idx.Search(Query{Text: "the and of"}); got.Total != 4 {
+		t.Errorf("a stopword query matched %d documents, want all 4 as a filter only query", got.Total)
+	}
+}
+
+func TestSearchFilters(t *testing.T) {
+	idx := testIndex()
+	cases := []struct {
+		name  string
+		query Query
+		want  []string
+	}{
+		{"ticker", Query{Text: "revenue grew", Tickers: []string{"msft"}}, []string{"msft-fin"}},
+		{"section", Query{Text: "percent", Sections: []string{"FIN"}, Tickers: []string{"AAPL"}}, []string{"aapl-fin"}},
+		{"source", Query{Text: "margin", Sources: []string{SourceNews}}, []string{"aapl-news"}},
+		{"dates", Query{Text: "margin", From: day(2), To: day(4)}, []string{"msft-fin"}},
+		{"newest first without text", Query{}, []string{"aapl-news", "msft-fin", "brk-raw", "aapl-fin"}},
+		{"page", Query{Limit: 2, Offset: 1}, []string{"msft-fin", "brk-raw"}},
+		{"past the end", Query{Offset: 10}, nil},
+		{"no match", Query{Text: "dividend"}, nil},
+	}
+	for _, c := range cases {
+		if got := hitIDs(idx.Search(c.query)); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: got %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestAddReplacesAndRemove(t *testing.T) {
+	idx := testIndex()
+	idx.Add(Document{ID: "aapl-fin", Ticker: "AAPL", Source: SourceReport, Text: "Dividend raised by 4 percent.", Date: day(1)})
+	if idx.Len() != 4 {
+		t.Fatalf("index has %d documents after replacing one, want 4", idx.Len())
+	}
+	if got := hitIDs(idx.Search(Query{Text: "gross"})); got != nil {
+		t.Errorf("terms of the replaced document still match: %v", got)
+	}
+	if got := hitIDs(idx.Search(Query{Text: "dividend"})); !reflect.DeepEqual(got, []string{"aapl-fin"}) {
+		t.Errorf("got %v for the new text", got)
+	}
+
+	idx.Remove("aapl-fin")
+	idx.Remove("missing")
+	if idx.Len() != 3 || idx.Search(Query{Text: "dividend"}).Total != 0 {
+		t.Errorf("removed document is still indexed")
+	}
+	if _, ok := idx.postings["dividend"]; ok {
+		t.Errorf("the posting list of a removed term is kept")
+	}
+	for _, id := range []string{"aapl-news", "msft-fin", "brk-raw"} {
+		idx.Remove(id)
+	}
+	if idx.totalLen != 0 || len(idx.postings) != 0 {
+		t.Errorf("empty index keeps %d terms of length %d", len(idx.postings), idx.totalLen)
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	text := "Revenue was flat. " + strings.Repeat("Filler words about nothing. ", 10) + "Gross margins expanded on services margin strength."
+	fragments := DefaultHighlighter.Highlight(text, "margin")
+	if len(fragments) != 1 {
+		t.Fatalf("got %d fragments, want 1: %q", len(fragments), fragments)
+	}
+	if !strings.Contains(fragments[0], "<em>margins</em>") || !strings.Contains(fragments[0], "<em>margin</em>") {
+		t.Errorf("matches are not marked in %q", fragments[0])
+	}
+	if DefaultHighlighter.Highlight(text, "the") != nil {
+		t.Error("a stopword query should not highlight")
+	}
+}
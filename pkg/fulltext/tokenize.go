@@ -0,0 +1,77 @@
+package fulltext
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Token is a normalized term and the byte span it came from
+type Token struct {
+	Term  string
+	Start int
+	End   int
+}
+
+var stopwords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true, "by": true,
+	"for": true, "from": true, "has": true, "have": true, "in": true, "is": true, "it": true, "its": true,
+	"of": true, "on": true, "or": true, "that": true, "the": true, "this": true, "to": true, "was": true,
+	"were": true, "will": true, "with": true, "which": true, "while": true, "than": true, "into": true,
+}
+
+// Tokenize splits text into lowercase terms, dropping stopwords. Terms keep
+// inner dots and dashes so tickers like BRK.B and figures like 3.5 stay whole
+func Tokenize(text string) []Token {
+	var tokens []Token
+	start := -1
+	flush := func(end int) {
+		if start < 0 {
+			return
+		}
+		word := strings.TrimRight(text[start:end], ".-")
+		if word != "" {
+			if term := Normalize(word); term != "" {
+				tokens = append(tokens, Token{Term: term, Start: start, End: start + len(word)})
+			}
+		}
+		start = -1
+	}
+	for i, r := range text {
+		inner := (r == '.' || r == '-') && start >= 0
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || inner {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		flush(i)
+	}
+	flush(len(text))
+	return tokens
+}
+
+// Terms returns only the terms of Tokenize
+func Terms(text string) []string {
+	tokens := Tokenize(text)
+	terms := make([]string, len(tokens))
+	for i, t := range tokens {
+		terms[i] = t.Term
+	}
+	return terms
+}
+
+// Normalize lowercases a word and strips plural endings so "margins" matches
+// "margin". It returns "" for stopwords
+func Normalize(word string) string {
+	term := strings.ToLower(word)
+	if stopwords[term] {
+		return ""
+	}
+	switch {
+	case len(term) > 4 && strings.HasSuffix(term, "ies"):
+		term = term[:len(term)-3] + "y"
+	case len(term) > 3 && strings.HasSuffix(term, "s") && !strings.HasSuffix(term, "ss") && !strings.HasSuffix(term, "us"):
+		term = term[:len(term)-1]
+	}
+	return term
+}
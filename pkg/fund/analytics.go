@@ -0,0 +1,230 @@
+package fund
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// SectorWeight is the combined weight of all constituents in a sector
+type SectorWeight struct {
+	Sector string  `json:"sector"`
+	Weight float64 `json:"weight"`
+}
+
+// Tracking compares the fund's returns with its benchmark over the same days
+type Tracking struct {
+	Benchmark          string  `json:"benchmark"`
+	Days               int     `json:"days"`
+	FundReturn         float64 `json:"fund_return"`
+	BenchmarkReturn    float64 `json:"benchmark_return"`
+	TrackingDifference float64 `json:"tracking_difference"`
+	TrackingError      float64 `json:"tracking_error"`
+}
+
+// Fundamentals are the weighted fundamentals of the fund's constituents
+type Fundamentals struct {
+	Ticker        string         `json:"ticker"`
+	Type          string         `json:"type"`
+	Holdings      int            `json:"holdings"`
+	WeightedPE    float64        `json:"weighted_pe,omitempty"`
+	WeightedYield float64        `json:"weighted_yield,omitempty"`
+	ExpenseRatio  float64        `json:"expense_ratio,omitempty"`
+	Top10Weight   float64        `json:"top10_weight"`
+	Sectors       []SectorWeight `json:"sectors"`
+	Tracking      *Tracking      `json:"tracking,omitempty"`
+}
+
+// TopHoldings returns the n largest constituents by weight
+func (h *Holdings) TopHoldings(n int) []Constituent {
+	sorted := make([]Constituent, len(h.Constituents))
+	copy(sorted, h.Constituents)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Weight > sorted[j].Weight
+	})
+	if len(sorted) > n {
+		sorted = sorted[:n]
+	}
+	return sorted
+}
+
+// SectorBreakdown sums constituent weights per sector, largest first
+func (h *Holdings) SectorBreakdown() []SectorWeight {
+	sectors := make(map[string]float64)
+	for _, c := range h.Constituents {
+		sector := c.Sector
+		if sector == "" {
+			sector = "Unclassified"
+		}
+		sectors[sector] += c.Weight
+	}
+
+	breakdown := make([]SectorWeight, 0, len(sectors))
+	for sector, weight := range sectors {
+		breakdown = append(breakdown, SectorWeight{Sector: sector, Weight: weight})
+	}
+	sort.Slice(breakdown, func(i, j int) bool {
+		if breakdown[i].Weight == breakdown[j].Weight {
+			return breakdown[i].Sector < breakdown[j].Sector
+		}
+		return breakdown[i].Weight > breakdown[j].Weight
+	})
+	return breakdown
+}
+
+// WeightedPE is the weighted harmonic mean of constituent P/E ratios, which is
+// the fund's price over its share of constituent earnings. Constituents with
+// no or negative earnings are left out
+func (h *Holdings) WeightedPE() float64 {
+	var weight, earningsYield float64
+	for _, c := range h.Constituents {
+		if c.PE <= 0 {
+			continue
+		}
+		weight += c.Weight
+		earningsYield += c.Weight / c.PE
+	}
+	if earningsYield == 0 {
+		return 0
+	}
+	return weight / earningsYield
+}
+
+// WeightedYield is the weight averaged dividend yield of the constituents
+func (h *Holdings) WeightedYield() float64 {
+	var weight, yield float64
+	for _, c := range h.Constituents {
+		weight += c.Weight
+		yield += c.Weight * c.DividendYield
+	}
+	if weight == 0 {
+		return 0
+	}
+	return yield / weight
+}
+
+// Analyze computes the fund's fundamentals, tracking is added by the caller
+func (h *Holdings) Analyze() Fundamentals {
+	var top10 float64
+	for _, c := range h.TopHoldings(10) {
+		top10 += c.Weight
+	}
+	return Fundamentals{
+		Ticker:        h.Ticker,
+		Type:          h.Type,
+		Holdings:      h.Count(),
+		WeightedPE:    h.WeightedPE(),
+		WeightedYield: h.WeightedYield(),
+		ExpenseRatio:  h.ExpenseRatio,
+		Top10Weight:   top10,
+		Sectors:       h.SectorBreakdown(),
+	}
+}
+
+// CompareTracking computes tracking difference and annualized tracking error
+// from two aligned series of daily closes, oldest first
+func CompareTracking(benchmark string, fundCloses, benchCloses []float64) (*Tracking, error) {
+	n := len(fundCloses)
+	if len(benchCloses) < n {
+		n = len(benchCloses)
+	}
+	if n < 2 {
+		return nil, fmt.Errorf("not enough price history to compare with %s", benchmark)
+	}
+	fundCloses, benchCloses = fundCloses[len(fundCloses)-n:], benchCloses[len(benchCloses)-n:]
+
+	diffs := make([]float64, 0, n-1)
+	for i := 1; i < n; i++ {
+		fundReturn := fundCloses[i]/fundCloses[i-1] - 1
+		benchReturn := benchCloses[i]/benchCloses[i-1] - 1
+		diffs = append(diffs, fundReturn-benchReturn)
+	}
+
+	var mean float64
+	for _, d := range diffs {
+		mean += d
+	}
+	mean /= float64(len(diffs))
+	var variance float64
+	for _, d := range diffs {
+		variance += (d - mean) * (d - mean)
+	}
+	if len(diffs) > 1 {
+		variance /= float64(len(diffs) - 1)
+	}
+
+	t := &Tracking{
+		Benchmark:       benchmark,
+		Days:            n,
+		FundReturn:      (fundCloses[n-1]/fundCloses[0] - 1) * 100,
+		BenchmarkReturn: (benchCloses[n-1]/benchCloses[0] - 1) * 100,
+		TrackingError:   math.Sqrt(variance) * math.Sqrt(252) * 100,
+	}
+	t.TrackingDifference = t.FundReturn - t.BenchmarkReturn
+	return t, nil
+}
+
+// Summary renders the fund profile as plain text for prompt templates
+func (h *Holdings) Summary(topN int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "FUND: %s (%s) TYPE: %s", h.Name, h.Ticker, h.Type)
+	if h.Issuer != "" {
+		fmt.Fprintf(&b, " ISSUER: %s", h.Issuer)
+	}
+	if h.Description != "" {
+		fmt.Fprintf(&b, " DESCRIPTION: %s", h.Description)
+	}
+	if h.Benchmark != "" {
+		fmt.Fprintf(&b, " BENCHMARK: %s", h.Benchmark)
+	}
+	if len(h.Constituents) == 0 {
+		b.WriteString(" HOLDINGS: NOT AVAILABLE")
+		return b.String()
+	}
+	fmt.Fprintf(&b, " NUMBER OF HOLDINGS: %d", h.Count())
+
+	top := make([]string, 0, topN)
+	for _, c := range h.TopHoldings(topN) {
+		top = append(top, fmt.Sprintf("%s %.2f%%", c.Ticker, c.Weight))
+	}
+	fmt.Fprintf(&b, " TOP HOLDINGS: %s", strings.Join(top, ", "))
+	if h.AsOf != "" {
+		fmt.Fprintf(&b, " HOLDINGS AS OF: %s", h.AsOf)
+	}
+	return b.String()
+}
+
+// Summary renders the fund fundamentals as plain text for prompt templates
+func (f Fundamentals) Summary() string {
+	var b strings.Builder
+	// without constituent weights there is nothing to aggregate
+	if f.Top10Weight == 0 {
+		fmt.Fprintf(&b, "The holdings of %s are NOT AVAILABLE", f.Ticker)
+	} else {
+		fmt.Fprintf(&b, "The aggregated fundamentals for %s are HOLDINGS: %d, TOP 10 CONCENTRATION: %.2f%%", f.Ticker, f.Holdings, f.Top10Weight)
+	}
+	if f.WeightedPE > 0 {
+		fmt.Fprintf(&b, ", WEIGHTED P/E: %.2f", f.WeightedPE)
+	}
+	if f.WeightedYield > 0 {
+		fmt.Fprintf(&b, ", WEIGHTED DIVIDEND YIELD: %.2f%%", f.WeightedYield)
+	}
+	if f.Type == TypeETF {
+		fmt.Fprintf(&b, ", EXPENSE RATIO: %.2f%%", f.ExpenseRatio)
+	}
+
+	if len(f.Sectors) > 0 {
+		sectors := make([]string, 0, len(f.Sectors))
+		for _, s := range f.Sectors {
+			sectors = append(sectors, fmt.Sprintf("%s %.2f%%", s.Sector, s.Weight))
+		}
+		fmt.Fprintf(&b, ", SECTOR BREAKDOWN: %s", strings.Join(sectors, ", "))
+	}
+
+	if t := f.Tracking; t != nil {
+		fmt.Fprintf(&b, ", TRACKING VERSUS %s OVER %d DAYS: FUND RETURN %.2f%%, BENCHMARK RETURN %.2f%%, TRACKING DIFFERENCE %.2f%%, ANNUALIZED TRACKING ERROR %.2f%%",
+			t.Benchmark, t.Days, t.FundReturn, t.BenchmarkReturn, t.TrackingDifference, t.TrackingError)
+	}
+	return b.String()
+}
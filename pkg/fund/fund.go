@@ -0,0 +1,162 @@
+package fund
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned when a provider has no holdings for a ticker
+var ErrNotFound = errors.New("fund holdings not found")
+
+// fund types
+const (
+	TypeETF   = "ETF"
+	TypeIndex = "INDEX"
+)
+
+// Constituent is a single holding of an index or ETF
+type Constituent struct {
+	Ticker        string  `json:"ticker"`
+	Name          string  `json:"name,omitempty"`
+	Weight        float64 `json:"weight"`
+	Sector        string  `json:"sector,omitempty"`
+	PE            float64 `json:"pe,omitempty"`
+	DividendYield float64 `json:"dividend_yield,omitempty"`
+}
+
+// Holdings describes an index or ETF and its constituents. Weights are
+// percentages, ExpenseRatio and DividendYield are percentages as well
+type Holdings struct {
+	Ticker       string  `json:"ticker"`
+	Name         string  `json:"name"`
+	Type         string  `json:"type"`
+	Description  string  `json:"description,omitempty"`
+	Issuer       string  `json:"issuer,omitempty"`
+	ExpenseRatio float64 `json:"expense_ratio,omitempty"`
+	Benchmark    string  `json:"benchmark,omitempty"`
+	AsOf         string  `json:"as_of,omitempty"`
+	// TotalHoldings is the fund's number of holdings when Constituents only
+	// lists the largest ones
+	TotalHoldings int           `json:"total_holdings,omitempty"`
+	Constituents  []Constituent `json:"constituents"`
+}
+
+// Count is the number of holdings of the fund
+func (h *Holdings) Count() int {
+	if h.TotalHoldings > len(h.Constituents) {
+		return h.TotalHoldings
+	}
+	return len(h.Constituents)
+}
+
+// index tickers such as I:SPX and ETF tickers such as SPY or BRK.B
+var tickerPattern = regexp.MustCompile(`^(I:)?[A-Z0-9][A-Z0-9.\-]{0,14}$`)
+
+// ValidTicker reports whether holdings can be looked up for ticker, which
+// keeps tickers from naming other files or URLs
+func ValidTicker(ticker string) bool {
+	return tickerPattern.MatchString(strings.ToUpper(ticker))
+}
+
+// Provider loads holdings for an index or ETF ticker
+type Provider interface {
+	Holdings(ctx context.Context, ticker string) (*Holdings, error)
+}
+
+// FileProvider reads holdings from <Dir>/<ticker>.json, the colon in index
+// tickers is replaced with an underscore so I:SPX is stored as I_SPX.json
+type FileProvider struct {
+	Dir string
+}
+
+func (p FileProvider) Holdings(ctx context.Context, ticker string) (*Holdings, error) {
+	if !ValidTicker(ticker) {
+		return nil, ErrNotFound
+	}
+	path := filepath.Join(p.Dir, FileName(ticker))
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var h Holdings
+	if err := json.Unmarshal(data, &h); err != nil {
+		return nil, fmt.Errorf("could not parse holdings file %s: %v", path, err)
+	}
+	return &h, nil
+}
+
+// FileName returns the holdings file name for a ticker
+func FileName(ticker string) string {
+	return strings.ReplaceAll(strings.ToUpper(ticker), ":", "_") + ".json"
+}
+
+// HTTPProvider loads holdings from a JSON endpoint using the same document
+// shape as the holdings files. The URL template may contain {ticker}
+type HTTPProvider struct {
+	URLTemplate string
+	Client      *http.Client
+}
+
+func NewHTTPProvider(urlTemplate string) *HTTPProvider {
+	return &HTTPProvider{
+		URLTemplate: urlTemplate,
+		Client:      &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+func (p *HTTPProvider) Holdings(ctx context.Context, ticker string) (*Holdings, error) {
+	if !ValidTicker(ticker) {
+		return nil, ErrNotFound
+	}
+	reqURL := strings.ReplaceAll(p.URLTemplate, "{ticker}", url.PathEscape(ticker))
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("holdings provider returned status %d", resp.StatusCode)
+	}
+
+	var h Holdings
+	if err := json.NewDecoder(resp.Body).Decode(&h); err != nil {
+		return nil, err
+	}
+	return &h, nil
+}
+
+// Chain tries each provider in order until one has the holdings
+type Chain []Provider
+
+func (c Chain) Holdings(ctx context.Context, ticker string) (*Holdings, error) {
+	for _, p := range c {
+		h, err := p.Holdings(ctx, ticker)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		return h, err
+	}
+	return nil, ErrNotFound
+}
@@ -0,0 +1,77 @@
+package ingest
+
+import (
+	"regexp"
+	"strings"
+)
+
+// ChunkOptions size the overlapping sentence windows a text is split into
+type ChunkOptions struct {
+	// Sentences is the number of sentences per chunk
+	Sentences int
+	// Overlap is the number of sentences shared by consecutive chunks
+	Overlap int
+}
+
+// DefaultChunkOptions match the windows the historical data loader used
+func DefaultChunkOptions() ChunkOptions {
+	return ChunkOptions{Sentences: 6, Overlap: 2}
+}
+
+var abbreviation = regexp.MustCompile(`(?i)\b(?:[a-z]\.|(?:dr|mr|mrs|ms|prof|inc|llc|llp|ie|eg|vs|co|corp|ltd)\.)$`)
+
+// SplitSentences splits text on sentence ending punctuation, leaving
+// ellipses, initials and common abbreviations inside their sentence
+func SplitSentences(text string) []string {
+	var sentences []string
+	var current strings.Builder
+	for _, word := range strings.Fields(text) {
+		if current.Len() > 0 {
+			current.WriteByte(' ')
+		}
+		current.WriteString(word)
+		if isEndOfSentence(word) {
+			sentences = append(sentences, current.String())
+			current.Reset()
+		}
+	}
+	if current.Len() > 0 {
+		sentences = append(sentences, current.String())
+	}
+	return sentences
+}
+
+func isEndOfSentence(word string) bool {
+	word = strings.TrimRight(word, `"')]`)
+	if !strings.HasSuffix(word, ".") && !strings.HasSuffix(word, "!") && !strings.HasSuffix(word, "?") {
+		return false
+	}
+	return !strings.HasSuffix(word, "...") && !abbreviation.MatchString(word)
+}
+
+// Chunk splits text into windows of opts.Sentences sentences, each sharing
+// opts.Overlap sentences with the one before it
+func Chunk(text string, opts ChunkOptions) []string {
+	size := opts.Sentences
+	if size <= 0 {
+		size = DefaultChunkOptions().Sentences
+	}
+	overlap := opts.Overlap
+	if overlap < 0 || overlap >= size {
+		overlap = 0
+	}
+
+	sentences := SplitSentences(text)
+	var chunks []string
+	for start := 0; start < len(sentences); start += size - overlap {
+		end := start + size
+		if end > len(sentences) {
+			end = len(sentences)
+		}
+		chunks = append(chunks, strings.Join(sentences[start:end], " "))
+		if end == len(sentences) {
+			break
+		}
+	}
+	return chunks
+}
@@ -0,0 +1,64 @@
+package ingest
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+// infoSections maps the keys of the aggregator's report JSON to the section
+// names used by the rest of the service
+var infoSections = map[string]string{
+	"StockPerformance":  "stk",
+	"FinancialHealth":   "fin",
+	"NewsSummary":       "news",
+	"CompanyDesc":       "desc",
+	"TechnicalAnalysis": "ta",
+	"PeerComparison":    "peers",
+	"Valuation":         "valuation",
+	// historical data uploaded by scripts/loadhistdata
+	"Info": "history",
+}
+
+// ParseInfo reads the "info" form field of the ingestor endpoint. It is a
+// JSON object of report sections, {"Info": text} for historical data, or
+// plain text which becomes a single record. A "Ticker" key in the object
+// overrides ticker
+func ParseInfo(info string, ticker string) []Record {
+	info = strings.TrimSpace(info)
+	if info == "" {
+		return nil
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(info), &fields); err != nil {
+		return []Record{{Ticker: ticker, Section: "info", Text: info}}
+	}
+	for key, value := range fields {
+		if strings.EqualFold(key, "ticker") {
+			if s, ok := value.(string); ok && s != "" {
+				ticker = s
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var records []Record
+	for _, key := range keys {
+		text, ok := fields[key].(string)
+		if !ok || strings.TrimSpace(text) == "" || strings.EqualFold(key, "ticker") {
+			continue
+		}
+		section, known := infoSections[key]
+		if !known {
+			section = strings.ToLower(key)
+		}
+		// report sections are regenerated daily, uploaded history accumulates
+		records = append(records, Record{Ticker: ticker, Section: section, Text: text, Replace: known && section != "history"})
+	}
+	return records
+}
@@ -0,0 +1,173 @@
+package ingest
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"fineas/pkg/embed"
+	"fineas/pkg/vectorstore"
+)
+
+// Metadata fields written with every chunk. DateField holds the day as a
+// YYYYMMDD int so chat queries can range filter it
+const (
+	TextField     = "text"
+	TickerField   = "ticker"
+	SectionField  = "section"
+	DateField     = "current_date"
+	URLField      = "url"
+	TemplateField = "template_version"
+	ChunkField    = "chunk"
+)
+
+// Record is one piece of text to ingest, usually a report section
+type Record struct {
+	Ticker  string
+	Section string
+	Text    string
+	// SourceURL is where the text came from, empty for generated reports
+	SourceURL string
+	// Date is the day the text describes, zero for today
+	Date time.Time
+	// TemplateVersion tags the chunks with the prompt templates that wrote them
+	TemplateVersion string
+	// Replace deletes the chunks stored for the same ticker, section and day
+	// first, so regenerating a report does not leave stale chunks behind
+	Replace bool
+}
+
+// Result lists the vectors an Ingest call wrote
+type Result struct {
+	Records int      `json:"records"`
+	Chunks  int      `json:"chunks"`
+	IDs     []string `json:"ids"`
+}
+
+// Ingestor chunks records, embeds the chunks as passages and upserts them to
+// the vector store
+type Ingestor struct {
+	Embedder embed.Embedder
+	Store    vectorstore.Store
+	Chunking ChunkOptions
+	// Now is the clock used for records without a date
+	Now func() time.Time
+}
+
+// ErrEmpty is returned when none of the records has any text
+var ErrEmpty = errors.New("nothing to ingest")
+
+// DateKey is the YYYYMMDD int stored in DateField
+func DateKey(t time.Time) int {
+	return t.Year()*10000 + int(t.Month())*100 + t.Day()
+}
+
+// CheckDimension reports an embedder whose vectors do not fit the store
+func (i *Ingestor) CheckDimension(ctx context.Context) error {
+	dimension, err := i.Store.Dimension(ctx)
+	if err != nil {
+		return err
+	}
+	return embed.CheckDimension(i.Embedder, dimension)
+}
+
+// Ingest writes the records to the store
+func (i *Ingestor) Ingest(ctx context.Context, records []Record) (Result, error) {
+	now := time.Now
+	if i.Now != nil {
+		now = i.Now
+	}
+
+	var texts []string
+	var vectors []vectorstore.Vector
+	replace := map[string]vectorstore.Filter{}
+	result := Result{}
+	for _, record := range records {
+		chunks := Chunk(record.Text, i.Chunking)
+		if len(chunks) == 0 {
+			continue
+		}
+		result.Records++
+		date := record.Date
+		if date.IsZero() {
+			date = now()
+		}
+		ticker := strings.ToUpper(strings.TrimSpace(record.Ticker))
+		section := strings.TrimSpace(record.Section)
+		day := DateKey(date)
+		if record.Replace && ticker != "" && section != "" {
+			replace[ticker+"|"+section+"|"+strconv.Itoa(day)] = vectorstore.Filter{
+				TickerField:  ticker,
+				SectionField: section,
+				DateField:    day,
+			}
+		}
+
+		for n, chunk := range chunks {
+			metadata := map[string]interface{}{
+				TextField:  chunk,
+				DateField:  day,
+				ChunkField: n,
+			}
+			if ticker != "" {
+				metadata[TickerField] = ticker
+			}
+			if section != "" {
+				metadata[SectionField] = section
+			}
+			if record.SourceURL != "" {
+				metadata[URLField] = record.SourceURL
+			}
+			if record.TemplateVersion != "" {
+				metadata[TemplateField] = record.TemplateVersion
+			}
+			texts = append(texts, chunk)
+			vectors = append(vectors, vectorstore.Vector{
+				ID:       ChunkID(ticker, section, record.SourceURL, day, n, chunk),
+				Metadata: metadata,
+			})
+		}
+	}
+	if len(vectors) == 0 {
+		return result, ErrEmpty
+	}
+
+	if err := i.CheckDimension(ctx); err != nil {
+		return result, err
+	}
+	values, err := embed.Batch(ctx, i.Embedder, texts, embed.Passage)
+	if err != nil {
+		return result, fmt.Errorf("embedding %d chunks: %w", len(texts), err)
+	}
+	for n := range vectors {
+		vectors[n].Values = values[n]
+	}
+
+	for _, filter := range replace {
+		if err := i.Store.DeleteByFilter(ctx, filter); err != nil {
+			return result, fmt.Errorf("removing previous chunks: %w", err)
+		}
+	}
+	if err := i.Store.Upsert(ctx, vectors); err != nil {
+		return result, err
+	}
+
+	result.Chunks = len(vectors)
+	for _, v := range vectors {
+		result.IDs = append(result.IDs, v.ID)
+	}
+	return result, nil
+}
+
+// ChunkID is the ID chunk n of a record is stored under. It is stable for the
+// same chunk so re-ingesting overwrites it, and other indexes of the same
+// text can use it to refer to the stored chunk
+func ChunkID(ticker string, section string, sourceURL string, day int, n int, text string) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%s\x00%s\x00%d\x00%d\x00%s", ticker, section, sourceURL, day, n, text)))
+	return hex.EncodeToString(sum[:16])
+}
@@ -0,0 +1,196 @@
+package news
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"fineas/pkg/sentiment"
+)
+
+// Article is a single news item with the metadata the news section needs
+type Article struct {
+	ID          string    `json:"id" bson:"id"`
+	Title       string    `json:"title" bson:"title"`
+	URL         string    `json:"url" bson:"url"`
+	Publisher   string    `json:"publisher,omitempty" bson:"publisher,omitempty"`
+	PublishedAt time.Time `json:"published_at,omitempty" bson:"published_at,omitempty"`
+	Snippet     string    `json:"snippet,omitempty" bson:"snippet,omitempty"`
+	Tickers     []string  `json:"tickers,omitempty" bson:"tickers,omitempty"`
+	Language    string    `json:"language,omitempty" bson:"language,omitempty"`
+	Source      string    `json:"source,omitempty" bson:"source,omitempty"`
+	Text        string    `json:"text,omitempty" bson:"text,omitempty"`
+
+	Sentiment *sentiment.Score `json:"sentiment,omitempty" bson:"sentiment,omitempty"`
+}
+
+// NewArticle builds an article with a canonical URL and a stable ID
+func NewArticle(title string, rawURL string, publisher string, publishedAt time.Time, snippet string, language string) Article {
+	canonical := CanonicalURL(rawURL)
+	return Article{
+		ID:          ArticleID(canonical),
+		Title:       strings.TrimSpace(title),
+		URL:         canonical,
+		Publisher:   strings.TrimSpace(publisher),
+		PublishedAt: publishedAt.UTC(),
+		Snippet:     strings.TrimSpace(snippet),
+		Language:    language,
+	}
+}
+
+// ArticleID is derived from the canonical URL so the same article always gets the same ID
+func ArticleID(canonicalURL string) string {
+	sum := sha256.Sum256([]byte(canonicalURL))
+	return hex.EncodeToString(sum[:8])
+}
+
+// query parameters that only track where a reader came from
+var trackingParams = []string{"utm_source", "utm_medium", "utm_campaign", "utm_term", "utm_content", "guccounter", "guce_referrer", "guce_referrer_sig", "cmpid", "ncid", "ref", "fbclid", "gclid"}
+
+// CanonicalURL lowercases the scheme and host and drops fragments, tracking
+// parameters and trailing slashes so the same article compares equal
+func CanonicalURL(rawURL string) string {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || u.Host == "" {
+		return strings.TrimSpace(rawURL)
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.TrimPrefix(strings.ToLower(u.Host), "www.")
+	u.Fragment = ""
+
+	query := u.Query()
+	for key := range query {
+		if isTrackingParam(key) {
+			query.Del(key)
+		}
+	}
+	u.RawQuery = query.Encode()
+	if u.Path != "/" {
+		u.Path = strings.TrimSuffix(u.Path, "/")
+	}
+	return u.String()
+}
+
+func isTrackingParam(key string) bool {
+	key = strings.ToLower(key)
+	if strings.HasPrefix(key, "utm_") {
+		return true
+	}
+	for _, tracking := range trackingParams {
+		if key == tracking {
+			return true
+		}
+	}
+	return false
+}
+
+var cashtag = regexp.MustCompile(`\$([A-Z]{1,5})\b`)
+
+// MentionedTickers finds cashtags and any of the known tickers written as a
+// standalone uppercase word in the text
+func MentionedTickers(text string, known []string) []string {
+	seen := make(map[string]bool)
+	var tickers []string
+	add := func(t string) {
+		if !seen[t] {
+			seen[t] = true
+			tickers = append(tickers, t)
+		}
+	}
+	for _, match := range cashtag.FindAllStringSubmatch(text, -1) {
+		add(match[1])
+	}
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !(r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '.')
+	})
+	wordSet := make(map[string]bool, len(words))
+	for _, w := range words {
+		wordSet[strings.TrimSuffix(w, ".")] = true
+	}
+	for _, k := range known {
+		if wordSet[strings.ToUpper(k)] {
+			add(strings.ToUpper(k))
+		}
+	}
+	return tickers
+}
+
+// SortByRecency orders articles newest first, undated articles go last
+func SortByRecency(articles []Article) {
+	sort.SliceStable(articles, func(i, j int) bool {
+		a, b := articles[i].PublishedAt, articles[j].PublishedAt
+		if a.IsZero() != b.IsZero() {
+			return b.IsZero()
+		}
+		return a.After(b)
+	})
+}
+
+// WithinLookback keeps articles published after the cutoff, undated articles
+// are kept because the source could not tell us their age
+func WithinLookback(articles []Article, cutoff time.Time) []Article {
+	var kept []Article
+	for _, a := range articles {
+		if a.PublishedAt.IsZero() || !a.PublishedAt.Before(cutoff) {
+			kept = append(kept, a)
+		}
+	}
+	return kept
+}
+
+// Page returns the 1-based page of articles
+func Page(articles []Article, page int, pageSize int) []Article {
+	if page < 1 || pageSize < 1 {
+		return nil
+	}
+	start := (page - 1) * pageSize
+	if start >= len(articles) {
+		return []Article{}
+	}
+	end := start + pageSize
+	if end > len(articles) {
+		end = len(articles)
+	}
+	return articles[start:end]
+}
+
+// Summary renders an article as a single line for prompt templates
+func (a Article) Summary() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Headline: %s", a.Title)
+	if a.Publisher != "" {
+		fmt.Fprintf(&b, ", Publisher: %s", a.Publisher)
+	}
+	if !a.PublishedAt.IsZero() {
+		fmt.Fprintf(&b, ", Published: %s", a.PublishedAt.Format("2006-01-02 15:04 MST"))
+	}
+	if a.Snippet != "" {
+		fmt.Fprintf(&b, ", Snippet: %s", a.Snippet)
+	}
+	if a.Text != "" {
+		fmt.Fprintf(&b, ", Text: %s", excerpt(a.Text, 600))
+	}
+	if a.Sentiment != nil && a.Sentiment.Hits > 0 {
+		fmt.Fprintf(&b, ", Lexicon sentiment: %.2f (%s)", a.Sentiment.Value, a.Sentiment.Label)
+	}
+	fmt.Fprintf(&b, ", URL: %s", a.URL)
+	return b.String()
+}
+
+// excerpt cuts text to at most n bytes at a word boundary
+func excerpt(text string, n int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if len(text) <= n {
+		return text
+	}
+	cut := strings.LastIndex(text[:n], " ")
+	if cut <= 0 {
+		cut = n
+	}
+	return text[:cut] + "..."
+}
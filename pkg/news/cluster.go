@@ -0,0 +1,234 @@
+package news
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Clustering settings. Articles whose estimated shingle Jaccard similarity is
+// at least ClusterThreshold are grouped into one story
+var (
+	ClusterThreshold = 0.35
+	MinHashSize      = 128
+	RecencyHalfLife  = 24 * time.Hour
+)
+
+// Cluster is a group of articles covering the same story
+type Cluster struct {
+	ID             string    `json:"id" bson:"id"`
+	Representative Article   `json:"representative" bson:"representative"`
+	MemberIDs      []string  `json:"member_ids" bson:"member_ids"`
+	Publishers     []string  `json:"publishers" bson:"publishers"`
+	Size           int       `json:"size" bson:"size"`
+	Latest         time.Time `json:"latest,omitempty" bson:"latest,omitempty"`
+	Importance     float64   `json:"importance" bson:"importance"`
+}
+
+// ClusterArticles groups near duplicate articles with MinHash signatures over
+// word shingles of the headline and snippet, then ranks the clusters by
+// coverage breadth decayed by the age of their newest article
+func ClusterArticles(articles []Article, now time.Time) []Cluster {
+	signatures := make([][]uint64, len(articles))
+	for i, a := range articles {
+		signatures[i] = minHash(shingles(stripPublisher(a.Title)+" "+a.Snippet, 2), MinHashSize)
+	}
+
+	// union find over every pair above the threshold
+	parent := make([]int, len(articles))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	similarity := make([][]float64, len(articles))
+	for i := range articles {
+		similarity[i] = make([]float64, len(articles))
+	}
+	for i := range articles {
+		for j := i + 1; j < len(articles); j++ {
+			sim := estimateJaccard(signatures[i], signatures[j])
+			similarity[i][j], similarity[j][i] = sim, sim
+			if sim >= ClusterThreshold {
+				parent[find(i)] = find(j)
+			}
+		}
+	}
+
+	groups := make(map[int][]int)
+	var roots []int
+	for i := range articles {
+		root := find(i)
+		if _, ok := groups[root]; !ok {
+			roots = append(roots, root)
+		}
+		groups[root] = append(groups[root], i)
+	}
+
+	var clusters []Cluster
+	for _, root := range roots {
+		clusters = append(clusters, buildCluster(articles, groups[root], similarity, now))
+	}
+	sort.SliceStable(clusters, func(i, j int) bool {
+		if clusters[i].Importance != clusters[j].Importance {
+			return clusters[i].Importance > clusters[j].Importance
+		}
+		return clusters[i].Latest.After(clusters[j].Latest)
+	})
+	return clusters
+}
+
+func buildCluster(articles []Article, members []int, similarity [][]float64, now time.Time) Cluster {
+	// the representative is the most central member, ties go to the earliest report
+	best := members[0]
+	bestScore := -1.0
+	for _, i := range members {
+		var score float64
+		for _, j := range members {
+			score += similarity[i][j]
+		}
+		if score > bestScore || (score == bestScore && earlier(articles[i], articles[best])) {
+			best, bestScore = i, score
+		}
+	}
+
+	cluster := Cluster{
+		Representative: articles[best],
+		Size:           len(members),
+	}
+	seen := make(map[string]bool)
+	for _, i := range members {
+		a := articles[i]
+		cluster.MemberIDs = append(cluster.MemberIDs, a.ID)
+		publisher := strings.ToLower(a.Publisher)
+		if publisher != "" && !seen[publisher] {
+			seen[publisher] = true
+			cluster.Publishers = append(cluster.Publishers, a.Publisher)
+		}
+		if a.PublishedAt.After(cluster.Latest) {
+			cluster.Latest = a.PublishedAt
+		}
+	}
+	sort.Strings(cluster.MemberIDs)
+	cluster.ID = ArticleID(strings.Join(cluster.MemberIDs, ","))
+
+	// distinct publishers count fully, repeat coverage from the same publisher counts half
+	breadth := float64(len(cluster.Publishers)) + 0.5*float64(cluster.Size-len(cluster.Publishers))
+	if len(cluster.Publishers) == 0 {
+		breadth = float64(cluster.Size)
+	}
+	cluster.Importance = breadth * recency(cluster.Latest, now)
+	return cluster
+}
+
+func earlier(a Article, b Article) bool {
+	return !a.PublishedAt.IsZero() && (b.PublishedAt.IsZero() || a.PublishedAt.Before(b.PublishedAt))
+}
+
+// recency halves every RecencyHalfLife, undated stories get the weight of a week old one
+func recency(latest time.Time, now time.Time) float64 {
+	age := 7 * 24 * time.Hour
+	if !latest.IsZero() {
+		age = now.Sub(latest)
+	}
+	if age < 0 {
+		age = 0
+	}
+	return math.Pow(0.5, age.Hours()/RecencyHalfLife.Hours())
+}
+
+// shingles are the sets of n consecutive words, ignoring stop words
+func shingles(text string, n int) map[string]bool {
+	var words []string
+	for _, w := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	}) {
+		if !stopWords[w] {
+			words = append(words, w)
+		}
+	}
+
+	set := make(map[string]bool)
+	if len(words) < n {
+		for _, w := range words {
+			set[w] = true
+		}
+		return set
+	}
+	for i := 0; i+n <= len(words); i++ {
+		set[strings.Join(words[i:i+n], " ")] = true
+	}
+	return set
+}
+
+// stripPublisher drops the " - Publisher" suffix that feeds append to headlines
+func stripPublisher(title string) string {
+	if i := strings.LastIndex(title, " - "); i > 0 {
+		return title[:i]
+	}
+	return title
+}
+
+var stopWords = map[string]bool{
+	"a": true, "an": true, "the": true, "and": true, "or": true, "of": true, "to": true,
+	"in": true, "on": true, "for": true, "at": true, "by": true, "with": true, "is": true,
+	"as": true, "its": true, "it": true, "s": true, "from": true, "after": true,
+}
+
+// minHash returns the minimum of size seeded hashes over the shingle set
+func minHash(set map[string]bool, size int) []uint64 {
+	signature := make([]uint64, size)
+	for i := range signature {
+		signature[i] = math.MaxUint64
+	}
+	for shingle := range set {
+		h := fnv.New64a()
+		h.Write([]byte(shingle))
+		base := h.Sum64()
+		for i := range signature {
+			// cheap family of hash functions derived from one base hash
+			v := mix(base ^ (uint64(i+1) * 0x9e3779b97f4a7c15))
+			if v < signature[i] {
+				signature[i] = v
+			}
+		}
+	}
+	return signature
+}
+
+// mix is the splitmix64 finalizer
+func mix(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+// estimateJaccard is the share of signature slots two sets agree on
+func estimateJaccard(a []uint64, b []uint64) float64 {
+	if len(a) == 0 || len(a) != len(b) || a[0] == math.MaxUint64 || b[0] == math.MaxUint64 {
+		return 0
+	}
+	same := 0
+	for i := range a {
+		if a[i] == b[i] {
+			same++
+		}
+	}
+	return float64(same) / float64(len(a))
+}
+
+// Summary renders the cluster's representative and its coverage for prompt templates
+func (c Cluster) Summary() string {
+	return fmt.Sprintf("%s, Coverage: %d articles from %d publishers", c.Representative.Summary(), c.Size, len(c.Publishers))
+}
@@ -0,0 +1,223 @@
+package news
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html/charset"
+)
+
+// SECPressReleaseFeed is EDGAR's Atom feed of a company's 8-K filings, which
+// carry press releases as exhibit 99.1. The SEC requires a User-Agent with a
+// contact address on every request
+const SECPressReleaseFeed = "https://www.sec.gov/cgi-bin/browse-edgar?action=getcompany&CIK={ticker}&type=8-K&dateb=&owner=include&count=40&output=atom"
+
+// FeedSource reads RSS 2.0 and Atom feeds. Locations may be URLs or local
+// file paths and may contain {ticker}. Items from feeds without {ticker},
+// such as a publisher's markets feed, are kept only if they mention the ticker
+type FeedSource struct {
+	SourceName string
+	Locations  []string
+	Publisher  string // used when the feed does not name one
+	UserAgent  string
+	Client     *http.Client
+}
+
+func NewFeedSource(name string, locations []string, publisher string, userAgent string) *FeedSource {
+	return &FeedSource{
+		SourceName: name,
+		Locations:  locations,
+		Publisher:  publisher,
+		UserAgent:  userAgent,
+		Client:     &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+func (f *FeedSource) Name() string {
+	return f.SourceName
+}
+
+func (f *FeedSource) Fetch(ctx context.Context, q Query) ([]Article, error) {
+	var articles []Article
+	var failures []string
+	for _, location := range f.Locations {
+		items, err := f.fetchFeed(ctx, expand(location, q.Ticker), q)
+		if err != nil {
+			failures = append(failures, err.Error())
+			continue
+		}
+		if !strings.Contains(location, "{ticker}") {
+			items = mentioning(items, q.Ticker)
+		}
+		articles = append(articles, items...)
+	}
+	if len(failures) > 0 && len(failures) == len(f.Locations) {
+		return nil, fmt.Errorf("%s: %s", f.SourceName, strings.Join(failures, "; "))
+	}
+
+	articles = WithinLookback(articles, q.Since)
+	SortByRecency(articles)
+	if q.Limit > 0 && len(articles) > q.Limit {
+		articles = articles[:q.Limit]
+	}
+	return articles, nil
+}
+
+func (f *FeedSource) fetchFeed(ctx context.Context, location string, q Query) ([]Article, error) {
+	body, err := open(ctx, f.Client, location, f.UserAgent)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	// EDGAR declares its feeds as ISO-8859-1
+	var doc feedDocument
+	decoder := xml.NewDecoder(body)
+	decoder.CharsetReader = charset.NewReaderLabel
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("could not parse feed %s: %v", location, err)
+	}
+	articles := doc.articles(f.Publisher, q.Language)
+	for i := range articles {
+		articles[i].Source = f.SourceName
+		articles[i].Tickers = MentionedTickers(articles[i].Title+" "+articles[i].Snippet, []string{q.Ticker})
+	}
+	return articles, nil
+}
+
+// mentioning keeps the articles that mention the ticker
+func mentioning(articles []Article, ticker string) []Article {
+	var kept []Article
+	for _, a := range articles {
+		for _, t := range a.Tickers {
+			if t == strings.ToUpper(ticker) {
+				kept = append(kept, a)
+				break
+			}
+		}
+	}
+	return kept
+}
+
+// feedDocument decodes both RSS (<rss><channel><item>) and Atom (<feed><entry>)
+type feedDocument struct {
+	XMLName xml.Name
+	Channel struct {
+		Title string    `xml:"title"`
+		Items []rssItem `xml:"item"`
+	} `xml:"channel"`
+	Title   string      `xml:"title"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type rssItem struct {
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	Description string `xml:"description"`
+	PubDate     string `xml:"pubDate"`
+	Date        string `xml:"http://purl.org/dc/elements/1.1/ date"`
+	Source      string `xml:"source"`
+}
+
+type atomEntry struct {
+	Title string `xml:"title"`
+	Links []struct {
+		Href string `xml:"href,attr"`
+		Rel  string `xml:"rel,attr"`
+	} `xml:"link"`
+	Summary   string `xml:"summary"`
+	Content   string `xml:"content"`
+	Published string `xml:"published"`
+	Updated   string `xml:"updated"`
+	Author    struct {
+		Name string `xml:"name"`
+	} `xml:"author"`
+}
+
+func (d feedDocument) articles(publisher string, language string) []Article {
+	var articles []Article
+	if d.XMLName.Local == "feed" {
+		if publisher == "" {
+			publisher = d.Title
+		}
+		for _, e := range d.Entries {
+			link := ""
+			for _, l := range e.Links {
+				if l.Rel == "" || l.Rel == "alternate" {
+					link = l.Href
+					break
+				}
+			}
+			snippet := e.Summary
+			if snippet == "" {
+				snippet = e.Content
+			}
+			published := e.Published
+			if published == "" {
+				published = e.Updated
+			}
+			entryPublisher := publisher
+			if e.Author.Name != "" && publisher == "" {
+				entryPublisher = e.Author.Name
+			}
+			articles = append(articles, NewArticle(e.Title, link, entryPublisher, parseFeedTime(published), stripHTML(snippet), language))
+		}
+		return articles
+	}
+
+	if publisher == "" {
+		publisher = d.Channel.Title
+	}
+	for _, item := range d.Channel.Items {
+		itemPublisher := publisher
+		if item.Source != "" {
+			itemPublisher = item.Source
+		}
+		published := item.PubDate
+		if published == "" {
+			published = item.Date
+		}
+		articles = append(articles, NewArticle(item.Title, item.Link, itemPublisher, parseFeedTime(published), stripHTML(item.Description), language))
+	}
+	return articles
+}
+
+// date layouts seen in RSS and Atom feeds
+var feedTimeLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC3339,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+// parseFeedTime returns the zero time when no layout matches
+func parseFeedTime(value string) time.Time {
+	value = strings.TrimSpace(value)
+	for _, layout := range feedTimeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// stripHTML reduces an HTML description to its text
+func stripHTML(value string) string {
+	if !strings.Contains(value, "<") {
+		return strings.TrimSpace(value)
+	}
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(value))
+	if err != nil {
+		return strings.TrimSpace(value)
+	}
+	return strings.Join(strings.Fields(doc.Text()), " ")
+}
@@ -0,0 +1,174 @@
+package news
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func titles(articles []Article) []string {
+	var out []string
+	for _, a := range articles {
+		out = append(out, a.Title)
+	}
+	return out
+}
+
+func TestFeedSourceRSS(t *testing.T) {
+	source := NewFeedSource("finance", []string{"testdata/rss_{ticker}.xml"}, "", "")
+	articles, err := source.Fetch(context.Background(), Query{Ticker: "aapl", Language: "en"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"Apple announces record $110 billion buyback",
+		"Apple beats quarterly revenue estimates on iPhone demand",
+		"What to watch in Apple's services business",
+		"Undated analyst note on AAPL",
+	}
+	if got := titles(articles); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %q, want %q newest first and undated last", got, want)
+	}
+
+	buyback, beats, services := articles[0], articles[1], articles[2]
+	if buyback.Publisher != "Reuters" || beats.Publisher != "Example Finance - AAPL" {
+		t.Errorf("publishers are %q and %q, want the item source then the channel title", buyback.Publisher, beats.Publisher)
+	}
+	if !buyback.PublishedAt.Equal(time.Date(2024, 5, 2, 21, 5, 0, 0, time.UTC)) {
+		t.Errorf("dc:date parsed as %v", buyback.PublishedAt)
+	}
+	if !services.PublishedAt.Equal(time.Date(2024, 5, 1, 19, 0, 0, 0, time.UTC)) {
+		t.Errorf("single digit day with an offset parsed as %v", services.PublishedAt)
+	}
+	if beats.URL != "https://finance.example.com/news/apple-beats-estimates" {
+		t.Errorf("URL not canonical: %s", beats.URL)
+	}
+	if beats.ID != ArticleID(beats.URL) {
+		t.Errorf("ID %s does not derive from the canonical URL", beats.ID)
+	}
+	if beats.Snippet != "Apple (AAPL) reported revenue of $90.8 billion." {
+		t.Errorf("HTML description reduced to %q", beats.Snippet)
+	}
+	if !reflect.DeepEqual(beats.Tickers, []string{"AAPL"}) || buyback.Tickers != nil {
+		t.Errorf("tickers are %v and %v, want only the snippet that names AAPL tagged", beats.Tickers, buyback.Tickers)
+	}
+	for _, a := range articles {
+		if a.Source != "finance" || a.Language != "en" {
+			t.Errorf("%q has source %q and language %q", a.Title, a.Source, a.Language)
+		}
+	}
+}
+
+func TestFeedSourceAtom(t *testing.T) {
+	source := NewFeedSource("sec", []string{"testdata/atom_{ticker}.xml"}, "", "")
+	articles, err := source.Fetch(context.Background(), Query{Ticker: "AAPL"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(articles) != 2 {
+		t.Fatalf("got %d entries, want 2", len(articles))
+	}
+	results, meeting := articles[0], articles[1]
+	if results.URL != "https://sec.gov/Archives/edgar/data/320193/000032019324000067-index.htm" {
+		t.Errorf("alternate link is %s", results.URL)
+	}
+	if meeting.URL != "https://sec.gov/Archives/edgar/data/320193/000114036124008896-index.htm" {
+		t.Errorf("link without rel is %s, the related link should be skipped", meeting.URL)
+	}
+	if results.Snippet != "Filed: 2024-05-02 AccNo: 0000320193-24-000067 Item 2.02: Results of Operations and Financial Condition" {
+		t.Errorf("summary reduced to %q", results.Snippet)
+	}
+	if meeting.Snippet != "Item 5.07: Submission of Matters to a Vote of Security Holders" {
+		t.Errorf("content is not used without a summary: %q", meeting.Snippet)
+	}
+	if !results.PublishedAt.Equal(time.Date(2024, 5, 2, 20, 30, 48, 0, time.UTC)) || !meeting.PublishedAt.Equal(time.Date(2024, 2, 28, 21, 30, 10, 0, time.UTC)) {
+		t.Errorf("dates are %v and %v", results.PublishedAt, meeting.PublishedAt)
+	}
+	// the feed title wins over entry authors
+	if results.Publisher != "APPLE INC. (0000320193)" || meeting.Publisher != results.Publisher {
+		t.Errorf("publishers are %q and %q", results.Publisher, meeting.Publisher)
+	}
+
+	named := NewFeedSource("sec", []string{"testdata/atom_{ticker}.xml"}, "SEC EDGAR", "")
+	if articles, _ := named.Fetch(context.Background(), Query{Ticker: "AAPL"}); articles[0].Publisher != "SEC EDGAR" {
+		t.Errorf("configured publisher is ignored: %q", articles[0].Publisher)
+	}
+}
+
+func TestFeedSourceFilters(t *testing.T) {
+	// a feed without {ticker} only keeps items that mention it
+	markets := NewFeedSource("markets", []string{"testdata/markets.xml"}, "", "")
+	articles, err := markets.Fetch(context.Background(), Query{Ticker: "AAPL"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"Stocks rally as AAPL and MSFT lead tech higher", "Why $AAPL options traders are bracing for a move"}
+	if got := titles(articles); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// undated items survive the lookback
+	source := NewFeedSource("finance", []string{"testdata/rss_{ticker}.xml", "testdata/missing_{ticker}.xml"}, "", "")
+	articles, err = source.Fetch(context.Background(), Query{Ticker: "AAPL", Since: time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC), Limit: 3})
+	if err != nil {
+		t.Fatalf("one readable location should be enough: %v", err)
+	}
+	want = []string{"Apple announces record $110 billion buyback", "Apple beats quarterly revenue estimates on iPhone demand", "Undated analyst note on AAPL"}
+	if got := titles(articles); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	broken := NewFeedSource("broken", []string{"testdata/missing_{ticker}.xml", "testdata/missing.xml"}, "", "")
+	if _, err := broken.Fetch(context.Background(), Query{Ticker: "AAPL"}); err == nil || !strings.HasPrefix(err.Error(), "broken: ") {
+		t.Errorf("every location failing returned %v", err)
+	}
+}
+
+func TestGoogleSource(t *testing.T) {
+	source := NewGoogleSource("testdata/google_{ticker}.html")
+	articles, err := source.Fetch(context.Background(), Query{Ticker: "AAPL", Language: "en"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(articles) != 2 {
+		t.Fatalf("got %d articles, want the 2 with headline links", len(articles))
+	}
+	first := articles[0]
+	if !strings.HasPrefix(first.URL, "https://news.google.com/read/CBMi") || first.Publisher != "CNBC" || first.Source != "google" {
+		t.Errorf("got %+v", first)
+	}
+	if !first.PublishedAt.Equal(time.Date(2024, 5, 2, 20, 45, 0, 0, time.UTC)) {
+		t.Errorf("published at %v", first.PublishedAt)
+	}
+	if limited, _ := source.Fetch(context.Background(), Query{Ticker: "AAPL", Limit: 1}); len(limited) != 1 {
+		t.Errorf("limit 1 returned %d articles", len(limited))
+	}
+}
+
+func TestFetchAllMergesSources(t *testing.T) {
+	sources := []Source{
+		NewFeedSource("finance", []string{"testdata/rss_{ticker}.xml"}, "", ""),
+		NewGoogleSource("testdata/google_{ticker}.html"),
+		NewFeedSource("broken", []string{"testdata/missing_{ticker}.xml"}, "", ""),
+	}
+	articles, failures, err := FetchAll(context.Background(), sources, Query{Ticker: "AAPL"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(failures) != 1 || failures[0].Source != "broken" {
+		t.Errorf("failures are %+v, want the broken source", failures)
+	}
+	// the Google copy of the earnings story carries a publisher suffix and merges into the feed item
+	if len(articles) != 5 {
+		t.Fatalf("got %d articles, want 5: %q", len(articles), titles(articles))
+	}
+	if articles[1].Source != "finance" || articles[1].Publisher != "Example Finance - AAPL" {
+		t.Errorf("the first occurrence should win, got %+v", articles[1])
+	}
+
+	if _, _, err := FetchAll(context.Background(), sources[2:], Query{Ticker: "AAPL"}); err == nil {
+		t.Error("all sources failing should return an error")
+	}
+}
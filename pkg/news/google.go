@@ -0,0 +1,209 @@
+package news
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// GoogleNewsURL is the Google News search results page, {query} is replaced
+// with the escaped search query
+const GoogleNewsURL = "https://news.google.com/search?q={query}&hl=en-US&gl=US&ceid=US%3Aen"
+
+// GoogleSource scrapes Google News search results. Location may be a local
+// HTML file, in which case {query} and {ticker} are still expanded
+type GoogleSource struct {
+	Location string
+	Client   *http.Client
+}
+
+func NewGoogleSource(location string) *GoogleSource {
+	if location == "" {
+		location = GoogleNewsURL
+	}
+	return &GoogleSource{
+		Location: location,
+		Client:   &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+func (g *GoogleSource) Name() string {
+	return "google"
+}
+
+func (g *GoogleSource) Fetch(ctx context.Context, q Query) ([]Article, error) {
+	query := strings.ToUpper(q.Ticker) + "-news" + q.Tag
+	if !q.Since.IsZero() {
+		days := int(time.Since(q.Since).Hours()/24) + 1
+		query += " when:" + strconv.Itoa(days) + "d"
+	}
+	location := strings.ReplaceAll(expand(g.Location, q.Ticker), "{query}", url.QueryEscape(query))
+
+	body, err := open(ctx, g.Client, location, "")
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	// Parse the HTML response body
+	doc, err := goquery.NewDocumentFromReader(body)
+	if err != nil {
+		return nil, err
+	}
+
+	var articles []Article
+	doc.Find("article").Each(func(i int, s *goquery.Selection) {
+		if q.Limit > 0 && len(articles) >= q.Limit {
+			return
+		}
+		headline := s.Find("a.JtKRv").First()
+		link, exists := headline.Attr("href")
+		if !exists {
+			return
+		}
+		// Format the URL correctly
+		fullURL := "https://news.google.com" + strings.TrimPrefix(link, ".")
+
+		var publishedAt time.Time
+		if datetime, ok := s.Find("time").First().Attr("datetime"); ok {
+			publishedAt, _ = time.Parse(time.RFC3339, datetime)
+		}
+		publisher := s.Find("div.vr1PYe").First().Text()
+
+		article := NewArticle(headline.Text(), fullURL, publisher, publishedAt, "", q.Language)
+		article.Source = g.Name()
+		article.Tickers = MentionedTickers(article.Title, []string{q.Ticker})
+		articles = append(articles, article)
+	})
+
+	return WithinLookback(articles, q.Since), nil
+}
+
+// IsGoogleNewsURL reports whether a link points at a Google News article
+// page rather than the publisher
+func IsGoogleNewsURL(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	return err == nil && (u.Host == "news.google.com" || strings.HasSuffix(u.Host, ".news.google.com"))
+}
+
+// googleDecodeURL is the endpoint that turns a signed article id into the
+// publisher URL
+const googleDecodeURL = "https://news.google.com/_/DotsSplashUi/data/batchexecute"
+
+// ResolveGoogleNewsURL returns the publisher URL behind a Google News article
+// link. Older article ids carry the URL and are decoded locally, newer ones
+// are exchanged for it with the signature on the article page. Other links
+// are returned unchanged
+func ResolveGoogleNewsURL(ctx context.Context, client *http.Client, rawURL string) (string, error) {
+	if !IsGoogleNewsURL(rawURL) {
+		return rawURL, nil
+	}
+	u, _ := url.Parse(rawURL)
+	id := path.Base(u.Path)
+	if resolved, ok := decodeGoogleNewsID(id); ok {
+		return resolved, nil
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	// the article page carries a signature and timestamp for the id
+	body, err := open(ctx, client, "https://news.google.com/rss/articles/"+url.PathEscape(id), "")
+	if err != nil {
+		return "", err
+	}
+	doc, err := goquery.NewDocumentFromReader(body)
+	body.Close()
+	if err != nil {
+		return "", err
+	}
+	node := doc.Find("c-wiz > div[jscontroller]").First()
+	signature, okSignature := node.Attr("data-n-a-sg")
+	timestamp, okTimestamp := node.Attr("data-n-a-ts")
+	if !okSignature || !okTimestamp {
+		return "", fmt.Errorf("no signature for google news article %s", id)
+	}
+
+	inner := fmt.Sprintf(`["garturlreq",[["X","X",["X","X"],null,null,1,1,"US:en",null,1,null,null,null,null,null,0,1],"X","X",1,[1,1,1],1,1,null,0,0,null,0],%q,%s,%q]`, id, timestamp, signature)
+	outer, _ := json.Marshal([][][]interface{}{{{"Fbv4je", inner, nil, "generic"}}})
+	req, err := http.NewRequestWithContext(ctx, "POST", googleDecodeURL, strings.NewReader(url.Values{"f.req": {string(outer)}}.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded;charset=UTF-8")
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("google news decode returned %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", err
+	}
+	return parseGoogleDecodeResponse(data)
+}
+
+// decodeGoogleNewsID reads the publisher URL from an article id that embeds
+// it, a base64 protobuf with the URL as a length prefixed string
+func decodeGoogleNewsID(id string) (string, bool) {
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(id, "="))
+	if err != nil || len(data) < 4 || data[0] != 0x08 || data[2] != 0x22 {
+		return "", false
+	}
+	length, n := binary.Uvarint(data[3:])
+	if n <= 0 || 3+n+int(length) > len(data) {
+		return "", false
+	}
+	link := string(data[3+n : 3+n+int(length)])
+	if !strings.HasPrefix(link, "http://") && !strings.HasPrefix(link, "https://") {
+		return "", false
+	}
+	return link, true
+}
+
+// parseGoogleDecodeResponse reads the URL from a batchexecute reply, a
+// guarded JSON array whose payload is itself JSON encoded
+func parseGoogleDecodeResponse(data []byte) (string, error) {
+	text := string(data)
+	if i := strings.Index(text, "\n\n"); i >= 0 && strings.HasPrefix(text, ")]}'") {
+		text = text[i+2:]
+	}
+	text = strings.TrimPrefix(text, ")]}'")
+	text = strings.TrimSpace(text)
+	var envelope [][]interface{}
+	if err := json.Unmarshal([]byte(text), &envelope); err != nil {
+		return "", err
+	}
+	for _, entry := range envelope {
+		if len(entry) < 3 {
+			continue
+		}
+		payload, ok := entry[2].(string)
+		if !ok {
+			continue
+		}
+		var result []interface{}
+		if err := json.Unmarshal([]byte(payload), &result); err != nil || len(result) < 2 {
+			continue
+		}
+		if link, ok := result[1].(string); ok && result[0] == "garturlres" {
+			return link, nil
+		}
+	}
+	return "", errors.New("no url in google news decode response")
+}
@@ -0,0 +1,101 @@
+package news
+
+import (
+	"strings"
+	"unicode"
+)
+
+// TitleSimilarityThreshold is the word Jaccard similarity above which two
+// headlines are treated as the same article
+var TitleSimilarityThreshold = 0.8
+
+// Merge combines article lists, dropping duplicates by canonical URL or near
+// identical titles. The first occurrence wins but missing metadata is filled
+// in from its duplicates
+func Merge(lists ...[]Article) []Article {
+	var merged []Article
+	var titleWords []map[string]bool
+	byURL := make(map[string]int)
+
+	for _, list := range lists {
+		for _, article := range list {
+			if i, ok := byURL[article.URL]; ok && article.URL != "" {
+				merged[i] = fillMissing(merged[i], article)
+				continue
+			}
+			words := titleSet(article.Title)
+			duplicate := -1
+			for i, existing := range titleWords {
+				if Jaccard(words, existing) >= TitleSimilarityThreshold {
+					duplicate = i
+					break
+				}
+			}
+			if duplicate >= 0 {
+				merged[duplicate] = fillMissing(merged[duplicate], article)
+				byURL[article.URL] = duplicate
+				continue
+			}
+			byURL[article.URL] = len(merged)
+			merged = append(merged, article)
+			titleWords = append(titleWords, words)
+		}
+	}
+	return merged
+}
+
+// fillMissing copies fields the kept article lacks from a duplicate
+func fillMissing(kept Article, dup Article) Article {
+	if kept.Publisher == "" {
+		kept.Publisher = dup.Publisher
+	}
+	if kept.PublishedAt.IsZero() {
+		kept.PublishedAt = dup.PublishedAt
+	}
+	if kept.Snippet == "" {
+		kept.Snippet = dup.Snippet
+	}
+	if kept.Source == "" {
+		kept.Source = dup.Source
+	}
+	for _, t := range dup.Tickers {
+		found := false
+		for _, k := range kept.Tickers {
+			if k == t {
+				found = true
+				break
+			}
+		}
+		if !found {
+			kept.Tickers = append(kept.Tickers, t)
+		}
+	}
+	return kept
+}
+
+// titleSet lowercases a headline into its set of words, the " - Publisher"
+// suffix that feeds append is dropped so syndicated copies still match
+func titleSet(title string) map[string]bool {
+	words := strings.FieldsFunc(strings.ToLower(stripPublisher(title)), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	set := make(map[string]bool, len(words))
+	for _, w := range words {
+		set[w] = true
+	}
+	return set
+}
+
+// Jaccard is the size of the intersection over the size of the union
+func Jaccard(a map[string]bool, b map[string]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	intersection := 0
+	for w := range a {
+		if b[w] {
+			intersection++
+		}
+	}
+	return float64(intersection) / float64(len(a)+len(b)-intersection)
+}
@@ -0,0 +1,83 @@
+package news
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMergeByURL(t *testing.T) {
+	published := time.Date(2024, 5, 2, 20, 31, 0, 0, time.UTC)
+	first := NewArticle("Apple beats estimates", "https://www.example.com/apple-beats/?utm_source=rss", "", time.Time{}, "", "en")
+	first.Tickers = []string{"AAPL"}
+	duplicate := NewArticle("Apple tops Wall Street forecasts", "https://example.com/apple-beats#comments", "Example", published, "Revenue rose.", "en")
+	duplicate.Source = "polygon"
+	duplicate.Tickers = []string{"AAPL", "MSFT"}
+	other := NewArticle("Microsoft cloud growth slows", "https://example.com/msft-cloud", "Example", published, "", "en")
+
+	merged := Merge([]Article{first, other}, []Article{duplicate})
+	if len(merged) != 2 {
+		t.Fatalf("got %d articles, want 2", len(merged))
+	}
+	kept := merged[0]
+	if kept.Title != first.Title || kept.ID != first.ID {
+		t.Errorf("the first occurrence should win, got %q", kept.Title)
+	}
+	if kept.Publisher != "Example" || !kept.PublishedAt.Equal(published) || kept.Snippet != "Revenue rose." || kept.Source != "polygon" {
+		t.Errorf("missing metadata was not filled in: %+v", kept)
+	}
+	if !reflect.DeepEqual(kept.Tickers, []string{"AAPL", "MSFT"}) {
+		t.Errorf("tickers are %v", kept.Tickers)
+	}
+}
+
+func TestMergeByTitle(t *testing.T) {
+	base := "Apple shares rise after record quarterly iPhone sales in China"
+	cases := []struct {
+		name  string
+		title string
+		// merged reports whether the title is a duplicate of base
+		merged bool
+	}{
+		{"same words", "Apple Shares Rise After Record Quarterly iPhone Sales In China", true},
+		{"publisher suffix", base + " - Reuters", true},
+		{"one word of eleven differs", "Apple shares climb after record quarterly iPhone sales in China", true},
+		{"two words differ", "Apple shares climb after record quarterly iPhone sales in India", false},
+		{"unrelated", "Oil slips on demand worries", false},
+	}
+	for _, c := range cases {
+		a := NewArticle(base, "https://example.com/a", "", time.Time{}, "", "")
+		b := NewArticle(c.title, "https://example.org/b", "", time.Time{}, "", "")
+		if got := len(Merge([]Article{a}, []Article{b})) == 1; got != c.merged {
+			t.Errorf("%s: %q merged with base is %v, want %v", c.name, c.title, got, c.merged)
+		}
+	}
+
+	// the threshold is inclusive, four of five words is exactly 0.8
+	a := NewArticle("Apple raises dividend again", "https://example.com/a", "", time.Time{}, "", "")
+	b := NewArticle("Apple raises dividend again today", "https://example.com/b", "", time.Time{}, "", "")
+	if similarity := Jaccard(titleSet(a.Title), titleSet(b.Title)); similarity != 0.8 {
+		t.Fatalf("similarity is %v, want 0.8", similarity)
+	}
+	if merged := Merge([]Article{a, b}); len(merged) != 1 {
+		t.Errorf("titles at the threshold were not merged")
+	}
+}
+
+func TestMergeFollowsTitleDuplicates(t *testing.T) {
+	a := NewArticle("Apple raises its dividend", "https://example.com/a", "", time.Time{}, "", "")
+	b := NewArticle("Apple raises its dividend - CNBC", "https://example.com/b", "CNBC", time.Time{}, "", "")
+	// shares b's URL but has a different title
+	c := NewArticle("Dividend news", "https://example.com/b", "", time.Time{}, "Four percent higher.", "")
+	// articles without URLs only merge by title
+	d := Article{Title: "Undated rumor"}
+	e := Article{Title: "Another rumor about suppliers"}
+
+	merged := Merge([]Article{a}, []Article{b, c, d, e})
+	if got := titles(merged); !reflect.DeepEqual(got, []string{"Apple raises its dividend", "Undated rumor", "Another rumor about suppliers"}) {
+		t.Fatalf("got %q", got)
+	}
+	if merged[0].Publisher != "CNBC" || merged[0].Snippet != "Four percent higher." {
+		t.Errorf("duplicates did not fill the kept article: %+v", merged[0])
+	}
+}
@@ -0,0 +1,67 @@
+package news
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+	"time"
+
+	polygon "github.com/polygon-io/client-go/rest"
+	"github.com/polygon-io/client-go/rest/models"
+)
+
+// PolygonSource reads Polygon's ticker news. When FixturePath is set the
+// results are read from a JSON file of models.TickerNews instead, so the
+// adapter can run without an API key
+type PolygonSource struct {
+	Client      *polygon.Client
+	FixturePath string
+}
+
+func (p *PolygonSource) Name() string {
+	return "polygon"
+}
+
+func (p *PolygonSource) Fetch(ctx context.Context, q Query) ([]Article, error) {
+	var items []models.TickerNews
+	if p.FixturePath != "" {
+		data, err := os.ReadFile(expand(p.FixturePath, q.Ticker))
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &items); err != nil {
+			return nil, err
+		}
+	} else {
+		limit := q.Limit
+		if limit <= 0 || limit > 1000 {
+			limit = 50
+		}
+		params := models.ListTickerNewsParams{}.
+			WithTicker(models.EQ, strings.ToUpper(q.Ticker)).
+			WithSort(models.PublishedUTC).
+			WithOrder(models.Desc).
+			WithLimit(limit)
+		if !q.Since.IsZero() {
+			params = params.WithPublishedUTC(models.GTE, models.Millis(q.Since))
+		}
+
+		iter := p.Client.ListTickerNews(ctx, params)
+		for iter.Next() && len(items) < limit {
+			items = append(items, iter.Item())
+		}
+		if iter.Err() != nil {
+			return nil, iter.Err()
+		}
+	}
+
+	var articles []Article
+	for _, item := range items {
+		article := NewArticle(item.Title, item.ArticleURL, item.Publisher.Name, time.Time(item.PublishedUTC), item.Description, q.Language)
+		article.Source = p.Name()
+		article.Tickers = item.Tickers
+		articles = append(articles, article)
+	}
+	return WithinLookback(articles, q.Since), nil
+}
@@ -0,0 +1,94 @@
+package news
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Query describes the news wanted from a source
+type Query struct {
+	Ticker   string
+	Tag      string // "-stock" or "-crypto", used by search based sources
+	Since    time.Time
+	Limit    int
+	Language string
+}
+
+// Source is a provider of news articles for a ticker
+type Source interface {
+	Name() string
+	Fetch(ctx context.Context, q Query) ([]Article, error)
+}
+
+// SourceError records a source that failed without failing the whole fetch
+type SourceError struct {
+	Source string `json:"source"`
+	Error  string `json:"error"`
+}
+
+// FetchAll queries every source concurrently and merges the results. It only
+// returns an error when every source failed
+func FetchAll(ctx context.Context, sources []Source, q Query) ([]Article, []SourceError, error) {
+	results := make([][]Article, len(sources))
+	errs := make([]error, len(sources))
+
+	var wg sync.WaitGroup
+	for i, source := range sources {
+		wg.Add(1)
+		go func(i int, source Source) {
+			defer wg.Done()
+			results[i], errs[i] = source.Fetch(ctx, q)
+		}(i, source)
+	}
+	wg.Wait()
+
+	var failures []SourceError
+	for i, err := range errs {
+		if err != nil {
+			failures = append(failures, SourceError{Source: sources[i].Name(), Error: err.Error()})
+		}
+	}
+	if len(sources) > 0 && len(failures) == len(sources) {
+		return nil, failures, fmt.Errorf("all news sources failed, first error: %s: %s", failures[0].Source, failures[0].Error)
+	}
+	return Merge(results...), failures, nil
+}
+
+// open reads a local file path or file:// URL, or GETs an http(s) URL, so
+// every adapter can be pointed at fixture files
+func open(ctx context.Context, client *http.Client, location string, userAgent string) (io.ReadCloser, error) {
+	if !strings.HasPrefix(location, "http://") && !strings.HasPrefix(location, "https://") {
+		return os.Open(strings.TrimPrefix(location, "file://"))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", location, nil)
+	if err != nil {
+		return nil, err
+	}
+	if userAgent != "" {
+		req.Header.Set("User-Agent", userAgent)
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("status code error: %d %s", resp.StatusCode, resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// expand fills the {ticker} placeholder of a URL or path template
+func expand(template string, ticker string) string {
+	return strings.ReplaceAll(template, "{ticker}", strings.ToUpper(ticker))
+}
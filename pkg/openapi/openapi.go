@@ -0,0 +1,161 @@
+package openapi
+
+import "reflect"
+
+// The subset of the OpenAPI 3.0 document model the Fineas spec uses
+
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Servers    []Server             `json:"servers,omitempty"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components Components           `json:"components"`
+
+	// schemaTypes maps component schema names to the Go type they describe
+	schemaTypes map[string]reflect.Type
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+type Server struct {
+	URL         string `json:"url"`
+	Description string `json:"description,omitempty"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas,omitempty"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type        string `json:"type"`
+	Scheme      string `json:"scheme,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem holds the operations of one path. Servers records the port the
+// path is served on
+type PathItem struct {
+	Servers []Server   `json:"servers,omitempty"`
+	Get     *Operation `json:"get,omitempty"`
+	Post    *Operation `json:"post,omitempty"`
+	Delete  *Operation `json:"delete,omitempty"`
+}
+
+// Operation returns the operation for an HTTP method
+func (p *PathItem) Operation(method string) *Operation {
+	switch method {
+	case "GET":
+		return p.Get
+	case "POST":
+		return p.Post
+	case "DELETE":
+		return p.Delete
+	}
+	return nil
+}
+
+type Operation struct {
+	OperationID string                `json:"operationId"`
+	Summary     string                `json:"summary"`
+	Description string                `json:"description,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+// Parameter is a query, path or header parameter
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Description string                `json:"description,omitempty"`
+	Required    bool                  `json:"required"`
+	Content     map[string]*MediaType `json:"content"`
+	// MaxBytes limits the body the validator reads, 0 uses the default for
+	// the content types
+	MaxBytes int64 `json:"x-max-bytes,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+// BearerAuth is the security requirement of services behind the pass key hash
+var BearerAuth = []map[string][]string{{"bearerAuth": {}}}
+
+// Query builds a query parameter
+func Query(name string, schema *Schema, required bool, description string) Parameter {
+	return Parameter{Name: name, In: "query", Schema: schema, Required: required, Description: description}
+}
+
+// PathParam builds a required parameter filling a {name} segment of the path
+func PathParam(name string, schema *Schema, description string) Parameter {
+	return Parameter{Name: name, In: "path", Schema: schema, Required: true, Description: description}
+}
+
+// String, Integer, Number and Boolean build primitive schemas
+func String(enum ...string) *Schema {
+	return &Schema{Type: "string", Enum: enum}
+}
+
+func Integer(min float64, max float64) *Schema {
+	return &Schema{Type: "integer", Minimum: &min, Maximum: &max}
+}
+
+func Number() *Schema {
+	return &Schema{Type: "number"}
+}
+
+func Boolean() *Schema {
+	return &Schema{Type: "boolean"}
+}
+
+// JSON wraps a schema as an application/json body
+func JSON(schema *Schema) map[string]*MediaType {
+	return map[string]*MediaType{"application/json": {Schema: schema}}
+}
+
+// Form accepts the fields of schema as a multipart or urlencoded form body
+func Form(schema *Schema) map[string]*MediaType {
+	return map[string]*MediaType{
+		"multipart/form-data":               {Schema: schema},
+		"application/x-www-form-urlencoded": {Schema: schema},
+	}
+}
+
+// Text is a text/plain body
+func Text() map[string]*MediaType {
+	return map[string]*MediaType{"text/plain": {Schema: String()}}
+}
@@ -0,0 +1,125 @@
+package openapi
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// SchemaFor derives a schema from a Go value's type using its json tags, so
+// the spec follows the contract types. Named structs are added to the
+// document's components and referenced
+func (d *Document) SchemaFor(v interface{}) *Schema {
+	return d.schemaForType(reflect.TypeOf(v))
+}
+
+func (d *Document) schemaForType(t reflect.Type) *Schema {
+	switch t.Kind() {
+	case reflect.Ptr:
+		schema := d.schemaForType(t.Elem())
+		if schema.Ref != "" {
+			return schema
+		}
+		copied := *schema
+		copied.Nullable = true
+		return &copied
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: d.schemaForType(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: d.schemaForType(t.Elem())}
+	case reflect.Interface:
+		return &Schema{}
+	case reflect.Struct:
+		if t == timeType {
+			return &Schema{Type: "string", Format: "date-time"}
+		}
+		if t.Name() == "" {
+			return d.structSchema(t)
+		}
+		if d.Components.Schemas == nil {
+			d.Components.Schemas = make(map[string]*Schema)
+			d.schemaTypes = make(map[string]reflect.Type)
+		}
+		name := t.Name()
+		if other, ok := d.schemaTypes[name]; ok && other != t {
+			name = qualifiedName(t)
+		}
+		if _, ok := d.Components.Schemas[name]; !ok {
+			d.schemaTypes[name] = t
+			// reserve the name first so recursive types terminate
+			d.Components.Schemas[name] = &Schema{Type: "object"}
+			d.Components.Schemas[name] = d.structSchema(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + name}
+	}
+	return &Schema{}
+}
+
+func (d *Document) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, omitempty, skip := jsonName(field)
+		if skip {
+			continue
+		}
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && field.Tag.Get("json") == "" {
+			embedded := d.structSchema(field.Type)
+			for key, value := range embedded.Properties {
+				schema.Properties[key] = value
+			}
+			schema.Required = append(schema.Required, embedded.Required...)
+			continue
+		}
+		schema.Properties[name] = d.schemaForType(field.Type)
+		if !omitempty && field.Type.Kind() != reflect.Ptr {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+	return schema
+}
+
+// qualifiedName prefixes a type with its package when two packages use the
+// same name, search.Result becomes SearchResult
+func qualifiedName(t reflect.Type) string {
+	pkg := t.PkgPath()
+	if i := strings.LastIndex(pkg, "/"); i >= 0 {
+		pkg = pkg[i+1:]
+	}
+	if pkg == "" {
+		return t.Name()
+	}
+	return strings.ToUpper(pkg[:1]) + pkg[1:] + t.Name()
+}
+
+func jsonName(field reflect.StructField) (name string, omitempty bool, skip bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false, true
+	}
+	parts := strings.Split(tag, ",")
+	name = parts[0]
+	if name == "" {
+		name = field.Name
+	}
+	for _, option := range parts[1:] {
+		if option == "omitempty" {
+			omitempty = true
+		}
+	}
+	return name, omitempty, false
+}
@@ -0,0 +1,306 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// body limits of request bodies without MaxBytes. Forms leave room for the
+// 32 MB of fields the ingestor parses, JSON bodies are queries and prompts
+const (
+	DefaultMaxJSONBytes int64 = 1 << 20
+	DefaultMaxFormBytes int64 = 64 << 20
+)
+
+// ValidationError is a request that does not match the spec
+type ValidationError struct {
+	Status  int    `json:"status"`
+	Message string `json:"error"`
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+func invalid(status int, format string, args ...interface{}) *ValidationError {
+	return &ValidationError{Status: status, Message: fmt.Sprintf(format, args...)}
+}
+
+// Validate checks a request against the operation the spec declares for its
+// path and method. The body is read and replaced so the handler can still read it
+func (d *Document) Validate(r *http.Request) *ValidationError {
+	item, pathParams, ok := d.lookup(r.URL.Path)
+	if !ok {
+		return invalid(http.StatusNotFound, "%s is not in the API specification", r.URL.Path)
+	}
+	// CORS preflight is answered by the cors middleware of every service
+	if r.Method == http.MethodOptions {
+		return nil
+	}
+	op := item.Operation(r.Method)
+	if op == nil {
+		return invalid(http.StatusMethodNotAllowed, "%s does not accept %s", r.URL.Path, r.Method)
+	}
+
+	if len(op.Security) > 0 {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ") {
+			return invalid(http.StatusUnauthorized, "%s requires an Authorization: Bearer header", r.URL.Path)
+		}
+	}
+
+	query := r.URL.Query()
+	for _, param := range op.Parameters {
+		if param.In == "path" {
+			if err := checkParameter(param, pathParams[param.Name]); err != nil {
+				return err
+			}
+			continue
+		}
+		if param.In != "query" {
+			continue
+		}
+		value, present := query[param.Name]
+		if !present || value[0] == "" {
+			if param.Required {
+				return invalid(http.StatusBadRequest, "missing required query parameter %q", param.Name)
+			}
+			continue
+		}
+		if err := checkParameter(param, value[0]); err != nil {
+			return err
+		}
+	}
+
+	if op.RequestBody != nil {
+		return d.validateBody(r, op.RequestBody)
+	}
+	return nil
+}
+
+// lookup finds the path item of a request path. Templated paths such as
+// /chat/sessions/{id} match any single segment in place of {id}
+func (d *Document) lookup(path string) (*PathItem, map[string]string, bool) {
+	if item, ok := d.Paths[path]; ok {
+		return item, nil, true
+	}
+	segments := strings.Split(path, "/")
+	for template, item := range d.Paths {
+		if !strings.Contains(template, "{") {
+			continue
+		}
+		parts := strings.Split(template, "/")
+		if len(parts) != len(segments) {
+			continue
+		}
+		params := map[string]string{}
+		for i, part := range parts {
+			if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") && segments[i] != "" {
+				params[part[1:len(part)-1]] = segments[i]
+			} else if part != segments[i] {
+				params = nil
+				break
+			}
+		}
+		if params != nil {
+			return item, params, true
+		}
+	}
+	return nil, nil, false
+}
+
+func checkParameter(param Parameter, value string) *ValidationError {
+	schema := param.Schema
+	switch schema.Type {
+	case "integer", "number":
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil || (schema.Type == "integer" && n != float64(int64(n))) {
+			return invalid(http.StatusBadRequest, "%s parameter %q must be an %s", param.In, param.Name, schema.Type)
+		}
+		if schema.Minimum != nil && n < *schema.Minimum {
+			return invalid(http.StatusBadRequest, "%s parameter %q must be at least %v", param.In, param.Name, *schema.Minimum)
+		}
+		if schema.Maximum != nil && n > *schema.Maximum {
+			return invalid(http.StatusBadRequest, "%s parameter %q must be at most %v", param.In, param.Name, *schema.Maximum)
+		}
+	case "boolean":
+		if value != "true" && value != "false" {
+			return invalid(http.StatusBadRequest, "%s parameter %q must be true or false", param.In, param.Name)
+		}
+	}
+	if len(schema.Enum) > 0 && !contains(schema.Enum, value) {
+		return invalid(http.StatusBadRequest, "%s parameter %q must be one of %s", param.In, param.Name, strings.Join(schema.Enum, ", "))
+	}
+	return nil
+}
+
+// maxBytes is the largest body the validator reads for the request body
+func (b *RequestBody) maxBytes() int64 {
+	if b.MaxBytes > 0 {
+		return b.MaxBytes
+	}
+	for contentType := range b.Content {
+		if contentType == "multipart/form-data" || contentType == "application/x-www-form-urlencoded" {
+			return DefaultMaxFormBytes
+		}
+	}
+	return DefaultMaxJSONBytes
+}
+
+func (d *Document) validateBody(r *http.Request, body *RequestBody) *ValidationError {
+	data, err := io.ReadAll(http.MaxBytesReader(nil, r.Body, body.maxBytes()))
+	r.Body.Close()
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return invalid(http.StatusRequestEntityTooLarge, "request body is larger than %d bytes", tooLarge.Limit)
+	}
+	if err != nil {
+		return invalid(http.StatusBadRequest, "could not read request body")
+	}
+	r.Body = io.NopCloser(bytes.NewReader(data))
+
+	// an optional body may be left out, whatever the content type
+	if len(bytes.TrimSpace(data)) == 0 {
+		if body.Required {
+			return invalid(http.StatusBadRequest, "request body is required")
+		}
+		return nil
+	}
+
+	contentType := r.Header.Get("Content-Type")
+	if i := strings.Index(contentType, ";"); i >= 0 {
+		contentType = contentType[:i]
+	}
+	contentType = strings.TrimSpace(contentType)
+	media, ok := body.Content[contentType]
+	if !ok {
+		var accepted []string
+		for key := range body.Content {
+			accepted = append(accepted, key)
+		}
+		sort.Strings(accepted)
+		return invalid(http.StatusUnsupportedMediaType, "%s expects %s, got %q", r.URL.Path, strings.Join(accepted, " or "), contentType)
+	}
+
+	if contentType != "application/json" {
+		return nil
+	}
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return invalid(http.StatusBadRequest, "request body is not valid JSON: %v", err)
+	}
+	if err := d.checkValue(media.Schema, value, "body"); err != nil {
+		return invalid(http.StatusBadRequest, "%s", err.Error())
+	}
+	return nil
+}
+
+// checkValue validates a decoded JSON value against a schema
+func (d *Document) checkValue(schema *Schema, value interface{}, path string) error {
+	if schema == nil {
+		return nil
+	}
+	if schema.Ref != "" {
+		return d.checkValue(d.Components.Schemas[strings.TrimPrefix(schema.Ref, "#/components/schemas/")], value, path)
+	}
+	if value == nil {
+		if schema.Nullable || schema.Type == "" {
+			return nil
+		}
+		return fmt.Errorf("%s must not be null", path)
+	}
+
+	switch schema.Type {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s must be an object", path)
+		}
+		for _, name := range schema.Required {
+			if _, ok := object[name]; !ok {
+				return fmt.Errorf("%s.%s is required", path, name)
+			}
+		}
+		for name, field := range object {
+			if propSchema, ok := schema.Properties[name]; ok {
+				if err := d.checkValue(propSchema, field, path+"."+name); err != nil {
+					return err
+				}
+			} else if schema.AdditionalProperties != nil {
+				if err := d.checkValue(schema.AdditionalProperties, field, path+"."+name); err != nil {
+					return err
+				}
+			}
+		}
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			return fmt.Errorf("%s must be an array", path)
+		}
+		for i, item := range items {
+			if err := d.checkValue(schema.Items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("%s must be a string", path)
+		}
+		if len(schema.Enum) > 0 && !contains(schema.Enum, s) {
+			return fmt.Errorf("%s must be one of %s", path, strings.Join(schema.Enum, ", "))
+		}
+	case "integer", "number":
+		n, ok := value.(float64)
+		if !ok {
+			return fmt.Errorf("%s must be a number", path)
+		}
+		if schema.Type == "integer" && n != float64(int64(n)) {
+			return fmt.Errorf("%s must be an integer", path)
+		}
+		if schema.Minimum != nil && n < *schema.Minimum {
+			return fmt.Errorf("%s must be at least %v", path, *schema.Minimum)
+		}
+		if schema.Maximum != nil && n > *schema.Maximum {
+			return fmt.Errorf("%s must be at most %v", path, *schema.Maximum)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("%s must be a boolean", path)
+		}
+	}
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Middleware rejects requests that do not match the spec with a JSON error
+func (d *Document) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := d.Validate(r); err != nil {
+			WriteError(w, err)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// WriteError writes a validation error as JSON
+func WriteError(w http.ResponseWriter, err *ValidationError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(err.Status)
+	json.NewEncoder(w).Encode(err)
+}
@@ -0,0 +1,223 @@
+package openapi
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type sessionRequest struct {
+	Title string `json:"title"`
+	Turns int    `json:"turns"`
+}
+
+func testDocument() *Document {
+	doc := &Document{Paths: make(map[string]*PathItem)}
+	doc.Paths["/news"] = &PathItem{
+		Get: &Operation{
+			Parameters: []Parameter{
+				Query("ticker", String(), true, ""),
+				Query("lookback_days", Integer(1, 365), false, ""),
+				Query("full_text", Boolean(), false, ""),
+				Query("mode", String("stock", "index"), false, ""),
+			},
+			Security: BearerAuth,
+		},
+	}
+	doc.Paths["/chat/sessions"] = &PathItem{
+		Post: &Operation{RequestBody: &RequestBody{Content: JSON(doc.SchemaFor(sessionRequest{}))}},
+	}
+	doc.Paths["/chat/sessions/{id}"] = &PathItem{
+		Get:    &Operation{Parameters: []Parameter{PathParam("id", String(), "")}},
+		Delete: &Operation{Parameters: []Parameter{PathParam("id", String(), "")}},
+	}
+	doc.Paths["/chat"] = &PathItem{
+		Post: &Operation{RequestBody: &RequestBody{Required: true, Content: JSON(doc.SchemaFor(sessionRequest{})), MaxBytes: 64}},
+	}
+	doc.Paths["/ingestor"] = &PathItem{
+		Post: &Operation{RequestBody: &RequestBody{Required: true, Content: Form(&Schema{Type: "object"})}},
+	}
+	return doc
+}
+
+func TestLookup(t *testing.T) {
+	doc := testDocument()
+	cases := []struct {
+		path   string
+		found  bool
+		params map[string]string
+	}{
+		{"/news", true, nil},
+		{"/chat/sessions", true, nil},
+		{"/chat/sessions/abc123", true, map[string]string{"id": "abc123"}},
+		{"/chat/sessions/", false, nil},
+		{"/chat/sessions/abc123/turns", false, nil},
+		{"/chat/session/abc123", false, nil},
+		{"/missing", false, nil},
+	}
+	for _, c := range cases {
+		item, params, found := doc.lookup(c.path)
+		if found != c.found {
+			t.Errorf("%s: got found %v, want %v", c.path, found, c.found)
+			continue
+		}
+		if found && item == nil {
+			t.Errorf("%s: found no path item", c.path)
+		}
+		if len(params) != 0 || len(c.params) != 0 {
+			if !reflect.DeepEqual(params, c.params) {
+				t.Errorf("%s: got params %v, want %v", c.path, params, c.params)
+			}
+		}
+	}
+}
+
+func TestCheckParameter(t *testing.T) {
+	days := Query("lookback_days", Integer(1, 365), false, "")
+	cases := []struct {
+		param Parameter
+		value string
+		valid bool
+	}{
+		{days, "7", true},
+		{days, "1", true},
+		{days, "365", true},
+		{days, "0", false},
+		{days, "366", false},
+		{days, "1.5", false},
+		{days, "seven", false},
+		{Query("score", Number(), false, ""), "0.25", true},
+		{Query("full_text", Boolean(), false, ""), "true", true},
+		{Query("full_text", Boolean(), false, ""), "yes", false},
+		{Query("mode", String("stock", "index"), false, ""), "index", true},
+		{Query("mode", String("stock", "index"), false, ""), "bond", false},
+		{Query("ticker", String(), true, ""), "AAPL", true},
+	}
+	for _, c := range cases {
+		err := checkParameter(c.param, c.value)
+		if c.valid && err != nil {
+			t.Errorf("%s=%q: unexpected error %v", c.param.Name, c.value, err)
+		}
+		if !c.valid {
+			if err == nil {
+				t.Errorf("%s=%q: got no error", c.param.Name, c.value)
+			} else if err.Status != http.StatusBadRequest {
+				t.Errorf("%s=%q: got status %d, want %d", c.param.Name, c.value, err.Status, http.StatusBadRequest)
+			}
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	doc := testDocument()
+	cases := []struct {
+		name        string
+		method      string
+		target      string
+		contentType string
+		body        string
+		noAuth      bool
+		status      int
+	}{
+		{name: "valid query", method: "GET", target: "/news?ticker=AAPL&lookback_days=30&full_text=true&mode=stock"},
+		{name: "missing required parameter", method: "GET", target: "/news?lookback_days=30", status: http.StatusBadRequest},
+		{name: "empty required parameter", method: "GET", target: "/news?ticker=", status: http.StatusBadRequest},
+		{name: "integer below minimum", method: "GET", target: "/news?ticker=AAPL&lookback_days=0", status: http.StatusBadRequest},
+		{name: "integer above maximum", method: "GET", target: "/news?ticker=AAPL&lookback_days=400", status: http.StatusBadRequest},
+		{name: "value outside enum", method: "GET", target: "/news?ticker=AAPL&mode=bond", status: http.StatusBadRequest},
+		{name: "missing bearer token", method: "GET", target: "/news?ticker=AAPL", noAuth: true, status: http.StatusUnauthorized},
+		{name: "unknown path", method: "GET", target: "/quotes?ticker=AAPL", status: http.StatusNotFound},
+		{name: "method not allowed", method: "POST", target: "/news?ticker=AAPL", status: http.StatusMethodNotAllowed},
+		{name: "preflight", method: "OPTIONS", target: "/news"},
+		{name: "session path", method: "GET", target: "/chat/sessions/abc123"},
+		{name: "session delete", method: "DELETE", target: "/chat/sessions/abc123"},
+		{name: "session path without id", method: "GET", target: "/chat/sessions/", status: http.StatusNotFound},
+		{name: "optional body left out", method: "POST", target: "/chat/sessions", contentType: "text/plain"},
+		{name: "valid JSON body", method: "POST", target: "/chat", contentType: "application/json; charset=utf-8", body: `{"title": "apple", "turns": 2}`},
+		{name: "missing required body", method: "POST", target: "/chat", contentType: "application/json", status: http.StatusBadRequest},
+		{name: "unsupported media type", method: "POST", target: "/chat", contentType: "text/plain", body: "apple", status: http.StatusUnsupportedMediaType},
+		{name: "malformed JSON", method: "POST", target: "/chat", contentType: "application/json", body: `{"title":`, status: http.StatusBadRequest},
+		{name: "wrong field type", method: "POST", target: "/chat", contentType: "application/json", body: `{"turns": "two"}`, status: http.StatusBadRequest},
+		{name: "body over the operation limit", method: "POST", target: "/chat", contentType: "application/json", body: `{"title": "` + strings.Repeat("a", 64) + `"}`, status: http.StatusRequestEntityTooLarge},
+		{name: "form body", method: "POST", target: "/ingestor", contentType: "application/x-www-form-urlencoded", body: "info=" + strings.Repeat("a", 2<<20)},
+	}
+	for _, c := range cases {
+		r := httptest.NewRequest(c.method, c.target, strings.NewReader(c.body))
+		if c.contentType != "" {
+			r.Header.Set("Content-Type", c.contentType)
+		}
+		if !c.noAuth {
+			r.Header.Set("Authorization", "Bearer hash")
+		}
+		err := doc.Validate(r)
+		switch {
+		case c.status == 0 && err != nil:
+			t.Errorf("%s: unexpected error %v", c.name, err)
+		case c.status != 0 && err == nil:
+			t.Errorf("%s: got no error, want status %d", c.name, c.status)
+		case c.status != 0 && err.Status != c.status:
+			t.Errorf("%s: got status %d (%v), want %d", c.name, err.Status, err, c.status)
+		}
+	}
+}
+
+func TestValidateKeepsBody(t *testing.T) {
+	body := `{"title": "apple", "turns": 2}`
+	r := httptest.NewRequest("POST", "/chat", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	if err := testDocument().Validate(r); err != nil {
+		t.Fatal(err)
+	}
+	got, err := io.ReadAll(r.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != body {
+		t.Errorf("got body %q, want %q", got, body)
+	}
+}
+
+func TestMaxBytesDefaults(t *testing.T) {
+	cases := []struct {
+		body *RequestBody
+		want int64
+	}{
+		{&RequestBody{Content: JSON(String())}, DefaultMaxJSONBytes},
+		{&RequestBody{Content: Form(String())}, DefaultMaxFormBytes},
+		{&RequestBody{Content: JSON(String()), MaxBytes: 8 << 20}, 8 << 20},
+	}
+	for _, c := range cases {
+		if got := c.body.maxBytes(); got != c.want {
+			t.Errorf("got %d, want %d", got, c.want)
+		}
+	}
+	if DefaultMaxFormBytes < 32<<20 {
+		t.Errorf("form limit %d is below the ingestor's 32 MB", DefaultMaxFormBytes)
+	}
+}
+
+func TestMiddlewareRejects(t *testing.T) {
+	handler := testDocument().Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	cases := []struct {
+		target string
+		status int
+	}{
+		{"/chat/sessions/abc123", http.StatusNoContent},
+		{"/news?ticker=AAPL", http.StatusUnauthorized},
+	}
+	for _, c := range cases {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", c.target, nil))
+		if w.Code != c.status {
+			t.Errorf("%s: got status %d, want %d", c.target, w.Code, c.status)
+		}
+		if c.status >= 400 && !strings.Contains(w.Body.String(), `"error"`) {
+			t.Errorf("%s: got body %q, want a JSON error", c.target, w.Body.String())
+		}
+	}
+}
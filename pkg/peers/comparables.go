@@ -0,0 +1,175 @@
+package peers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Metrics are the valuation and fundamental figures compared across peers.
+// Ratios are plain numbers, margins, growth and returns are percentages.
+// Missing values are nil so they never skew a ranking
+type Metrics struct {
+	Ticker          string   `json:"ticker"`
+	Name            string   `json:"name,omitempty"`
+	MarketCap       *float64 `json:"market_cap,omitempty"`
+	PE              *float64 `json:"pe,omitempty"`
+	EVToEBITDA      *float64 `json:"ev_to_ebitda,omitempty"`
+	PS              *float64 `json:"ps,omitempty"`
+	PB              *float64 `json:"pb,omitempty"`
+	GrossMargin     *float64 `json:"gross_margin,omitempty"`
+	OperatingMargin *float64 `json:"operating_margin,omitempty"`
+	NetMargin       *float64 `json:"net_margin,omitempty"`
+	RevenueGrowth   *float64 `json:"revenue_growth,omitempty"`
+	ROE             *float64 `json:"roe,omitempty"`
+	ROA             *float64 `json:"roa,omitempty"`
+}
+
+// metric names in table order
+var metricNames = []string{"market_cap", "pe", "ev_to_ebitda", "ps", "pb", "gross_margin", "operating_margin", "net_margin", "revenue_growth", "roe", "roa"}
+
+func (m *Metrics) value(name string) *float64 {
+	switch name {
+	case "market_cap":
+		return m.MarketCap
+	case "pe":
+		return m.PE
+	case "ev_to_ebitda":
+		return m.EVToEBITDA
+	case "ps":
+		return m.PS
+	case "pb":
+		return m.PB
+	case "gross_margin":
+		return m.GrossMargin
+	case "operating_margin":
+		return m.OperatingMargin
+	case "net_margin":
+		return m.NetMargin
+	case "revenue_growth":
+		return m.RevenueGrowth
+	case "roe":
+		return m.ROE
+	case "roa":
+		return m.ROA
+	}
+	return nil
+}
+
+// Row is one company in the comparables table with the percentile rank of
+// each metric within the group
+type Row struct {
+	Metrics
+	Percentiles map[string]float64 `json:"percentiles"`
+}
+
+// Table is the comparables table for a subject and its peers
+type Table struct {
+	Subject string             `json:"subject"`
+	Peers   []string           `json:"peers"`
+	Rows    []Row              `json:"rows"`
+	Medians map[string]float64 `json:"medians"`
+}
+
+// BuildTable ranks the subject and its peers on every metric
+func BuildTable(subject string, metrics []Metrics) Table {
+	table := Table{
+		Subject: strings.ToUpper(subject),
+		Medians: make(map[string]float64),
+	}
+	for _, m := range metrics {
+		if !strings.EqualFold(m.Ticker, subject) {
+			table.Peers = append(table.Peers, m.Ticker)
+		}
+		table.Rows = append(table.Rows, Row{Metrics: m, Percentiles: make(map[string]float64)})
+	}
+
+	for _, name := range metricNames {
+		var values []float64
+		for i := range metrics {
+			if v := metrics[i].value(name); v != nil {
+				values = append(values, *v)
+			}
+		}
+		if len(values) == 0 {
+			continue
+		}
+		table.Medians[name] = median(values)
+		for i := range table.Rows {
+			if v := table.Rows[i].value(name); v != nil {
+				table.Rows[i].Percentiles[name] = PercentileRank(values, *v)
+			}
+		}
+	}
+	return table
+}
+
+// PercentileRank is the share of values below v, counting ties as half, in percent
+func PercentileRank(values []float64, v float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	var below, equal float64
+	for _, x := range values {
+		if x < v {
+			below++
+		} else if x == v {
+			equal++
+		}
+	}
+	return (below + equal/2) / float64(len(values)) * 100
+}
+
+func median(values []float64) float64 {
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+// Summary renders the table as plain text for prompt templates, valuation
+// multiple percentiles are noted as lower meaning cheaper
+func (t Table) Summary() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "PEER COMPARISON FOR %s AGAINST %s.", t.Subject, strings.Join(t.Peers, ", "))
+	for _, row := range t.Rows {
+		fmt.Fprintf(&b, " %s:", row.Ticker)
+		for _, name := range metricNames {
+			v := row.value(name)
+			if v == nil {
+				continue
+			}
+			if name == "market_cap" {
+				fmt.Fprintf(&b, " %s %.0f (%.0fth percentile),", labels[name], *v, row.Percentiles[name])
+			} else {
+				fmt.Fprintf(&b, " %s %.2f (%.0fth percentile),", labels[name], *v, row.Percentiles[name])
+			}
+		}
+	}
+	b.WriteString(" PEER MEDIANS:")
+	for _, name := range metricNames {
+		if m, ok := t.Medians[name]; ok {
+			fmt.Fprintf(&b, " %s %.2f,", labels[name], m)
+		}
+	}
+	b.WriteString(" Lower P/E, EV/EBITDA, P/S and P/B percentiles mean cheaper relative to peers.")
+	return b.String()
+}
+
+var labels = map[string]string{
+	"market_cap":       "MARKET CAP",
+	"pe":               "P/E",
+	"ev_to_ebitda":     "EV/EBITDA",
+	"ps":               "P/S",
+	"pb":               "P/B",
+	"gross_margin":     "GROSS MARGIN %",
+	"operating_margin": "OPERATING MARGIN %",
+	"net_margin":       "NET MARGIN %",
+	"revenue_growth":   "REVENUE GROWTH %",
+	"roe":              "ROE %",
+	"roa":              "ROA %",
+}
@@ -0,0 +1,75 @@
+package peers
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+
+	"fineas/pkg/registry"
+)
+
+// Overrides maps a ticker to a hand picked peer set
+type Overrides map[string][]string
+
+// LoadOverrides reads peer overrides from a JSON file, a missing file means no overrides
+func LoadOverrides(path string) (Overrides, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return Overrides{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var raw Overrides
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	overrides := make(Overrides, len(raw))
+	for ticker, peers := range raw {
+		overrides[strings.ToUpper(ticker)] = peers
+	}
+	return overrides, nil
+}
+
+// Subject is the company peers are selected for
+type Subject struct {
+	Ticker  string
+	SICCode string
+	Sector  string
+}
+
+// Select picks up to max peers for the subject. Overrides win, then tickers
+// with the same four digit SIC code, then the same two digit major group,
+// then the same sector
+func Select(reg *registry.Registry, overrides Overrides, subject Subject, max int) []string {
+	ticker := strings.ToUpper(subject.Ticker)
+	if peers, ok := overrides[ticker]; ok {
+		if len(peers) > max {
+			peers = peers[:max]
+		}
+		return peers
+	}
+
+	seen := map[string]bool{ticker: true}
+	var selected []string
+	add := func(candidates []registry.Ticker) {
+		for _, c := range candidates {
+			value := strings.ToUpper(c.Value)
+			if len(selected) >= max || seen[value] || strings.Contains(value, ":") {
+				continue
+			}
+			seen[value] = true
+			selected = append(selected, value)
+		}
+	}
+
+	if len(subject.SICCode) >= 4 {
+		add(reg.BySIC(subject.SICCode[:4]))
+	}
+	if len(subject.SICCode) >= 2 {
+		add(reg.BySIC(subject.SICCode[:2]))
+	}
+	add(reg.BySector(subject.Sector))
+	return selected
+}
@@ -0,0 +1,148 @@
+package profile
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Enrichment is the document an enrichment source returns for a ticker
+type Enrichment struct {
+	Source       string      `json:"source"`
+	AsOf         string      `json:"as_of,omitempty"`
+	YearFounded  string      `json:"year_founded,omitempty"`
+	Headquarters string      `json:"headquarters,omitempty"`
+	Sector       string      `json:"sector,omitempty"`
+	Executives   []Executive `json:"executives,omitempty"`
+}
+
+// Enricher supplies profile fields that ticker details do not have. A nil
+// enrichment with no error means the source knows nothing about the ticker
+type Enricher interface {
+	Enrich(ctx context.Context, ticker string) (*Enrichment, error)
+}
+
+// ErrInvalidTicker is returned by the enrichers for tickers that could name
+// other files or URLs
+var ErrInvalidTicker = errors.New("invalid ticker")
+
+// stock tickers such as AAPL or BRK.B
+var tickerPattern = regexp.MustCompile(`^[A-Z0-9][A-Z0-9.\-]{0,14}$`)
+
+// Apply merges enrichment into the profile without overwriting fields that
+// already have a value
+func (p *CompanyProfile) Apply(e *Enrichment) {
+	if e == nil {
+		return
+	}
+	source := e.Source
+	if source == "" {
+		source = "enrichment"
+	}
+
+	if p.YearFounded == nil {
+		p.YearFounded = newField(e.YearFounded, source, e.AsOf)
+	}
+	if p.Headquarters == nil {
+		p.Headquarters = newField(e.Headquarters, source, e.AsOf)
+	}
+	if p.Sector == nil {
+		p.Sector = newField(e.Sector, source, e.AsOf)
+	}
+	for _, exec := range e.Executives {
+		if exec.Name == "" {
+			continue
+		}
+		if exec.Source == "" {
+			exec.Source = source
+		}
+		p.Executives = append(p.Executives, exec)
+	}
+}
+
+// FileEnricher reads enrichment documents from <Dir>/<TICKER>.json
+type FileEnricher struct {
+	Dir string
+}
+
+func (f FileEnricher) Enrich(ctx context.Context, ticker string) (*Enrichment, error) {
+	if !tickerPattern.MatchString(strings.ToUpper(ticker)) {
+		return nil, ErrInvalidTicker
+	}
+	data, err := os.ReadFile(filepath.Join(f.Dir, strings.ToUpper(ticker)+".json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var e Enrichment
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// HTTPEnricher reads enrichment documents from a JSON endpoint, the URL
+// template may contain {ticker} in its path or query
+type HTTPEnricher struct {
+	URLTemplate string
+	Client      *http.Client
+}
+
+func NewHTTPEnricher(urlTemplate string) *HTTPEnricher {
+	return &HTTPEnricher{
+		URLTemplate: urlTemplate,
+		Client:      &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+func (h *HTTPEnricher) Enrich(ctx context.Context, ticker string) (*Enrichment, error) {
+	if !tickerPattern.MatchString(strings.ToUpper(ticker)) {
+		return nil, ErrInvalidTicker
+	}
+	reqURL := expandURLTemplate(h.URLTemplate, ticker)
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := h.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("enrichment source returned status %d", resp.StatusCode)
+	}
+
+	var e Enrichment
+	if err := json.NewDecoder(resp.Body).Decode(&e); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// substitutes the ticker into a URL template, escaped for the path or the
+// query depending on where {ticker} appears
+func expandURLTemplate(urlTemplate string, ticker string) string {
+	path, query, found := strings.Cut(urlTemplate, "?")
+	path = strings.ReplaceAll(path, "{ticker}", url.PathEscape(ticker))
+	if !found {
+		return path
+	}
+	return path + "?" + strings.ReplaceAll(query, "{ticker}", url.QueryEscape(ticker))
+}
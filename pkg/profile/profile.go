@@ -0,0 +1,169 @@
+package profile
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/polygon-io/client-go/rest/models"
+)
+
+// provenance of fields populated from polygon
+const SourceTickerDetails = "polygon ticker details"
+
+// Field is a single profile value together with where it came from
+type Field struct {
+	Value  string `json:"value" bson:"value"`
+	Source string `json:"source" bson:"source"`
+	AsOf   string `json:"as_of,omitempty" bson:"as_of,omitempty"`
+}
+
+// Executive is a company officer supplied by an enrichment source
+type Executive struct {
+	Name   string `json:"name" bson:"name"`
+	Title  string `json:"title" bson:"title"`
+	Since  string `json:"since,omitempty" bson:"since,omitempty"`
+	Source string `json:"source" bson:"source"`
+}
+
+// CompanyProfile is a structured company description. Fields that no source
+// could supply are nil so they are never passed to the LLM
+type CompanyProfile struct {
+	Ticker            string      `json:"ticker" bson:"ticker"`
+	Name              *Field      `json:"name,omitempty" bson:"name,omitempty"`
+	Description       *Field      `json:"description,omitempty" bson:"description,omitempty"`
+	Address           *Field      `json:"address,omitempty" bson:"address,omitempty"`
+	Phone             *Field      `json:"phone,omitempty" bson:"phone,omitempty"`
+	Headquarters      *Field      `json:"headquarters,omitempty" bson:"headquarters,omitempty"`
+	YearFounded       *Field      `json:"year_founded,omitempty" bson:"year_founded,omitempty"`
+	SICCode           *Field      `json:"sic_code,omitempty" bson:"sic_code,omitempty"`
+	SICDescription    *Field      `json:"sic_description,omitempty" bson:"sic_description,omitempty"`
+	Sector            *Field      `json:"sector,omitempty" bson:"sector,omitempty"`
+	Homepage          *Field      `json:"homepage,omitempty" bson:"homepage,omitempty"`
+	PrimaryExchange   *Field      `json:"primary_exchange,omitempty" bson:"primary_exchange,omitempty"`
+	ListDate          *Field      `json:"list_date,omitempty" bson:"list_date,omitempty"`
+	SharesOutstanding *Field      `json:"share_class_shares_outstanding,omitempty" bson:"share_class_shares_outstanding,omitempty"`
+	TotalEmployees    *Field      `json:"total_employees,omitempty" bson:"total_employees,omitempty"`
+	MarketCap         *Field      `json:"market_cap,omitempty" bson:"market_cap,omitempty"`
+	LogoURL           *Field      `json:"logo_url,omitempty" bson:"logo_url,omitempty"`
+	IconURL           *Field      `json:"icon_url,omitempty" bson:"icon_url,omitempty"`
+	Executives        []Executive `json:"executives,omitempty" bson:"executives,omitempty"`
+}
+
+// returns a field only when the value is present
+func newField(value string, source string, asOf string) *Field {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+	return &Field{Value: value, Source: source, AsOf: asOf}
+}
+
+// FromTickerDetails populates a profile from polygon ticker details
+func FromTickerDetails(t models.Ticker) *CompanyProfile {
+	asOf := time.Now().Format("2006-01-02")
+	src := SourceTickerDetails
+
+	p := &CompanyProfile{
+		Ticker:          t.Ticker,
+		Name:            newField(t.Name, src, asOf),
+		Description:     newField(t.Description, src, asOf),
+		Phone:           newField(t.PhoneNumber, src, asOf),
+		SICCode:         newField(t.SICCode, src, asOf),
+		SICDescription:  newField(t.SICDescription, src, asOf),
+		Homepage:        newField(t.HomepageURL, src, asOf),
+		PrimaryExchange: newField(t.PrimaryExchange, src, asOf),
+		LogoURL:         newField(t.Branding.LogoURL, src, asOf),
+		IconURL:         newField(t.Branding.IconURL, src, asOf),
+	}
+
+	a := t.Address
+	var parts []string
+	for _, part := range []string{a.Address1, a.Address2, a.City, a.State, a.PostalCode} {
+		if strings.TrimSpace(part) != "" {
+			parts = append(parts, strings.TrimSpace(part))
+		}
+	}
+	p.Address = newField(strings.Join(parts, ", "), src, asOf)
+	if a.City != "" && a.State != "" {
+		p.Headquarters = newField(a.City+", "+a.State, src, asOf)
+	}
+
+	if listDate := time.Time(t.ListDate); !listDate.IsZero() {
+		p.ListDate = newField(listDate.Format("2006-01-02"), src, asOf)
+	}
+	if t.ShareClassSharesOutstanding > 0 {
+		p.SharesOutstanding = newField(fmt.Sprint(t.ShareClassSharesOutstanding), src, asOf)
+	}
+	if t.TotalEmployees > 0 {
+		p.TotalEmployees = newField(fmt.Sprint(t.TotalEmployees), src, asOf)
+	}
+	if t.MarketCap > 0 {
+		//market cap is in notation 2.99437134256e+12
+		p.MarketCap = newField(fmt.Sprintf("%.0f", t.MarketCap), src, asOf)
+	}
+	return p
+}
+
+// Facts lists every populated field as "LABEL: value (source: ...)" so the
+// LLM only sees what is actually known
+func (p *CompanyProfile) Facts() []string {
+	fields := []struct {
+		label string
+		field *Field
+	}{
+		{"NAME", p.Name},
+		{"DESCRIPTION", p.Description},
+		{"HEADQUARTERS", p.Headquarters},
+		{"ADDRESS", p.Address},
+		{"PHONE", p.Phone},
+		{"YEAR FOUNDED", p.YearFounded},
+		{"SECTOR", p.Sector},
+		{"SIC CODE", p.SICCode},
+		{"SIC DESCRIPTION", p.SICDescription},
+		{"HOMEPAGE", p.Homepage},
+		{"PRIMARY EXCHANGE", p.PrimaryExchange},
+		{"LIST DATE", p.ListDate},
+		{"SHARE CLASS SHARES OUTSTANDING", p.SharesOutstanding},
+		{"TOTAL EMPLOYEES", p.TotalEmployees},
+		{"MARKET CAP", p.MarketCap},
+	}
+
+	var facts []string
+	for _, f := range fields {
+		if f.field != nil {
+			facts = append(facts, fmt.Sprintf("%s: %s (source: %s)", f.label, f.field.Value, f.field.Source))
+		}
+	}
+	for _, e := range p.Executives {
+		facts = append(facts, fmt.Sprintf("EXECUTIVE: %s, %s (source: %s)", e.Name, e.Title, e.Source))
+	}
+	return facts
+}
+
+// Missing lists the labels of fields no source could supply
+func (p *CompanyProfile) Missing() []string {
+	var missing []string
+	if p.YearFounded == nil {
+		missing = append(missing, "YEAR FOUNDED")
+	}
+	if p.Headquarters == nil {
+		missing = append(missing, "HEADQUARTERS")
+	}
+	if p.Sector == nil && p.SICDescription == nil {
+		missing = append(missing, "SECTOR")
+	}
+	if len(p.Executives) == 0 {
+		missing = append(missing, "EXECUTIVES")
+	}
+	return missing
+}
+
+// Summary renders the known facts and the missing fields for prompt templates
+func (p *CompanyProfile) Summary() string {
+	summary := "COMPANY PROFILE: " + strings.Join(p.Facts(), "; ")
+	if missing := p.Missing(); len(missing) > 0 {
+		summary += ". NOT AVAILABLE: " + strings.Join(missing, ", ")
+	}
+	return summary
+}
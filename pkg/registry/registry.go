@@ -0,0 +1,125 @@
+package registry
+
+import (
+	"encoding/json"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// DefaultPath is where the scripts and services read the tracked tickers list
+const DefaultPath = "../../utils/data/tickerslist.json"
+
+// Ticker is an entry of the tracked tickers list. SICCode and Sector are
+// optional and only needed for peer selection, scripts/populatedata -enrich
+// fills them from Polygon ticker details
+type Ticker struct {
+	Value   string `json:"value"`
+	Label   string `json:"label"`
+	SICCode string `json:"sic_code,omitempty"`
+	Sector  string `json:"sector,omitempty"`
+}
+
+// Registry is the tracked tickers list indexed by ticker
+type Registry struct {
+	Tickers []Ticker
+	byValue map[string]int
+}
+
+// Load reads the tickers list from a JSON file
+func Load(path string) (*Registry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var tickers []Ticker
+	if err := json.Unmarshal(data, &tickers); err != nil {
+		return nil, err
+	}
+	return New(tickers), nil
+}
+
+// New builds a registry from a list of tickers
+func New(tickers []Ticker) *Registry {
+	r := &Registry{Tickers: tickers, byValue: make(map[string]int, len(tickers))}
+	for i, t := range tickers {
+		r.byValue[strings.ToUpper(t.Value)] = i
+		if t.Sector == "" {
+			r.Tickers[i].Sector = SICDivision(t.SICCode)
+		}
+	}
+	return r
+}
+
+// SIC divisions by the first two digits of a SIC code
+var sicDivisions = []struct {
+	last     int
+	division string
+}{
+	{9, "Agriculture, Forestry and Fishing"},
+	{14, "Mining"},
+	{17, "Construction"},
+	{39, "Manufacturing"},
+	{49, "Transportation, Communications, Electric, Gas and Sanitary Services"},
+	{51, "Wholesale Trade"},
+	{59, "Retail Trade"},
+	{67, "Finance, Insurance and Real Estate"},
+	{89, "Services"},
+	{99, "Public Administration"},
+}
+
+// SICDivision is the SIC division of a code, the sector used when a ticker
+// has no other. It is empty for a missing or malformed code
+func SICDivision(code string) string {
+	if len(code) < 2 {
+		return ""
+	}
+	group, err := strconv.Atoi(code[:2])
+	if err != nil || group < 1 {
+		return ""
+	}
+	for _, d := range sicDivisions {
+		if group <= d.last {
+			return d.division
+		}
+	}
+	return ""
+}
+
+// Lookup finds a ticker by its symbol
+func (r *Registry) Lookup(ticker string) (Ticker, bool) {
+	i, ok := r.byValue[strings.ToUpper(ticker)]
+	if !ok {
+		return Ticker{}, false
+	}
+	return r.Tickers[i], true
+}
+
+// BySIC returns the tickers whose SIC code starts with the given prefix, a
+// four digit code matches the industry and a two digit code the major group
+func (r *Registry) BySIC(prefix string) []Ticker {
+	var matches []Ticker
+	if prefix == "" {
+		return matches
+	}
+	for _, t := range r.Tickers {
+		if strings.HasPrefix(t.SICCode, prefix) {
+			matches = append(matches, t)
+		}
+	}
+	return matches
+}
+
+// BySector returns the tickers in the given sector
+func (r *Registry) BySector(sector string) []Ticker {
+	var matches []Ticker
+	if sector == "" {
+		return matches
+	}
+	for _, t := range r.Tickers {
+		if strings.EqualFold(t.Sector, sector) {
+			matches = append(matches, t)
+		}
+	}
+	return matches
+}
@@ -0,0 +1,148 @@
+package retrieval
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PineconeRerankURL is the hosted cross-encoder endpoint of Pinecone inference
+const PineconeRerankURL = "https://api.pinecone.io/rerank"
+
+// CrossEncoder reranks with a hosted cross-encoder speaking the Pinecone
+// rerank API, bge-reranker-v2-m3 by default
+type CrossEncoder struct {
+	URL    string
+	APIKey string
+	Model  string
+	HTTP   *http.Client
+}
+
+// NewCrossEncoder returns a Pinecone hosted cross-encoder reranker
+func NewCrossEncoder(apiKey string, model string) *CrossEncoder {
+	if model == "" {
+		model = "bge-reranker-v2-m3"
+	}
+	return &CrossEncoder{URL: PineconeRerankURL, APIKey: apiKey, Model: model, HTTP: &http.Client{Timeout: 20 * time.Second}}
+}
+
+type rerankDocument struct {
+	Text string `json:"text"`
+}
+
+type rerankRequest struct {
+	Model           string           `json:"model"`
+	Query           string           `json:"query"`
+	Documents       []rerankDocument `json:"documents"`
+	TopN            int              `json:"top_n"`
+	ReturnDocuments bool             `json:"return_documents"`
+}
+
+type rerankResponse struct {
+	Data []struct {
+		Index int     `json:"index"`
+		Score float64 `json:"score"`
+	} `json:"data"`
+}
+
+func (c *CrossEncoder) Rerank(ctx context.Context, query string, candidates []Candidate) ([]Candidate, error) {
+	body := rerankRequest{Model: c.Model, Query: query, TopN: len(candidates)}
+	for _, candidate := range candidates {
+		body.Documents = append(body.Documents, rerankDocument{Text: candidate.Text})
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Api-Key", c.APIKey)
+	req.Header.Set("X-Pinecone-API-Version", "2024-10")
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("rerank returned %s", resp.Status)
+	}
+	var decoded rerankResponse
+	if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil {
+		return nil, err
+	}
+
+	reranked := make([]Candidate, 0, len(decoded.Data))
+	for _, d := range decoded.Data {
+		if d.Index < 0 || d.Index >= len(candidates) {
+			continue
+		}
+		c := candidates[d.Index]
+		c.Score = d.Score
+		reranked = append(reranked, c)
+	}
+	sort.SliceStable(reranked, func(i, j int) bool { return reranked[i].Score > reranked[j].Score })
+	return reranked, nil
+}
+
+// LLMReranker asks a language model to grade each candidate from 0 to 10.
+// Scores are scaled to 0..1 so thresholds match the cross-encoder's
+type LLMReranker struct {
+	Complete func(ctx context.Context, prompt string) (string, error)
+	// MaxChars truncates each candidate in the prompt
+	MaxChars int
+}
+
+var gradeLine = regexp.MustCompile(`(?m)^\s*\[?(\d+)\]?\s*[:=-]\s*(\d+(?:\.\d+)?)`)
+
+func (l *LLMReranker) Rerank(ctx context.Context, query string, candidates []Candidate) ([]Candidate, error) {
+	maxChars := l.MaxChars
+	if maxChars <= 0 {
+		maxChars = 800
+	}
+	var b strings.Builder
+	b.WriteString("Grade how useful each passage is for answering the question, from 0 (irrelevant) to 10 (answers it). ")
+	b.WriteString("Respond only with one line per passage in the form <number>: <grade>.\n\nQUESTION:\n")
+	b.WriteString(query)
+	b.WriteString("\n\nPASSAGES:\n")
+	for i, c := range candidates {
+		text := c.Text
+		if len(text) > maxChars {
+			text = text[:maxChars]
+		}
+		fmt.Fprintf(&b, "[%d] %s\n", i+1, strings.Join(strings.Fields(text), " "))
+	}
+
+	response, err := l.Complete(ctx, b.String())
+	if err != nil {
+		return nil, err
+	}
+	grades := make(map[int]float64)
+	for _, match := range gradeLine.FindAllStringSubmatch(response, -1) {
+		n, _ := strconv.Atoi(match[1])
+		grade, _ := strconv.ParseFloat(match[2], 64)
+		if n >= 1 && n <= len(candidates) {
+			grades[n-1] = grade / 10
+		}
+	}
+	if len(grades) == 0 {
+		return nil, fmt.Errorf("no grades in reranker response")
+	}
+
+	reranked := make([]Candidate, len(candidates))
+	copy(reranked, candidates)
+	for i := range reranked {
+		reranked[i].Score = grades[i]
+	}
+	sort.SliceStable(reranked, func(i, j int) bool { return reranked[i].Score > reranked[j].Score })
+	return reranked, nil
+}
@@ -0,0 +1,216 @@
+package retrieval
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Candidate is a retrieved chunk of context. Score is the score of the stage
+// that produced it: similarity for dense, BM25 for keyword, the fused RRF
+// score after Fuse and the reranker's relevance after reranking
+type Candidate struct {
+	ID       string                 `json:"id"`
+	Text     string                 `json:"text"`
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+	Score    float64                `json:"score"`
+	// Retrievers names the retrievers that returned the candidate
+	Retrievers []string `json:"retrievers,omitempty"`
+}
+
+// Retriever returns candidates for a query, best first
+type Retriever interface {
+	Name() string
+	Retrieve(ctx context.Context, query string, limit int) ([]Candidate, error)
+}
+
+// RetrieverFunc adapts a function to a Retriever
+type RetrieverFunc struct {
+	Label string
+	Func  func(ctx context.Context, query string, limit int) ([]Candidate, error)
+}
+
+func (f RetrieverFunc) Name() string {
+	return f.Label
+}
+
+func (f RetrieverFunc) Retrieve(ctx context.Context, query string, limit int) ([]Candidate, error) {
+	return f.Func(ctx, query, limit)
+}
+
+// Reranker reorders candidates by relevance to the query and sets their Score
+type Reranker interface {
+	Rerank(ctx context.Context, query string, candidates []Candidate) ([]Candidate, error)
+}
+
+// Options tune hybrid retrieval
+type Options struct {
+	// TopK is the number of candidates returned
+	TopK int
+	// CandidatesK is the number each retriever returns before fusion
+	CandidatesK int
+	// RRFK is the rank constant of reciprocal rank fusion
+	RRFK float64
+	// MinRetrieverScores drops a retriever's candidates scoring below the
+	// threshold before fusion, keyed by retriever name
+	MinRetrieverScores map[string]float64
+	// MinRerankScore drops reranked candidates scoring below the threshold
+	MinRerankScore float64
+}
+
+// DefaultOptions returns six fused candidates from twelve per retriever
+func DefaultOptions() Options {
+	return Options{TopK: 6, CandidatesK: 12, RRFK: 60}
+}
+
+// Hybrid runs several retrievers concurrently, fuses their rankings and
+// optionally reranks the fused list
+type Hybrid struct {
+	Retrievers []Retriever
+	Reranker   Reranker
+	Options    Options
+}
+
+// PartialError reports retrievers that failed while others succeeded
+type PartialError struct {
+	Errs []error
+}
+
+func (e *PartialError) Error() string {
+	return "some retrievers failed: " + errors.Join(e.Errs...).Error()
+}
+
+// Retrieve returns the TopK fused candidates. A failing retriever is skipped
+// as long as another one succeeds, the candidates are then returned with a
+// *PartialError. A failing reranker keeps the fused order
+func (h *Hybrid) Retrieve(ctx context.Context, query string) ([]Candidate, error) {
+	opts := h.Options
+	if opts.TopK <= 0 {
+		opts.TopK = DefaultOptions().TopK
+	}
+	if opts.CandidatesK < opts.TopK {
+		opts.CandidatesK = 2 * opts.TopK
+	}
+
+	lists := make([][]Candidate, len(h.Retrievers))
+	errs := make([]error, len(h.Retrievers))
+	var wg sync.WaitGroup
+	for i, r := range h.Retrievers {
+		wg.Add(1)
+		go func(i int, r Retriever) {
+			defer wg.Done()
+			candidates, err := r.Retrieve(ctx, query, opts.CandidatesK)
+			if err != nil {
+				errs[i] = fmt.Errorf("%s: %w", r.Name(), err)
+				return
+			}
+			min, ok := opts.MinRetrieverScores[r.Name()]
+			for _, c := range candidates {
+				if ok && c.Score < min {
+					continue
+				}
+				c.Retrievers = []string{r.Name()}
+				lists[i] = append(lists[i], c)
+			}
+		}(i, r)
+	}
+	wg.Wait()
+
+	var failed []error
+	for _, err := range errs {
+		if err != nil {
+			failed = append(failed, err)
+		}
+	}
+	if len(failed) > 0 && len(failed) == len(h.Retrievers) {
+		return nil, errors.Join(failed...)
+	}
+
+	fused := Fuse(opts.RRFK, lists...)
+	if h.Reranker != nil && len(fused) > 0 {
+		reranked, err := h.Reranker.Rerank(ctx, query, fused)
+		if err == nil {
+			fused = fused[:0]
+			for _, c := range reranked {
+				if c.Score >= opts.MinRerankScore {
+					fused = append(fused, c)
+				}
+			}
+		}
+	}
+	if len(fused) > opts.TopK {
+		fused = fused[:opts.TopK]
+	}
+	if len(failed) > 0 {
+		return fused, &PartialError{Errs: failed}
+	}
+	return fused, nil
+}
+
+// Fuse merges ranked lists with reciprocal rank fusion, scoring each
+// candidate by the sum of 1/(k+rank) over the lists that contain it
+func Fuse(k float64, lists ...[]Candidate) []Candidate {
+	if k <= 0 {
+		k = 60
+	}
+	byID := make(map[string]*Candidate)
+	var order []string
+	for _, list := range lists {
+		for rank, c := range list {
+			existing, ok := byID[c.ID]
+			if !ok {
+				copied := c
+				copied.Score = 0
+				copied.Retrievers = append([]string(nil), c.Retrievers...)
+				byID[c.ID] = &copied
+				order = append(order, c.ID)
+				existing = &copied
+			} else {
+				existing.Retrievers = append(existing.Retrievers, c.Retrievers...)
+				if existing.Text == "" {
+					existing.Text = c.Text
+				}
+				if existing.Metadata == nil {
+					existing.Metadata = c.Metadata
+				}
+			}
+			existing.Score += 1 / (k + float64(rank+1))
+		}
+	}
+	fused := make([]Candidate, 0, len(order))
+	for _, id := range order {
+		fused = append(fused, *byID[id])
+	}
+	sort.SliceStable(fused, func(i, j int) bool { return fused[i].Score > fused[j].Score })
+	return fused
+}
+
+// Interleave merges ranked lists by taking one candidate from each in turn,
+// so every list is represented, skipping ids already taken. It stops after
+// limit candidates, a limit of 0 keeps them all
+func Interleave(limit int, lists ...[]Candidate) []Candidate {
+	seen := make(map[string]bool)
+	var merged []Candidate
+	for rank := 0; ; rank++ {
+		remaining := false
+		for _, list := range lists {
+			if rank >= len(list) {
+				continue
+			}
+			remaining = true
+			if seen[list[rank].ID] {
+				continue
+			}
+			seen[list[rank].ID] = true
+			merged = append(merged, list[rank])
+			if limit > 0 && len(merged) == limit {
+				return merged
+			}
+		}
+		if !remaining {
+			return merged
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package retrieval
+
+import (
+	"context"
+	"errors"
+	"math"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// candidates with descending scores in the given order
+func ranked(ids ...string) []Candidate {
+	var candidates []Candidate
+	for i, id := range ids {
+		candidates = append(candidates, Candidate{ID: id, Text: "text of " + id, Score: float64(len(ids) - i)})
+	}
+	return candidates
+}
+
+func candidateIDs(candidates []Candidate) []string {
+	var ids []string
+	for _, c := range candidates {
+		ids = append(ids, c.ID)
+	}
+	return ids
+}
+
+func staticRetriever(name string, candidates []Candidate, err error) Retriever {
+	return RetrieverFunc{Label: name, Func: func(ctx context.Context, query string, limit int) ([]Candidate, error) {
+		if len(candidates) > limit {
+			candidates = candidates[:limit]
+		}
+		return candidates, err
+	}}
+}
+
+// reverses the candidates, scoring them by their new position
+type reverseReranker struct {
+	err error
+}
+
+func (r reverseReranker) Rerank(ctx context.Context, query string, candidates []Candidate) ([]Candidate, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	var reranked []Candidate
+	for i := len(candidates) - 1; i >= 0; i-- {
+		c := candidates[i]
+		c.Score = float64(i+1) / float64(len(candidates))
+		reranked = append(reranked, c)
+	}
+	return reranked, nil
+}
+
+func TestFuse(t *testing.T) {
+	cases := []struct {
+		name  string
+		k     float64
+		lists [][]Candidate
+		want  []string
+	}{
+		{"single list keeps its order", 60, [][]Candidate{ranked("a", "b", "c")}, []string{"a", "b", "c"}},
+		{"shared candidate ranks first", 60, [][]Candidate{ranked("a", "b", "c"), ranked("d", "b", "e")}, []string{"b", "a", "d", "c", "e"}},
+		{"ties keep first seen order", 60, [][]Candidate{ranked("a", "b"), ranked("c", "d")}, []string{"a", "c", "b", "d"}},
+		{"default rank constant", 0, [][]Candidate{ranked("a", "b", "c"), ranked("d", "b", "e")}, []string{"b", "a", "d", "c", "e"}},
+		{"no lists", 60, nil, nil},
+	}
+	for _, c := range cases {
+		got := candidateIDs(Fuse(c.k, c.lists...))
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: got %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestFuseScoresAndRetrievers(t *testing.T) {
+	dense := ranked("a", "b")
+	keyword := ranked("b")
+	for i := range dense {
+		dense[i].Retrievers = []string{"dense"}
+	}
+	keyword[0].Retrievers = []string{"keyword"}
+	keyword[0].Metadata = map[string]interface{}{"ticker": "AAPL"}
+
+	fused := Fuse(60, dense, keyword)
+	if fused[0].ID != "b" {
+		t.Fatalf("got %v, want b first", candidateIDs(fused))
+	}
+	if want := 1.0/62 + 1.0/61; math.Abs(fused[0].Score-want) > 1e-12 {
+		t.Errorf("got score %v, want %v", fused[0].Score, want)
+	}
+	if want := []string{"dense", "keyword"}; !reflect.DeepEqual(fused[0].Retrievers, want) {
+		t.Errorf("got retrievers %v, want %v", fused[0].Retrievers, want)
+	}
+	if fused[0].Metadata["ticker"] != "AAPL" {
+		t.Errorf("got metadata %v, want the keyword retriever's", fused[0].Metadata)
+	}
+	if dense[1].Score != 1 {
+		t.Errorf("fusion changed the input score to %v", dense[1].Score)
+	}
+}
+
+func TestInterleave(t *testing.T) {
+	cases := []struct {
+		name  string
+		limit int
+		lists [][]Candidate
+		want  []string
+	}{
+		{"takes one from each list in turn", 0, [][]Candidate{ranked("a", "b", "c"), ranked("d", "b", "e")}, []string{"a", "d", "b", "c", "e"}},
+		{"stops at the limit", 3, [][]Candidate{ranked("a", "b", "c"), ranked("d", "b", "e")}, []string{"a", "d", "b"}},
+		{"uneven lists", 0, [][]Candidate{ranked("a"), ranked("b", "c", "d")}, []string{"a", "b", "c", "d"}},
+		{"empty list", 0, [][]Candidate{nil, ranked("a", "b")}, []string{"a", "b"}},
+		{"no lists", 0, nil, nil},
+	}
+	for _, c := range cases {
+		got := candidateIDs(Interleave(c.limit, c.lists...))
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: got %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestHybridRetrieve(t *testing.T) {
+	failure := errors.New("unavailable")
+	// the dense candidate c scores below a 0.4 threshold
+	dense := []Candidate{{ID: "a", Score: 0.9}, {ID: "b", Score: 0.5}, {ID: "c", Score: 0.3}}
+	keyword := []Candidate{{ID: "c", Score: 12}, {ID: "d", Score: 8}}
+
+	cases := []struct {
+		name        string
+		retrievers  []Retriever
+		reranker    Reranker
+		options     Options
+		want        []string
+		wantPartial bool
+		wantErr     bool
+	}{
+		{
+			name:       "fuses with reciprocal rank fusion",
+			retrievers: []Retriever{staticRetriever("dense", dense, nil), staticRetriever("keyword", keyword, nil)},
+			options:    Options{TopK: 4, RRFK: 60},
+			want:       []string{"c", "a", "b", "d"},
+		},
+		{
+			name:       "cuts each retriever at its minimum score",
+			retrievers: []Retriever{staticRetriever("dense", dense, nil), staticRetriever("keyword", keyword, nil)},
+			options:    Options{TopK: 4, RRFK: 60, MinRetrieverScores: map[string]float64{"dense": 0.4}},
+			want:       []string{"a", "c", "b", "d"},
+		},
+		{
+			name:       "returns TopK",
+			retrievers: []Retriever{staticRetriever("dense", dense, nil), staticRetriever("keyword", keyword, nil)},
+			options:    Options{TopK: 2, RRFK: 60},
+			want:       []string{"c", "a"},
+		},
+		{
+			name:        "skips a failing retriever",
+			retrievers:  []Retriever{staticRetriever("dense", nil, failure), staticRetriever("keyword", keyword, nil)},
+			options:     Options{TopK: 4},
+			want:        []string{"c", "d"},
+			wantPartial: true,
+		},
+		{
+			name:       "fails when every retriever fails",
+			retrievers: []Retriever{staticRetriever("dense", nil, failure), staticRetriever("keyword", nil, failure)},
+			options:    Options{TopK: 4},
+			wantErr:    true,
+		},
+		{
+			name:       "reranks the fused candidates",
+			retrievers: []Retriever{staticRetriever("dense", dense, nil), staticRetriever("keyword", keyword, nil)},
+			reranker:   reverseReranker{},
+			options:    Options{TopK: 4, RRFK: 60},
+			want:       []string{"d", "b", "a", "c"},
+		},
+		{
+			name:       "drops reranked candidates below the minimum",
+			retrievers: []Retriever{staticRetriever("dense", dense, nil), staticRetriever("keyword", keyword, nil)},
+			reranker:   reverseReranker{},
+			options:    Options{TopK: 4, RRFK: 60, MinRerankScore: 0.5},
+			want:       []string{"d", "b", "a"},
+		},
+		{
+			name:       "keeps the fused order when reranking fails",
+			retrievers: []Retriever{staticRetriever("dense", dense, nil), staticRetriever("keyword", keyword, nil)},
+			reranker:   reverseReranker{err: failure},
+			options:    Options{TopK: 4, RRFK: 60, MinRerankScore: 0.5},
+			want:       []string{"c", "a", "b", "d"},
+		},
+	}
+	for _, c := range cases {
+		h := &Hybrid{Retrievers: c.retrievers, Reranker: c.reranker, Options: c.options}
+		got, err := h.Retrieve(context.Background(), "apple revenue")
+		var partial *PartialError
+		switch {
+		case c.wantErr:
+			if err == nil || errors.As(err, &partial) {
+				t.Errorf("%s: got error %v, want a total failure", c.name, err)
+			}
+			if got != nil {
+				t.Errorf("%s: got %v, want no candidates", c.name, candidateIDs(got))
+			}
+			continue
+		case c.wantPartial:
+			if !errors.As(err, &partial) || len(partial.Errs) != 1 || !errors.Is(partial.Errs[0], failure) {
+				t.Errorf("%s: got error %v, want a partial error wrapping %v", c.name, err, failure)
+			}
+		case err != nil:
+			t.Errorf("%s: unexpected error %v", c.name, err)
+		}
+		if ids := candidateIDs(got); !reflect.DeepEqual(ids, c.want) {
+			t.Errorf("%s: got %v, want %v", c.name, ids, c.want)
+		}
+	}
+}
+
+func TestHybridRecordsRetrievers(t *testing.T) {
+	h := &Hybrid{
+		Retrievers: []Retriever{staticRetriever("dense", ranked("a", "b"), nil), staticRetriever("keyword", ranked("b", "c"), nil)},
+		Options:    Options{TopK: 3},
+	}
+	got, err := h.Retrieve(context.Background(), "apple revenue")
+	if err != nil {
+		t.Fatal(err)
+	}
+	retrievers := make(map[string][]string)
+	for _, c := range got {
+		sort.Strings(c.Retrievers)
+		retrievers[c.ID] = c.Retrievers
+	}
+	want := map[string][]string{"a": {"dense"}, "b": {"dense", "keyword"}, "c": {"keyword"}}
+	if !reflect.DeepEqual(retrievers, want) {
+		t.Errorf("got %v, want %v", retrievers, want)
+	}
+}
@@ -0,0 +1,67 @@
+package search
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Cached wraps a provider with a TTL cache keyed by the normalized request
+type Cached struct {
+	Provider Provider
+	TTL      time.Duration
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	results []Result
+	expires time.Time
+}
+
+func NewCached(provider Provider, ttl time.Duration) *Cached {
+	return &Cached{Provider: provider, TTL: ttl, entries: make(map[string]cacheEntry)}
+}
+
+func (c *Cached) Name() string {
+	return c.Provider.Name()
+}
+
+func (c *Cached) Search(ctx context.Context, req Request) ([]Result, error) {
+	results, _, err := c.Lookup(ctx, req)
+	return results, err
+}
+
+// Lookup is Search that also reports whether the results came from the cache
+func (c *Cached) Lookup(ctx context.Context, req Request) ([]Result, bool, error) {
+	key := c.Provider.Name() + "|" + req.Key()
+	now := time.Now()
+
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	if ok && now.After(entry.expires) {
+		delete(c.entries, key)
+		ok = false
+	}
+	c.mu.Unlock()
+	if ok {
+		return entry.results, true, nil
+	}
+
+	results, err := c.Provider.Search(ctx, req)
+	if err != nil {
+		return nil, false, err
+	}
+
+	c.mu.Lock()
+	c.entries[key] = cacheEntry{results: results, expires: now.Add(c.TTL)}
+	// drop expired entries so the cache does not grow without bound
+	for k, e := range c.entries {
+		if now.After(e.expires) {
+			delete(c.entries, k)
+		}
+	}
+	c.mu.Unlock()
+	return results, false, nil
+}
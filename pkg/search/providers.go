@@ -0,0 +1,255 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GoogleCSE is the Google Custom Search JSON API
+type GoogleCSE struct {
+	APIKey  string
+	CX      string
+	BaseURL string
+	Client  *http.Client
+}
+
+func NewGoogleCSE(apiKey string, cx string) *GoogleCSE {
+	return &GoogleCSE{
+		APIKey:  apiKey,
+		CX:      cx,
+		BaseURL: "https://customsearch.googleapis.com/customsearch/v1",
+		Client:  &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+func (g *GoogleCSE) Name() string {
+	return "google"
+}
+
+func (g *GoogleCSE) Search(ctx context.Context, req Request) ([]Result, error) {
+	params := url.Values{}
+	params.Set("key", g.APIKey)
+	params.Set("cx", g.CX)
+	params.Set("num", strconv.Itoa(req.PageSize))
+	params.Set("start", strconv.Itoa((req.Page-1)*req.PageSize+1))
+	if req.DateRestrict != "" {
+		params.Set("dateRestrict", req.DateRestrict)
+	}
+	// siteSearch takes a single site, more than one goes into the query
+	query := req.Query
+	if len(req.Sites) == 1 {
+		params.Set("siteSearch", req.Sites[0])
+		params.Set("siteSearchFilter", "i")
+		query = siteQuery(Request{Query: query, ExcludeSites: req.ExcludeSites})
+	} else {
+		query = siteQuery(req)
+	}
+	params.Set("q", query)
+
+	var response struct {
+		Items []struct {
+			Title   string `json:"title"`
+			Link    string `json:"link"`
+			Snippet string `json:"snippet"`
+			Pagemap struct {
+				Metatags []map[string]string `json:"metatags"`
+			} `json:"pagemap"`
+		} `json:"items"`
+	}
+	if err := getJSON(ctx, g.Client, g.BaseURL+"?"+params.Encode(), nil, &response); err != nil {
+		return nil, err
+	}
+
+	var results []Result
+	for _, item := range response.Items {
+		date := ""
+		for _, tags := range item.Pagemap.Metatags {
+			if published, ok := tags["article:published_time"]; ok {
+				date = published
+				break
+			}
+		}
+		results = append(results, Result{Title: item.Title, URL: item.Link, Snippet: item.Snippet, Date: date})
+	}
+	return results, nil
+}
+
+// Brave is the Brave Search web API
+type Brave struct {
+	APIKey  string
+	BaseURL string
+	Client  *http.Client
+}
+
+func NewBrave(apiKey string) *Brave {
+	return &Brave{
+		APIKey:  apiKey,
+		BaseURL: "https://api.search.brave.com/res/v1/web/search",
+		Client:  &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+func (b *Brave) Name() string {
+	return "brave"
+}
+
+func (b *Brave) Search(ctx context.Context, req Request) ([]Result, error) {
+	params := url.Values{}
+	params.Set("q", siteQuery(req))
+	params.Set("count", strconv.Itoa(req.PageSize))
+	params.Set("offset", strconv.Itoa(req.Page-1))
+	if req.DateRestrict != "" {
+		age, _ := ParseDateRestrict(req.DateRestrict)
+		now := time.Now().UTC()
+		params.Set("freshness", now.Add(-age).Format("2006-01-02")+"to"+now.Format("2006-01-02"))
+	}
+
+	var response struct {
+		Web struct {
+			Results []struct {
+				Title       string `json:"title"`
+				URL         string `json:"url"`
+				Description string `json:"description"`
+				PageAge     string `json:"page_age"`
+			} `json:"results"`
+		} `json:"web"`
+	}
+	headers := map[string]string{"X-Subscription-Token": b.APIKey, "Accept": "application/json"}
+	if err := getJSON(ctx, b.Client, b.BaseURL+"?"+params.Encode(), headers, &response); err != nil {
+		return nil, err
+	}
+
+	var results []Result
+	for _, item := range response.Web.Results {
+		results = append(results, Result{Title: item.Title, URL: item.URL, Snippet: item.Description, Date: item.PageAge})
+	}
+	return results, nil
+}
+
+// Bing is the Bing Web Search v7 API
+type Bing struct {
+	APIKey  string
+	BaseURL string
+	Client  *http.Client
+}
+
+func NewBing(apiKey string) *Bing {
+	return &Bing{
+		APIKey:  apiKey,
+		BaseURL: "https://api.bing.microsoft.com/v7.0/search",
+		Client:  &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+func (b *Bing) Name() string {
+	return "bing"
+}
+
+func (b *Bing) Search(ctx context.Context, req Request) ([]Result, error) {
+	params := url.Values{}
+	params.Set("q", siteQuery(req))
+	params.Set("count", strconv.Itoa(req.PageSize))
+	params.Set("offset", strconv.Itoa((req.Page-1)*req.PageSize))
+	if req.DateRestrict != "" {
+		age, _ := ParseDateRestrict(req.DateRestrict)
+		now := time.Now().UTC()
+		params.Set("freshness", now.Add(-age).Format("2006-01-02")+".."+now.Format("2006-01-02"))
+	}
+
+	var response struct {
+		WebPages struct {
+			Value []struct {
+				Name            string `json:"name"`
+				URL             string `json:"url"`
+				Snippet         string `json:"snippet"`
+				DatePublished   string `json:"datePublished"`
+				DateLastCrawled string `json:"dateLastCrawled"`
+			} `json:"value"`
+		} `json:"webPages"`
+	}
+	headers := map[string]string{"Ocp-Apim-Subscription-Key": b.APIKey}
+	if err := getJSON(ctx, b.Client, b.BaseURL+"?"+params.Encode(), headers, &response); err != nil {
+		return nil, err
+	}
+
+	var results []Result
+	for _, item := range response.WebPages.Value {
+		date := item.DatePublished
+		if date == "" {
+			date = item.DateLastCrawled
+		}
+		results = append(results, Result{Title: item.Name, URL: item.URL, Snippet: item.Snippet, Date: date})
+	}
+	return results, nil
+}
+
+// Fixture serves results from a local JSON file that maps queries to
+// results, for development and tests without a search API key. Queries are
+// matched case insensitively, "*" matches any query
+type Fixture struct {
+	Path string
+}
+
+func (f Fixture) Name() string {
+	return "fixture"
+}
+
+func (f Fixture) Search(ctx context.Context, req Request) ([]Result, error) {
+	data, err := os.ReadFile(f.Path)
+	if err != nil {
+		return nil, err
+	}
+	var fixtures map[string][]Result
+	if err := json.Unmarshal(data, &fixtures); err != nil {
+		return nil, fmt.Errorf("could not parse search fixture %s: %v", f.Path, err)
+	}
+
+	var results []Result
+	for query, items := range fixtures {
+		if strings.EqualFold(strings.Join(strings.Fields(query), " "), req.Query) {
+			results = items
+			break
+		}
+	}
+	if results == nil {
+		results = fixtures["*"]
+	}
+
+	results = FilterSites(results, req.Sites, req.ExcludeSites)
+	start := (req.Page - 1) * req.PageSize
+	if start >= len(results) {
+		return []Result{}, nil
+	}
+	end := start + req.PageSize
+	if end > len(results) {
+		end = len(results)
+	}
+	return results[start:end], nil
+}
+
+func getJSON(ctx context.Context, client *http.Client, reqURL string, headers map[string]string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
+	if err != nil {
+		return err
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("search provider returned status %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
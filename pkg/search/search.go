@@ -0,0 +1,157 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Result is a single web search result
+type Result struct {
+	Title   string `json:"title"`
+	URL     string `json:"url"`
+	Snippet string `json:"snippet"`
+	Date    string `json:"date"`
+}
+
+// Request is a provider independent search request
+type Request struct {
+	Query    string
+	Page     int // 1-based
+	PageSize int
+	// DateRestrict limits results to the last N days, weeks, months or years,
+	// written d7, w2, m1 or y1 as in Google's dateRestrict
+	DateRestrict string
+	Sites        []string // only return results from these hosts
+	ExcludeSites []string // never return results from these hosts
+}
+
+// Provider is a web search backend
+type Provider interface {
+	Name() string
+	Search(ctx context.Context, req Request) ([]Result, error)
+}
+
+// MaxPageSize is the largest page every provider supports
+const MaxPageSize = 10
+
+// Normalize validates a request and fills in defaults
+func (r Request) Normalize() (Request, error) {
+	r.Query = strings.Join(strings.Fields(r.Query), " ")
+	if r.Query == "" {
+		return r, fmt.Errorf("query is required")
+	}
+	if r.Page < 1 {
+		r.Page = 1
+	}
+	if r.PageSize < 1 || r.PageSize > MaxPageSize {
+		r.PageSize = MaxPageSize
+	}
+	if r.DateRestrict != "" {
+		if _, err := ParseDateRestrict(r.DateRestrict); err != nil {
+			return r, err
+		}
+	}
+	r.Sites = normalizeHosts(r.Sites)
+	r.ExcludeSites = normalizeHosts(r.ExcludeSites)
+	return r, nil
+}
+
+// Key identifies a request for caching, queries that differ only in case or
+// whitespace share a key
+func (r Request) Key() string {
+	return strings.Join([]string{
+		strings.ToLower(r.Query),
+		strconv.Itoa(r.Page),
+		strconv.Itoa(r.PageSize),
+		r.DateRestrict,
+		strings.Join(r.Sites, ","),
+		strings.Join(r.ExcludeSites, ","),
+	}, "|")
+}
+
+var dateRestrictPattern = regexp.MustCompile(`^([dwmy])(\d+)$`)
+
+// ParseDateRestrict converts d7, w2, m1 or y1 into a duration
+func ParseDateRestrict(value string) (time.Duration, error) {
+	match := dateRestrictPattern.FindStringSubmatch(strings.ToLower(value))
+	if match == nil {
+		return 0, fmt.Errorf("invalid date restriction %q, expected d7, w2, m1 or y1", value)
+	}
+	n, _ := strconv.Atoi(match[2])
+	day := 24 * time.Hour
+	switch match[1] {
+	case "d":
+		return time.Duration(n) * day, nil
+	case "w":
+		return time.Duration(n) * 7 * day, nil
+	case "m":
+		return time.Duration(n) * 30 * day, nil
+	default:
+		return time.Duration(n) * 365 * day, nil
+	}
+}
+
+// siteQuery appends site: operators for providers without native site filters
+func siteQuery(req Request) string {
+	query := req.Query
+	if len(req.Sites) > 0 {
+		var sites []string
+		for _, site := range req.Sites {
+			sites = append(sites, "site:"+site)
+		}
+		query += " (" + strings.Join(sites, " OR ") + ")"
+	}
+	for _, site := range req.ExcludeSites {
+		query += " -site:" + site
+	}
+	return query
+}
+
+// FilterSites drops results outside the allow list or inside the deny list.
+// Providers treat site operators as hints so results are always filtered again
+func FilterSites(results []Result, sites []string, exclude []string) []Result {
+	var kept []Result
+	for _, result := range results {
+		u, err := url.Parse(result.URL)
+		if err != nil {
+			continue
+		}
+		host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+		if len(sites) > 0 && !matchesHost(host, sites) {
+			continue
+		}
+		if matchesHost(host, exclude) {
+			continue
+		}
+		kept = append(kept, result)
+	}
+	return kept
+}
+
+// matchesHost is true when host is one of the sites or a subdomain of one
+func matchesHost(host string, sites []string) bool {
+	for _, site := range sites {
+		if host == site || strings.HasSuffix(host, "."+site) {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeHosts(hosts []string) []string {
+	var normalized []string
+	for _, host := range hosts {
+		host = strings.ToLower(strings.TrimSpace(host))
+		host = strings.TrimPrefix(strings.TrimPrefix(host, "https://"), "http://")
+		host = strings.TrimPrefix(strings.TrimSuffix(host, "/"), "www.")
+		if host != "" {
+			normalized = append(normalized, host)
+		}
+	}
+	return normalized
+}
@@ -0,0 +1,93 @@
+package sentiment
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Lexicon maps a lowercase word to its polarity. Positive values are
+// bullish, negative values bearish, strong words carry a magnitude of 2
+type Lexicon map[string]float64
+
+// DefaultLexicon is a finance specific word list modelled on the
+// Loughran-McDonald dictionary, which unlike general purpose lexicons does not
+// treat words like "liability", "tax" or "cost" as negative
+var DefaultLexicon = Lexicon{
+	// positive
+	"achieve": 1, "achieved": 1, "advance": 1, "advances": 1, "beat": 1, "beats": 1,
+	"benefit": 1, "benefited": 1, "boost": 1, "boosted": 1, "boosts": 1, "breakthrough": 2,
+	"bullish": 2, "climb": 1, "climbs": 1, "climbed": 1, "exceed": 1, "exceeded": 1,
+	"exceeds": 1, "expand": 1, "expanded": 1, "expansion": 1, "gain": 1, "gained": 1,
+	"gains": 1, "grew": 1, "growth": 1, "improve": 1, "improved": 1, "improvement": 1,
+	"improves": 1, "innovative": 1, "jump": 1, "jumps": 1, "jumped": 1, "outperform": 1,
+	"outperformed": 1, "outperforms": 1, "positive": 1, "profit": 1, "profitable": 1,
+	"profitability": 1, "rally": 1, "rallies": 1, "record": 2, "rebound": 1, "rebounds": 1,
+	"rise": 1, "rises": 1, "rose": 1, "soar": 2, "soared": 2, "soars": 2, "strength": 1,
+	"strong": 1, "stronger": 1, "success": 1, "successful": 1, "surge": 2, "surged": 2,
+	"surges": 2, "surpass": 1, "surpassed": 1, "upgrade": 2, "upgraded": 2, "upgrades": 2,
+	"upside": 1, "win": 1, "wins": 1, "optimistic": 1, "robust": 1, "accelerate": 1,
+	"accelerates": 1, "buyback": 1, "dividend": 1,
+
+	// negative
+	"adverse": -1, "bankruptcy": -2, "bearish": -2, "breach": -1, "concern": -1,
+	"concerns": -1, "crash": -2, "crashes": -2, "cut": -1, "cuts": -1, "decline": -1,
+	"declined": -1, "declines": -1, "decrease": -1, "decreased": -1, "default": -2,
+	"deficit": -1, "delay": -1, "delayed": -1, "delays": -1, "downgrade": -2,
+	"downgraded": -2, "downgrades": -2, "drop": -1, "dropped": -1, "drops": -1,
+	"fail": -1, "failed": -1, "failure": -1, "fall": -1, "falls": -1, "fell": -1,
+	"fined": -1, "fraud": -2, "impairment": -1, "investigation": -1,
+	"lawsuit": -1, "lawsuits": -1, "layoffs": -1, "litigation": -1, "loss": -1,
+	"losses": -1, "miss": -1, "missed": -1, "misses": -1, "negative": -1, "penalty": -1,
+	"plunge": -2, "plunged": -2, "plunges": -2, "probe": -1, "recall": -1, "recalls": -1,
+	"restatement": -2, "risk": -1, "risks": -1, "shortfall": -1, "slump": -2,
+	"slumped": -2, "slumps": -2, "slowdown": -1, "sink": -1, "sinks": -1, "sank": -1,
+	"tumble": -2, "tumbled": -2, "tumbles": -2, "volatile": -1, "volatility": -1,
+	"warning": -1, "warns": -1, "weak": -1, "weaker": -1, "weakness": -1, "worse": -1,
+	"worst": -2, "subpoena": -1, "writedown": -1, "downturn": -1, "pessimistic": -1,
+}
+
+// negators flip the polarity of the sentiment words that follow them
+var negators = map[string]bool{
+	"not": true, "no": true, "never": true, "neither": true, "nor": true, "none": true,
+	"without": true, "hardly": true, "barely": true, "cannot": true, "isn't": true,
+	"wasn't": true, "aren't": true, "weren't": true, "doesn't": true, "didn't": true,
+	"don't": true, "won't": true, "can't": true, "hasn't": true, "haven't": true,
+}
+
+// LoadLexicon reads "word,polarity" lines and merges them over the default
+// lexicon. Blank lines and lines starting with # are skipped
+func LoadLexicon(path string) (Lexicon, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	lexicon := make(Lexicon, len(DefaultLexicon))
+	for word, polarity := range DefaultLexicon {
+		lexicon[word] = polarity
+	}
+
+	scanner := bufio.NewScanner(file)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		word, value, found := strings.Cut(text, ",")
+		if !found {
+			return nil, fmt.Errorf("%s:%d: expected word,polarity", path, line)
+		}
+		polarity, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", path, line, err)
+		}
+		lexicon[strings.ToLower(strings.TrimSpace(word))] = polarity
+	}
+	return lexicon, scanner.Err()
+}
@@ -0,0 +1,169 @@
+package sentiment
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Score is the lexicon sentiment of a piece of text. Value is in [-1, 1]
+type Score struct {
+	Value    float64 `json:"value" bson:"value"`
+	Label    string  `json:"label" bson:"label"`
+	Positive float64 `json:"positive" bson:"positive"`
+	Negative float64 `json:"negative" bson:"negative"`
+	Hits     int     `json:"hits" bson:"hits"`
+}
+
+// Scorer scores headlines and body text against a lexicon
+type Scorer struct {
+	Lexicon        Lexicon
+	HeadlineWeight float64 // headline words count this many times a body word
+	NegationWindow int     // words after a negator whose polarity is flipped
+}
+
+func NewScorer(lexicon Lexicon) *Scorer {
+	if lexicon == nil {
+		lexicon = DefaultLexicon
+	}
+	return &Scorer{
+		Lexicon:        lexicon,
+		HeadlineWeight: 2,
+		NegationWindow: 3,
+	}
+}
+
+// Score weighs the headline and body and returns the net polarity,
+// (positive - negative) / (positive + negative)
+func (s *Scorer) Score(headline string, body string) Score {
+	var score Score
+	s.accumulate(&score, headline, s.HeadlineWeight)
+	s.accumulate(&score, body, 1)
+	if total := score.Positive + score.Negative; total > 0 {
+		score.Value = (score.Positive - score.Negative) / total
+	}
+	score.Label = Label(score.Value, score.Hits)
+	return score
+}
+
+func (s *Scorer) accumulate(score *Score, text string, weight float64) {
+	negated := 0
+	for _, token := range tokenize(text) {
+		if negators[token] || strings.HasSuffix(token, "n't") {
+			negated = s.NegationWindow
+			continue
+		}
+		polarity, ok := s.Lexicon[token]
+		if ok {
+			if negated > 0 {
+				polarity = -polarity
+			}
+			if polarity > 0 {
+				score.Positive += polarity * weight
+			} else {
+				score.Negative += -polarity * weight
+			}
+			score.Hits++
+		}
+		if negated > 0 {
+			negated--
+		}
+	}
+}
+
+// tokenize lowercases text into words, keeping apostrophes for contractions
+func tokenize(text string) []string {
+	text = strings.ReplaceAll(strings.ToLower(text), "’", "'")
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+	})
+}
+
+// Label buckets a score on the same scale the news template asks the model to use
+func Label(value float64, hits int) string {
+	switch {
+	case hits == 0:
+		return "no signal"
+	case value >= 0.6:
+		return "highly bullish"
+	case value >= 0.2:
+		return "bullish"
+	case value > -0.2:
+		return "neutral"
+	case value > -0.6:
+		return "bearish"
+	default:
+		return "highly bearish"
+	}
+}
+
+// Observation is a scored item at a point in time
+type Observation struct {
+	Time  time.Time
+	Score Score
+}
+
+// Point is the aggregate sentiment of one day
+type Point struct {
+	Ticker   string  `json:"ticker" bson:"ticker"`
+	Date     string  `json:"date" bson:"date"` // YYYY-MM-DD in UTC
+	Value    float64 `json:"value" bson:"value"`
+	Label    string  `json:"label" bson:"label"`
+	Articles int     `json:"articles" bson:"articles"`
+}
+
+// Daily averages the observations with a signal into one point per UTC day,
+// oldest first. Undated observations are skipped
+func Daily(ticker string, observations []Observation) []Point {
+	sums := make(map[string]float64)
+	counts := make(map[string]int)
+	for _, o := range observations {
+		if o.Time.IsZero() || o.Score.Hits == 0 {
+			continue
+		}
+		day := o.Time.UTC().Format("2006-01-02")
+		sums[day] += o.Score.Value
+		counts[day]++
+	}
+
+	var points []Point
+	for day, sum := range sums {
+		value := sum / float64(counts[day])
+		points = append(points, Point{Ticker: ticker, Date: day, Value: value, Label: Label(value, counts[day]), Articles: counts[day]})
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i].Date < points[j].Date })
+	return points
+}
+
+// Overall is the article weighted mean across a series
+func Overall(points []Point) Score {
+	var sum float64
+	var articles int
+	for _, p := range points {
+		sum += p.Value * float64(p.Articles)
+		articles += p.Articles
+	}
+	var score Score
+	if articles > 0 {
+		score.Value = sum / float64(articles)
+	}
+	score.Hits = articles
+	score.Label = Label(score.Value, articles)
+	return score
+}
+
+// Summary renders the series as plain text for prompt templates
+func Summary(overall Score, points []Point) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "LEXICON SENTIMENT SIGNAL: %.2f (%s) ACROSS %d SCORED ARTICLES.", overall.Value, strings.ToUpper(overall.Label), overall.Hits)
+	if len(points) > 0 {
+		b.WriteString(" DAILY:")
+		for _, p := range points {
+			fmt.Fprintf(&b, " %s %.2f (%d)", p.Date, p.Value, p.Articles)
+		}
+		b.WriteString(".")
+	}
+	return b.String()
+}
@@ -0,0 +1,129 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// StatusError is returned when a service answers with a non 200 status
+type StatusError struct {
+	Service    Service
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s service returned %d: %s", e.Service, e.StatusCode, strings.TrimSpace(e.Body))
+}
+
+// Client calls Fineas services with the shared pass key hash
+type Client struct {
+	PassHash string
+	BaseURLs map[Service]string
+	HTTP     *http.Client
+}
+
+// New builds a client using DefaultBaseURLs, overridden by baseURLs
+func New(passHash string, baseURLs map[Service]string) *Client {
+	urls := make(map[Service]string, len(DefaultBaseURLs))
+	for service, baseURL := range DefaultBaseURLs {
+		urls[service] = baseURL
+	}
+	for service, baseURL := range baseURLs {
+		if baseURL != "" {
+			urls[service] = strings.TrimSuffix(baseURL, "/")
+		}
+	}
+	return &Client{
+		PassHash: passHash,
+		BaseURLs: urls,
+		HTTP:     &http.Client{Timeout: 5 * time.Minute},
+	}
+}
+
+// Get calls a GET endpoint
+func Get[Req QueryRequest, Resp any](ctx context.Context, c *Client, e GetEndpoint[Req, Resp], req Req) (Resp, error) {
+	var resp Resp
+	reqURL := c.BaseURLs[e.Service] + e.Path + "?" + req.Query().Encode()
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
+	if err != nil {
+		return resp, err
+	}
+	return resp, c.do(httpReq, e.Service, &resp)
+}
+
+// Post calls a POST endpoint with a JSON body
+func Post[Req any, Resp any](ctx context.Context, c *Client, e PostEndpoint[Req, Resp], req Req) (Resp, error) {
+	var resp Resp
+	body, err := json.Marshal(req)
+	if err != nil {
+		return resp, err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.BaseURLs[e.Service]+e.Path, bytes.NewReader(body))
+	if err != nil {
+		return resp, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	return resp, c.do(httpReq, e.Service, &resp)
+}
+
+// rawDecoder is implemented by responses that are not JSON
+type rawDecoder interface {
+	decodeRaw(body []byte) error
+}
+
+// do sends the request and decodes the body into resp. An empty 200 body,
+// which the data services send after storing a writekey request, leaves resp
+// at its zero value
+func (c *Client) do(req *http.Request, service Service, resp interface{}) error {
+	req.Header.Set("Authorization", "Bearer "+c.PassHash)
+	httpResp, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer httpResp.Body.Close()
+
+	body, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return err
+	}
+	if httpResp.StatusCode != http.StatusOK {
+		return &StatusError{Service: service, StatusCode: httpResp.StatusCode, Body: string(body)}
+	}
+	if raw, ok := resp.(rawDecoder); ok {
+		return raw.decodeRaw(body)
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(body, resp); err != nil {
+		return fmt.Errorf("%s service returned an unexpected body %q: %v", service, truncate(string(body), 200), err)
+	}
+	return nil
+}
+
+// DecodeRequest decodes a POST endpoint's JSON body on the handler side
+func DecodeRequest[Req any, Resp any](r *http.Request, e PostEndpoint[Req, Resp]) (Req, error) {
+	var req Req
+	if ct := r.Header.Get("Content-Type"); ct != "" && !strings.HasPrefix(ct, "application/json") {
+		return req, fmt.Errorf("%s expects application/json, got %s", e.Path, ct)
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return req, errors.New("invalid JSON body: " + err.Error())
+	}
+	return req, nil
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
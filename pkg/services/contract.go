@@ -0,0 +1,225 @@
+package services
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"fineas/pkg/fulltext"
+	"fineas/pkg/news"
+	"fineas/pkg/peers"
+	"fineas/pkg/profile"
+	"fineas/pkg/search"
+	"fineas/pkg/sentiment"
+	"fineas/pkg/valuation"
+)
+
+// This file is the single contract between Fineas services. Every endpoint
+// declares its request and response types here, callers go through Get and
+// Post and the handlers decode with the same types, so a caller cannot send
+// a body the handler does not read
+
+// Service names a Fineas service so its base URL can be configured
+type Service string
+
+const (
+	ServiceStk    Service = "stk"
+	ServiceFin    Service = "fin"
+	ServiceNews   Service = "news"
+	ServiceDesc   Service = "desc"
+	ServiceTA     Service = "ta"
+	ServiceSearch Service = "search"
+	ServiceLLM    Service = "llm"
+
+	ServicePeers     Service = "peers"
+	ServiceValuation Service = "valuation"
+)
+
+// DefaultBaseURLs are the base URLs used when the client is not configured otherwise
+var DefaultBaseURLs = map[Service]string{
+	ServiceStk:    "http://0.0.0.0:8081",
+	ServiceFin:    "http://0.0.0.0:8082",
+	ServiceNews:   "http://0.0.0.0:8083",
+	ServiceDesc:   "http://0.0.0.0:8084",
+	ServiceTA:     "http://0.0.0.0:8089",
+	ServiceSearch: "http://0.0.0.0:8070",
+	ServiceLLM:    "http://0.0.0.0:8090",
+
+	ServicePeers:     "http://0.0.0.0:8085",
+	ServiceValuation: "http://0.0.0.0:8086",
+}
+
+// QueryRequest is a request sent as URL query parameters
+type QueryRequest interface {
+	Query() url.Values
+}
+
+// GetEndpoint is an endpoint called with GET and query parameters
+type GetEndpoint[Req QueryRequest, Resp any] struct {
+	Service Service
+	Path    string
+}
+
+// PostEndpoint is an endpoint called with POST and a JSON body
+type PostEndpoint[Req any, Resp any] struct {
+	Service Service
+	Path    string
+}
+
+// TickerRequest is the request of every ticker data service
+type TickerRequest struct {
+	Ticker   string
+	WriteKey string
+	// Params are extra service specific query parameters
+	Params url.Values
+}
+
+func (r TickerRequest) Query() url.Values {
+	values := url.Values{}
+	for key, value := range r.Params {
+		values[key] = value
+	}
+	values.Set("ticker", r.Ticker)
+	values.Set("writekey", r.WriteKey)
+	return values
+}
+
+// ResultResponse is the response of services that only return report text
+type ResultResponse struct {
+	Result string
+}
+
+// NewsResponse is the /news response
+type NewsResponse struct {
+	Result          string
+	Articles        []news.Article
+	Page            int
+	PageSize        int
+	Total           int
+	LookbackDays    int
+	Sentiment       sentiment.Score
+	SentimentSeries []sentiment.Point
+	Clusters        []news.Cluster
+}
+
+// DescResponse is the /desc response
+type DescResponse struct {
+	Result  string
+	Profile *profile.CompanyProfile `json:",omitempty" bson:",omitempty"`
+}
+
+// PeersResponse is the /peers response
+type PeersResponse struct {
+	Result string
+	Table  peers.Table
+}
+
+// ValuationResponse is the /valuation response
+type ValuationResponse struct {
+	Result    string
+	Valuation valuation.Report
+}
+
+// SearchResult is a single web search result
+type SearchResult = search.Result
+
+// SearchRequest is the /search body. Section names a report section (stk,
+// fin, desc) whose configured site lists and date restriction apply
+type SearchRequest struct {
+	Query        string   `json:"query"`
+	Page         int      `json:"page,omitempty"`
+	PageSize     int      `json:"page_size,omitempty"`
+	DateRestrict string   `json:"date_restrict,omitempty"`
+	Sites        []string `json:"sites,omitempty"`
+	ExcludeSites []string `json:"exclude_sites,omitempty"`
+	Section      string   `json:"section,omitempty"`
+}
+
+// SearchResponse is the /search response
+type SearchResponse struct {
+	Results  []SearchResult `json:"results"`
+	Page     int            `json:"page"`
+	PageSize int            `json:"page_size"`
+	Provider string         `json:"provider"`
+	Cached   bool           `json:"cached"`
+}
+
+// InternalSearchRequest is the /search/internal query, Text is sent as q. Tickers, Sections and
+// Sources are sent comma separated, From and To as YYYY-MM-DD
+type InternalSearchRequest struct {
+	Text      string
+	Tickers   []string
+	Sections  []string
+	Sources   []string
+	From      string
+	To        string
+	Limit     int
+	Offset    int
+	Highlight bool
+}
+
+func (r InternalSearchRequest) Query() url.Values {
+	values := url.Values{}
+	values.Set("q", r.Text)
+	setList := func(key string, items []string) {
+		if len(items) > 0 {
+			values.Set(key, strings.Join(items, ","))
+		}
+	}
+	setList("ticker", r.Tickers)
+	setList("section", r.Sections)
+	setList("source", r.Sources)
+	if r.From != "" {
+		values.Set("from", r.From)
+	}
+	if r.To != "" {
+		values.Set("to", r.To)
+	}
+	if r.Limit > 0 {
+		values.Set("limit", strconv.Itoa(r.Limit))
+	}
+	if r.Offset > 0 {
+		values.Set("offset", strconv.Itoa(r.Offset))
+	}
+	values.Set("highlight", strconv.FormatBool(r.Highlight))
+	return values
+}
+
+// InternalSearchResponse is the /search/internal response
+type InternalSearchResponse struct {
+	Query       string         `json:"query"`
+	Total       int            `json:"total"`
+	Hits        []fulltext.Hit `json:"hits"`
+	Indexed     int            `json:"indexed"`
+	RefreshedAt time.Time      `json:"refreshed_at"`
+}
+
+// LLMRequest is the /llm body
+type LLMRequest struct {
+	Prompt string `json:"prompt"`
+}
+
+// Text is a plain text response body
+type Text string
+
+func (t *Text) decodeRaw(body []byte) error {
+	*t = Text(body)
+	return nil
+}
+
+// the Fineas endpoints
+var (
+	Stk    = GetEndpoint[TickerRequest, ResultResponse]{Service: ServiceStk, Path: "/stk"}
+	Fin    = GetEndpoint[TickerRequest, ResultResponse]{Service: ServiceFin, Path: "/fin"}
+	News   = GetEndpoint[TickerRequest, NewsResponse]{Service: ServiceNews, Path: "/news"}
+	Desc   = GetEndpoint[TickerRequest, DescResponse]{Service: ServiceDesc, Path: "/desc"}
+	TA     = GetEndpoint[TickerRequest, ResultResponse]{Service: ServiceTA, Path: "/ta"}
+	Search = PostEndpoint[SearchRequest, SearchResponse]{Service: ServiceSearch, Path: "/search"}
+	LLM    = PostEndpoint[LLMRequest, Text]{Service: ServiceLLM, Path: "/llm"}
+
+	InternalSearch = GetEndpoint[InternalSearchRequest, InternalSearchResponse]{Service: ServiceSearch, Path: "/search/internal"}
+
+	Peers     = GetEndpoint[TickerRequest, PeersResponse]{Service: ServicePeers, Path: "/peers"}
+	Valuation = GetEndpoint[TickerRequest, ValuationResponse]{Service: ServiceValuation, Path: "/valuation"}
+)
@@ -0,0 +1,218 @@
+package valuation
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// WACCInputs are the inputs to the weighted average cost of capital. Rates
+// are percentages, EquityValue and DebtValue are market values in dollars
+type WACCInputs struct {
+	RiskFreeRate      float64 `json:"risk_free_rate"`
+	EquityRiskPremium float64 `json:"equity_risk_premium"`
+	Beta              float64 `json:"beta"`
+	PreTaxCostOfDebt  float64 `json:"pre_tax_cost_of_debt"`
+	TaxRate           float64 `json:"tax_rate"`
+	EquityValue       float64 `json:"equity_value"`
+	DebtValue         float64 `json:"debt_value"`
+}
+
+// CostOfEquity uses CAPM, in percent
+func (w WACCInputs) CostOfEquity() float64 {
+	return w.RiskFreeRate + w.Beta*w.EquityRiskPremium
+}
+
+// WACC weights the cost of equity and after tax cost of debt by market value, in percent
+func (w WACCInputs) WACC() float64 {
+	total := w.EquityValue + w.DebtValue
+	if total <= 0 {
+		return w.CostOfEquity()
+	}
+	afterTaxDebt := w.PreTaxCostOfDebt * (1 - w.TaxRate/100)
+	return w.EquityValue/total*w.CostOfEquity() + w.DebtValue/total*afterTaxDebt
+}
+
+// DCFInputs configure the discounted cash flow model. Growth fades linearly
+// from GrowthRate in the first year to TerminalGrowth in the last projected year
+type DCFInputs struct {
+	BaseFCF           float64    `json:"base_fcf"`
+	Years             int        `json:"years"`
+	GrowthRate        float64    `json:"growth_rate"`
+	TerminalGrowth    float64    `json:"terminal_growth"`
+	WACC              WACCInputs `json:"wacc_inputs"`
+	DiscountRate      float64    `json:"discount_rate,omitempty"`
+	NetDebt           float64    `json:"net_debt"`
+	SharesOutstanding float64    `json:"shares_outstanding"`
+	CurrentPrice      float64    `json:"current_price"`
+}
+
+// rate is the discount rate in percent, an explicit rate overrides WACC
+func (in DCFInputs) rate() float64 {
+	if in.DiscountRate > 0 {
+		return in.DiscountRate
+	}
+	return in.WACC.WACC()
+}
+
+// ProjectedYear is one year of projected free cash flow
+type ProjectedYear struct {
+	Year           int     `json:"year"`
+	Growth         float64 `json:"growth"`
+	FCF            float64 `json:"fcf"`
+	DiscountFactor float64 `json:"discount_factor"`
+	PresentValue   float64 `json:"present_value"`
+}
+
+// DCFResult is the output of the model with every intermediate value
+type DCFResult struct {
+	DiscountRate      float64         `json:"discount_rate"`
+	CostOfEquity      float64         `json:"cost_of_equity"`
+	Projections       []ProjectedYear `json:"projections"`
+	TerminalValue     float64         `json:"terminal_value"`
+	PVTerminalValue   float64         `json:"pv_terminal_value"`
+	EnterpriseValue   float64         `json:"enterprise_value"`
+	EquityValue       float64         `json:"equity_value"`
+	FairValuePerShare float64         `json:"fair_value_per_share"`
+	UpsidePercent     float64         `json:"upside_percent"`
+}
+
+// Validate checks the inputs can produce a meaningful valuation
+func (in DCFInputs) Validate() error {
+	if in.Years <= 0 {
+		return errors.New("projection years must be positive")
+	}
+	if in.SharesOutstanding <= 0 {
+		return errors.New("shares outstanding must be positive")
+	}
+	if in.rate() <= in.TerminalGrowth {
+		return fmt.Errorf("discount rate %.2f%% must exceed terminal growth %.2f%%", in.rate(), in.TerminalGrowth)
+	}
+	return nil
+}
+
+// DCF projects free cash flow, discounts it and adds a Gordon growth terminal value
+func DCF(in DCFInputs) (DCFResult, error) {
+	if err := in.Validate(); err != nil {
+		return DCFResult{}, err
+	}
+
+	r := in.rate() / 100
+	g := in.TerminalGrowth / 100
+	result := DCFResult{
+		DiscountRate: in.rate(),
+		CostOfEquity: in.WACC.CostOfEquity(),
+	}
+
+	fcf := in.BaseFCF
+	var pvSum float64
+	for year := 1; year <= in.Years; year++ {
+		growth := in.GrowthRate
+		if in.Years > 1 {
+			growth = in.GrowthRate + (in.TerminalGrowth-in.GrowthRate)*float64(year-1)/float64(in.Years-1)
+		}
+		fcf *= 1 + growth/100
+		factor := 1 / math.Pow(1+r, float64(year))
+		pv := fcf * factor
+		pvSum += pv
+		result.Projections = append(result.Projections, ProjectedYear{
+			Year:           year,
+			Growth:         growth,
+			FCF:            fcf,
+			DiscountFactor: factor,
+			PresentValue:   pv,
+		})
+	}
+
+	result.TerminalValue = fcf * (1 + g) / (r - g)
+	result.PVTerminalValue = result.TerminalValue / math.Pow(1+r, float64(in.Years))
+	result.EnterpriseValue = pvSum + result.PVTerminalValue
+	result.EquityValue = result.EnterpriseValue - in.NetDebt
+	result.FairValuePerShare = result.EquityValue / in.SharesOutstanding
+	if in.CurrentPrice > 0 {
+		result.UpsidePercent = (result.FairValuePerShare/in.CurrentPrice - 1) * 100
+	}
+	return result, nil
+}
+
+// ImpliedGrowth is the reverse DCF: the first year growth rate, in percent,
+// at which the model's fair value equals the current price
+func ImpliedGrowth(in DCFInputs) (float64, error) {
+	if in.CurrentPrice <= 0 {
+		return 0, errors.New("current price is required for a reverse DCF")
+	}
+	if in.BaseFCF <= 0 {
+		return 0, errors.New("base free cash flow must be positive for a reverse DCF")
+	}
+
+	fairValueAt := func(growth float64) (float64, error) {
+		in.GrowthRate = growth
+		res, err := DCF(in)
+		return res.FairValuePerShare, err
+	}
+
+	// fair value increases with growth so bisect between the bounds
+	low, high := -50.0, 100.0
+	lowValue, err := fairValueAt(low)
+	if err != nil {
+		return 0, err
+	}
+	highValue, err := fairValueAt(high)
+	if err != nil {
+		return 0, err
+	}
+	if in.CurrentPrice < lowValue || in.CurrentPrice > highValue {
+		return 0, fmt.Errorf("implied growth is outside %.0f%% to %.0f%%", low, high)
+	}
+	for i := 0; i < 100 && high-low > 1e-4; i++ {
+		mid := (low + high) / 2
+		value, err := fairValueAt(mid)
+		if err != nil {
+			return 0, err
+		}
+		if value < in.CurrentPrice {
+			low = mid
+		} else {
+			high = mid
+		}
+	}
+	return (low + high) / 2, nil
+}
+
+// SensitivityTable is fair value per share for each discount rate (rows) and
+// terminal growth rate (columns). Cells are nil where the rate does not exceed growth
+type SensitivityTable struct {
+	DiscountRates   []float64    `json:"discount_rates"`
+	TerminalGrowths []float64    `json:"terminal_growths"`
+	FairValues      [][]*float64 `json:"fair_values"`
+}
+
+// Sensitivity reruns the model across a grid of discount and terminal growth rates
+func Sensitivity(in DCFInputs, discountRates []float64, terminalGrowths []float64) SensitivityTable {
+	table := SensitivityTable{
+		DiscountRates:   discountRates,
+		TerminalGrowths: terminalGrowths,
+	}
+	for _, rate := range discountRates {
+		row := make([]*float64, len(terminalGrowths))
+		for j, growth := range terminalGrowths {
+			in.DiscountRate = rate
+			in.TerminalGrowth = growth
+			if res, err := DCF(in); err == nil {
+				value := res.FairValuePerShare
+				row[j] = &value
+			}
+		}
+		table.FairValues = append(table.FairValues, row)
+	}
+	return table
+}
+
+// Steps returns n values centred on mid spaced by step
+func Steps(mid float64, step float64, n int) []float64 {
+	values := make([]float64, n)
+	for i := range values {
+		values[i] = math.Round((mid+step*float64(i-n/2))*100) / 100
+	}
+	return values
+}
@@ -0,0 +1,103 @@
+package valuation
+
+import (
+	"sort"
+)
+
+// MultiplesInputs are the company's fundamentals and its peers' multiples
+type MultiplesInputs struct {
+	EPS               float64   `json:"eps"`
+	EBITDA            float64   `json:"ebitda"`
+	Revenue           float64   `json:"revenue"`
+	BookValue         float64   `json:"book_value"`
+	NetDebt           float64   `json:"net_debt"`
+	SharesOutstanding float64   `json:"shares_outstanding"`
+	PeerPE            []float64 `json:"peer_pe,omitempty"`
+	PeerEVToEBITDA    []float64 `json:"peer_ev_to_ebitda,omitempty"`
+	PeerPS            []float64 `json:"peer_ps,omitempty"`
+	PeerPB            []float64 `json:"peer_pb,omitempty"`
+}
+
+// FairValueRange is the per share value implied by the 25th, 50th and 75th
+// percentile of a peer multiple
+type FairValueRange struct {
+	Method       string  `json:"method"`
+	LowMultiple  float64 `json:"low_multiple"`
+	MidMultiple  float64 `json:"mid_multiple"`
+	HighMultiple float64 `json:"high_multiple"`
+	Low          float64 `json:"low"`
+	Mid          float64 `json:"mid"`
+	High         float64 `json:"high"`
+}
+
+// Multiples values the company at its peers' multiples. Methods whose
+// fundamental is not positive or that have no peer multiples are skipped
+func Multiples(in MultiplesInputs) []FairValueRange {
+	if in.SharesOutstanding <= 0 {
+		return nil
+	}
+	perShare := func(metric float64) func(float64) float64 {
+		return func(multiple float64) float64 {
+			return multiple * metric / in.SharesOutstanding
+		}
+	}
+	enterprise := func(multiple float64) float64 {
+		return (multiple*in.EBITDA - in.NetDebt) / in.SharesOutstanding
+	}
+
+	methods := []struct {
+		name     string
+		metric   float64
+		multiple []float64
+		value    func(float64) float64
+	}{
+		{"P/E", in.EPS * in.SharesOutstanding, in.PeerPE, perShare(in.EPS * in.SharesOutstanding)},
+		{"EV/EBITDA", in.EBITDA, in.PeerEVToEBITDA, enterprise},
+		{"P/S", in.Revenue, in.PeerPS, perShare(in.Revenue)},
+		{"P/B", in.BookValue, in.PeerPB, perShare(in.BookValue)},
+	}
+
+	var ranges []FairValueRange
+	for _, m := range methods {
+		multiples := positive(m.multiple)
+		if m.metric <= 0 || len(multiples) == 0 {
+			continue
+		}
+		low, mid, high := quantile(multiples, 0.25), quantile(multiples, 0.5), quantile(multiples, 0.75)
+		ranges = append(ranges, FairValueRange{
+			Method:       m.name,
+			LowMultiple:  low,
+			MidMultiple:  mid,
+			HighMultiple: high,
+			Low:          m.value(low),
+			Mid:          m.value(mid),
+			High:         m.value(high),
+		})
+	}
+	return ranges
+}
+
+// drops zero and negative multiples, which are not meaningful
+func positive(values []float64) []float64 {
+	var out []float64
+	for _, v := range values {
+		if v > 0 {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// linearly interpolated quantile
+func quantile(values []float64, q float64) float64 {
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+	pos := q * float64(len(sorted)-1)
+	lower := int(pos)
+	if lower+1 >= len(sorted) {
+		return sorted[lower]
+	}
+	frac := pos - float64(lower)
+	return sorted[lower] + (sorted[lower+1]-sorted[lower])*frac
+}
@@ -0,0 +1,70 @@
+package valuation
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Report bundles the inputs and outputs of every model so the report and UI
+// can show the assumptions behind each number
+type Report struct {
+	Ticker        string            `json:"ticker"`
+	Inputs        DCFInputs         `json:"inputs"`
+	Multiples     MultiplesInputs   `json:"multiples_inputs"`
+	Sources       map[string]string `json:"sources"`
+	DCF           *DCFResult        `json:"dcf,omitempty"`
+	ImpliedGrowth *float64          `json:"implied_growth,omitempty"`
+	FairValues    []FairValueRange  `json:"fair_value_ranges,omitempty"`
+	Sensitivity   SensitivityTable  `json:"sensitivity"`
+	Errors        map[string]string `json:"errors,omitempty"`
+}
+
+// Run evaluates the DCF, reverse DCF, multiples and sensitivity models,
+// recording model errors instead of failing the whole report
+func Run(ticker string, in DCFInputs, multiples MultiplesInputs, sources map[string]string) Report {
+	report := Report{
+		Ticker:    ticker,
+		Inputs:    in,
+		Multiples: multiples,
+		Sources:   sources,
+		Errors:    make(map[string]string),
+	}
+
+	if res, err := DCF(in); err != nil {
+		report.Errors["dcf"] = err.Error()
+	} else {
+		report.DCF = &res
+	}
+	if growth, err := ImpliedGrowth(in); err != nil {
+		report.Errors["reverse_dcf"] = err.Error()
+	} else {
+		report.ImpliedGrowth = &growth
+	}
+	report.FairValues = Multiples(multiples)
+
+	rate := in.rate()
+	report.Sensitivity = Sensitivity(in, Steps(rate, 1, 5), Steps(in.TerminalGrowth, 0.5, 5))
+	return report
+}
+
+// Summary renders the valuation and its key assumptions as plain text for prompt templates
+func (r Report) Summary() string {
+	var b strings.Builder
+	in := r.Inputs
+	fmt.Fprintf(&b, "VALUATION FOR %s. CURRENT PRICE: $%.2f.", r.Ticker, in.CurrentPrice)
+	fmt.Fprintf(&b, " DCF ASSUMPTIONS: BASE FCF $%.0f, %d YEAR PROJECTION, GROWTH FADING FROM %.2f%% TO TERMINAL GROWTH %.2f%%, RISK FREE RATE %.2f%%, EQUITY RISK PREMIUM %.2f%%, BETA %.2f, PRE-TAX COST OF DEBT %.2f%%, TAX RATE %.2f%%, NET DEBT $%.0f.",
+		in.BaseFCF, in.Years, in.GrowthRate, in.TerminalGrowth, in.WACC.RiskFreeRate, in.WACC.EquityRiskPremium, in.WACC.Beta, in.WACC.PreTaxCostOfDebt, in.WACC.TaxRate, in.NetDebt)
+	if r.DCF != nil {
+		fmt.Fprintf(&b, " DCF RESULT: DISCOUNT RATE %.2f%%, ENTERPRISE VALUE $%.0f, FAIR VALUE PER SHARE $%.2f, UPSIDE %.2f%%.",
+			r.DCF.DiscountRate, r.DCF.EnterpriseValue, r.DCF.FairValuePerShare, r.DCF.UpsidePercent)
+	} else if err, ok := r.Errors["dcf"]; ok {
+		fmt.Fprintf(&b, " DCF NOT AVAILABLE: %s.", err)
+	}
+	if r.ImpliedGrowth != nil {
+		fmt.Fprintf(&b, " REVERSE DCF: THE CURRENT PRICE IMPLIES %.2f%% FIRST YEAR FCF GROWTH.", *r.ImpliedGrowth)
+	}
+	for _, fv := range r.FairValues {
+		fmt.Fprintf(&b, " %s FAIR VALUE RANGE: $%.2f TO $%.2f (MEDIAN $%.2f AT %.2fx).", fv.Method, fv.Low, fv.High, fv.Mid, fv.MidMultiple)
+	}
+	return b.String()
+}
@@ -0,0 +1,189 @@
+package vectorstore
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Filter is a metadata filter in Pinecone's syntax: field equality
+// {"ticker": "AAPL"}, operators {"current_date": {"$gte": 20240101}} with
+// $eq, $ne, $gt, $gte, $lt, $lte, $in, $nin and $exists, and the $and and
+// $or combinators over lists of filters
+type Filter map[string]interface{}
+
+// Matches reports whether metadata satisfies the filter. An empty filter matches everything
+func (f Filter) Matches(metadata map[string]interface{}) bool {
+	for key, condition := range f {
+		switch key {
+		case "$and":
+			for _, sub := range subFilters(condition) {
+				if !sub.Matches(metadata) {
+					return false
+				}
+			}
+		case "$or":
+			matched := false
+			for _, sub := range subFilters(condition) {
+				if sub.Matches(metadata) {
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return false
+			}
+		default:
+			value, present := metadata[key]
+			if !matchesCondition(value, present, condition) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Validate reports operators the filter does not support
+func (f Filter) Validate() error {
+	for key, condition := range f {
+		if key == "$and" || key == "$or" {
+			for _, sub := range subFilters(condition) {
+				if err := sub.Validate(); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if ops, ok := condition.(map[string]interface{}); ok {
+			for op := range ops {
+				switch op {
+				case "$eq", "$ne", "$gt", "$gte", "$lt", "$lte", "$in", "$nin", "$exists":
+				default:
+					return fmt.Errorf("unsupported filter operator %s on %s", op, key)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func subFilters(condition interface{}) []Filter {
+	var filters []Filter
+	switch list := condition.(type) {
+	case []interface{}:
+		for _, item := range list {
+			switch sub := item.(type) {
+			case map[string]interface{}:
+				filters = append(filters, Filter(sub))
+			case Filter:
+				filters = append(filters, sub)
+			}
+		}
+	case []map[string]interface{}:
+		for _, sub := range list {
+			filters = append(filters, Filter(sub))
+		}
+	case []Filter:
+		filters = list
+	}
+	return filters
+}
+
+func matchesCondition(value interface{}, present bool, condition interface{}) bool {
+	ops, ok := condition.(map[string]interface{})
+	if !ok {
+		return present && equal(value, condition)
+	}
+	for op, operand := range ops {
+		var matched bool
+		switch op {
+		case "$eq":
+			matched = present && equal(value, operand)
+		case "$ne":
+			matched = !present || !equal(value, operand)
+		case "$gt", "$gte", "$lt", "$lte":
+			matched = present && compare(value, operand, op)
+		case "$in":
+			matched = present && in(value, operand)
+		case "$nin":
+			matched = !present || !in(value, operand)
+		case "$exists":
+			want, _ := operand.(bool)
+			matched = present == want
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// toFloat converts any numeric metadata value, JSON and structpb decode
+// numbers as float64 while callers build filters with ints
+func toFloat(v interface{}) (float64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+	return 0, false
+}
+
+func equal(a interface{}, b interface{}) bool {
+	if fa, ok := toFloat(a); ok {
+		fb, ok := toFloat(b)
+		return ok && fa == fb
+	}
+	// list metadata matches when it contains the value
+	if list, ok := a.([]interface{}); ok {
+		for _, item := range list {
+			if equal(item, b) {
+				return true
+			}
+		}
+		return false
+	}
+	if list, ok := a.([]string); ok {
+		for _, item := range list {
+			if item == b {
+				return true
+			}
+		}
+		return false
+	}
+	return a == b
+}
+
+func compare(a interface{}, b interface{}, op string) bool {
+	fa, okA := toFloat(a)
+	fb, okB := toFloat(b)
+	if !okA || !okB {
+		return false
+	}
+	switch op {
+	case "$gt":
+		return fa > fb
+	case "$gte":
+		return fa >= fb
+	case "$lt":
+		return fa < fb
+	default:
+		return fa <= fb
+	}
+}
+
+func in(value interface{}, operand interface{}) bool {
+	rv := reflect.ValueOf(operand)
+	if rv.Kind() != reflect.Slice {
+		return false
+	}
+	for i := 0; i < rv.Len(); i++ {
+		if equal(value, rv.Index(i).Interface()) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+package vectorstore
+
+import "testing"
+
+func TestFilterMatches(t *testing.T) {
+	metadata := map[string]interface{}{
+		"ticker":       "AAPL",
+		"section":      "fin",
+		"current_date": float64(20240315),
+		"chunk":        2,
+	}
+	cases := []struct {
+		name   string
+		filter Filter
+		want   bool
+	}{
+		{"empty", Filter{}, true},
+		{"equal", Filter{"ticker": "AAPL"}, true},
+		{"not equal", Filter{"ticker": "MSFT"}, false},
+		{"eq", Filter{"section": map[string]interface{}{"$eq": "fin"}}, true},
+		{"ne", Filter{"section": map[string]interface{}{"$ne": "fin"}}, false},
+		{"range", Filter{"current_date": map[string]interface{}{"$gte": 20240101, "$lte": 20241231}}, true},
+		{"outside range", Filter{"current_date": map[string]interface{}{"$gt": 20240315}}, false},
+		{"int against float", Filter{"chunk": float64(2)}, true},
+		{"lt", Filter{"chunk": map[string]interface{}{"$lt": 2}}, false},
+		{"in", Filter{"ticker": map[string]interface{}{"$in": []interface{}{"MSFT", "AAPL"}}}, true},
+		{"in strings", Filter{"ticker": map[string]interface{}{"$in": []string{"MSFT"}}}, false},
+		{"nin", Filter{"ticker": map[string]interface{}{"$nin": []interface{}{"MSFT"}}}, true},
+		{"exists", Filter{"url": map[string]interface{}{"$exists": false}}, true},
+		{"missing field", Filter{"url": "https://example.com"}, false},
+		{"and", Filter{"$and": []Filter{{"ticker": "AAPL"}, {"section": "news"}}}, false},
+		{"or", Filter{"$or": []Filter{{"ticker": "MSFT"}, {"section": "fin"}}}, true},
+		{"or of maps", Filter{"$or": []interface{}{map[string]interface{}{"ticker": "MSFT"}}}, false},
+		{"nested", Filter{"$and": []Filter{
+			{"current_date": map[string]interface{}{"$gte": 20240101}},
+			{"$or": []Filter{{"ticker": "AAPL"}, {"ticker": map[string]interface{}{"$exists": false}}}},
+		}}, true},
+	}
+	for _, c := range cases {
+		if got := c.filter.Matches(metadata); got != c.want {
+			t.Errorf("%s: %v matches %v, want %v", c.name, c.filter, got, c.want)
+		}
+	}
+}
+
+func TestFilterValidate(t *testing.T) {
+	valid := Filter{"$or": []Filter{{"ticker": "AAPL"}, {"current_date": map[string]interface{}{"$gte": 20240101}}}}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("valid filter: %v", err)
+	}
+	invalid := Filter{"$and": []Filter{{"ticker": map[string]interface{}{"$regex": "A.*"}}}}
+	if err := invalid.Validate(); err == nil {
+		t.Error("a filter with $regex should not validate")
+	}
+}
@@ -0,0 +1,267 @@
+package vectorstore
+
+import (
+	"container/heap"
+	"math"
+	"math/rand"
+)
+
+// HNSWParams tune the hierarchical navigable small world graph
+type HNSWParams struct {
+	// M is the number of neighbours per node above layer 0, 2M on layer 0
+	M int
+	// EfConstruction is the candidate list size while inserting
+	EfConstruction int
+	// EfSearch is the candidate list size while querying
+	EfSearch int
+}
+
+// DefaultHNSWParams suit indexes up to a few hundred thousand vectors
+func DefaultHNSWParams() HNSWParams {
+	return HNSWParams{M: 16, EfConstruction: 200, EfSearch: 64}
+}
+
+type hnswNode struct {
+	id      string
+	vec     []float32
+	deleted bool
+	// friends holds the neighbours on each layer the node is on
+	friends [][]int
+}
+
+// hnsw is an approximate nearest neighbour graph over unit vectors. Deleted
+// and replaced vectors are tombstoned and dropped when the graph is rebuilt
+type hnsw struct {
+	params   HNSWParams
+	levelMul float64
+	nodes    []*hnswNode
+	byID     map[string]int
+	entry    int
+	maxLevel int
+	deleted  int
+	rng      *rand.Rand
+}
+
+func newHNSW(params HNSWParams) *hnsw {
+	if params.M <= 1 {
+		params.M = DefaultHNSWParams().M
+	}
+	if params.EfConstruction <= 0 {
+		params.EfConstruction = DefaultHNSWParams().EfConstruction
+	}
+	if params.EfSearch <= 0 {
+		params.EfSearch = DefaultHNSWParams().EfSearch
+	}
+	return &hnsw{
+		params:   params,
+		levelMul: 1 / math.Log(float64(params.M)),
+		byID:     make(map[string]int),
+		entry:    -1,
+		// a fixed seed keeps rebuilt graphs identical for the same input
+		rng: rand.New(rand.NewSource(1)),
+	}
+}
+
+func (h *hnsw) distance(a []float32, b []float32) float64 {
+	return 1 - dot(a, b)
+}
+
+func (h *hnsw) maxFriends(layer int) int {
+	if layer == 0 {
+		return 2 * h.params.M
+	}
+	return h.params.M
+}
+
+// insert adds a unit vector, replacing the node of an existing id
+func (h *hnsw) insert(id string, vec []float32) {
+	h.remove(id)
+	level := int(math.Floor(-math.Log(1-h.rng.Float64()) * h.levelMul))
+	node := &hnswNode{id: id, vec: vec, friends: make([][]int, level+1)}
+	index := len(h.nodes)
+	h.nodes = append(h.nodes, node)
+	h.byID[id] = index
+
+	if h.entry < 0 {
+		h.entry = index
+		h.maxLevel = level
+		return
+	}
+
+	ep := h.entry
+	for layer := h.maxLevel; layer > level; layer-- {
+		ep = h.greedy(vec, ep, layer)
+	}
+	entryPoints := []int{ep}
+	for layer := minInt(level, h.maxLevel); layer >= 0; layer-- {
+		found := h.searchLayer(vec, entryPoints, h.params.EfConstruction, layer)
+		neighbours := h.prune(vec, found, h.maxFriends(layer))
+		node.friends[layer] = neighbours
+		for _, n := range neighbours {
+			friend := h.nodes[n]
+			friend.friends[layer] = append(friend.friends[layer], index)
+			if len(friend.friends[layer]) > h.maxFriends(layer) {
+				friend.friends[layer] = h.prune(friend.vec, friend.friends[layer], h.maxFriends(layer))
+			}
+		}
+		entryPoints = found
+	}
+	if level > h.maxLevel {
+		h.entry = index
+		h.maxLevel = level
+	}
+}
+
+// remove tombstones the node of an id
+func (h *hnsw) remove(id string) {
+	if index, ok := h.byID[id]; ok {
+		h.nodes[index].deleted = true
+		delete(h.byID, id)
+		h.deleted++
+	}
+}
+
+// search returns up to k live node indexes closest to the unit vector
+func (h *hnsw) search(vec []float32, k int, ef int) []int {
+	if h.entry < 0 {
+		return nil
+	}
+	if ef < k {
+		ef = k
+	}
+	ep := h.entry
+	for layer := h.maxLevel; layer > 0; layer-- {
+		ep = h.greedy(vec, ep, layer)
+	}
+	found := h.searchLayer(vec, []int{ep}, ef, 0)
+	var live []int
+	for _, index := range h.sortByDistance(vec, found) {
+		if !h.nodes[index].deleted {
+			live = append(live, index)
+			if len(live) == k {
+				break
+			}
+		}
+	}
+	return live
+}
+
+// greedy walks to the closest node on a layer
+func (h *hnsw) greedy(vec []float32, ep int, layer int) int {
+	best := h.distance(vec, h.nodes[ep].vec)
+	for changed := true; changed; {
+		changed = false
+		for _, n := range h.nodes[ep].friends[layer] {
+			if d := h.distance(vec, h.nodes[n].vec); d < best {
+				best, ep, changed = d, n, true
+			}
+		}
+	}
+	return ep
+}
+
+// searchLayer is the beam search of the HNSW paper, returning up to ef nodes
+func (h *hnsw) searchLayer(vec []float32, entryPoints []int, ef int, layer int) []int {
+	visited := map[int]bool{}
+	candidates := &distHeap{}
+	results := &distHeap{max: true}
+	for _, ep := range entryPoints {
+		if visited[ep] {
+			continue
+		}
+		visited[ep] = true
+		d := h.distance(vec, h.nodes[ep].vec)
+		heap.Push(candidates, distItem{ep, d})
+		heap.Push(results, distItem{ep, d})
+		if results.Len() > ef {
+			heap.Pop(results)
+		}
+	}
+	for candidates.Len() > 0 {
+		current := heap.Pop(candidates).(distItem)
+		if results.Len() >= ef && current.dist > results.items[0].dist {
+			break
+		}
+		node := h.nodes[current.index]
+		if layer >= len(node.friends) {
+			continue
+		}
+		for _, n := range node.friends[layer] {
+			if visited[n] {
+				continue
+			}
+			visited[n] = true
+			d := h.distance(vec, h.nodes[n].vec)
+			if results.Len() < ef || d < results.items[0].dist {
+				heap.Push(candidates, distItem{n, d})
+				heap.Push(results, distItem{n, d})
+				if results.Len() > ef {
+					heap.Pop(results)
+				}
+			}
+		}
+	}
+	found := make([]int, len(results.items))
+	for i, item := range results.items {
+		found[i] = item.index
+	}
+	return found
+}
+
+func (h *hnsw) sortByDistance(vec []float32, indexes []int) []int {
+	items := &distHeap{}
+	for _, index := range indexes {
+		heap.Push(items, distItem{index, h.distance(vec, h.nodes[index].vec)})
+	}
+	sorted := make([]int, 0, len(indexes))
+	for items.Len() > 0 {
+		sorted = append(sorted, heap.Pop(items).(distItem).index)
+	}
+	return sorted
+}
+
+// prune keeps the n neighbours closest to vec
+func (h *hnsw) prune(vec []float32, indexes []int, n int) []int {
+	sorted := h.sortByDistance(vec, indexes)
+	if len(sorted) > n {
+		sorted = sorted[:n]
+	}
+	return sorted
+}
+
+func minInt(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+type distItem struct {
+	index int
+	dist  float64
+}
+
+// distHeap is a min heap by distance, or a max heap when max is set
+type distHeap struct {
+	items []distItem
+	max   bool
+}
+
+func (h distHeap) Len() int { return len(h.items) }
+
+func (h distHeap) Less(i, j int) bool {
+	if h.max {
+		return h.items[i].dist > h.items[j].dist
+	}
+	return h.items[i].dist < h.items[j].dist
+}
+
+func (h distHeap) Swap(i, j int) { h.items[i], h.items[j] = h.items[j], h.items[i] }
+
+func (h *distHeap) Push(x interface{}) { h.items = append(h.items, x.(distItem)) }
+
+func (h *distHeap) Pop() interface{} {
+	last := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+	return last
+}
@@ -0,0 +1,275 @@
+package vectorstore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// Index types of the local store
+const (
+	IndexFlat = "flat"
+	IndexHNSW = "hnsw"
+)
+
+// bruteForceLimit is the filtered set size below which an exact scan is
+// cheaper than searching the graph and filtering its results
+const bruteForceLimit = 2000
+
+// LocalOptions configure the embedded store
+type LocalOptions struct {
+	// Path is the JSON file the vectors are persisted to, empty keeps them in memory
+	Path string
+	// Index is IndexFlat for exact search or IndexHNSW for approximate search
+	Index string
+	HNSW  HNSWParams
+}
+
+// Local is an embedded vector store. Vectors live in memory and are written
+// to Path after every change, the HNSW graph is rebuilt from them on open
+type Local struct {
+	opts LocalOptions
+
+	mu        sync.RWMutex
+	dimension int
+	vectors   map[string]Vector
+	unit      map[string][]float32
+	graph     *hnsw
+}
+
+// persisted is the on-disk form of the store
+type persisted struct {
+	Dimension int      `json:"dimension"`
+	Vectors   []Vector `json:"vectors"`
+}
+
+// OpenLocal opens the store at opts.Path, creating it when the file does not exist
+func OpenLocal(opts LocalOptions) (*Local, error) {
+	if opts.Index == "" {
+		opts.Index = IndexHNSW
+	}
+	if opts.Index != IndexFlat && opts.Index != IndexHNSW {
+		return nil, fmt.Errorf("unknown index type %q", opts.Index)
+	}
+	l := &Local{opts: opts, vectors: make(map[string]Vector), unit: make(map[string][]float32)}
+	l.resetGraph()
+	if opts.Path == "" {
+		return l, nil
+	}
+
+	data, err := os.ReadFile(opts.Path)
+	if os.IsNotExist(err) {
+		return l, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var stored persisted
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", opts.Path, err)
+	}
+	l.dimension = stored.Dimension
+	// insert in id order so the rebuilt graph does not depend on file order
+	sort.Slice(stored.Vectors, func(i, j int) bool { return stored.Vectors[i].ID < stored.Vectors[j].ID })
+	for _, v := range stored.Vectors {
+		l.put(v)
+	}
+	return l, nil
+}
+
+// Dimension is the vector length of the store, 0 while it is empty
+func (l *Local) Dimension(ctx context.Context) (int, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.dimension, nil
+}
+
+// Len is the number of stored vectors
+func (l *Local) Len() int {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return len(l.vectors)
+}
+
+func (l *Local) resetGraph() {
+	if l.opts.Index == IndexHNSW {
+		l.graph = newHNSW(l.opts.HNSW)
+	}
+}
+
+func (l *Local) put(v Vector) {
+	unit := normalize(v.Values)
+	l.vectors[v.ID] = v
+	l.unit[v.ID] = unit
+	if l.graph != nil {
+		l.graph.insert(v.ID, unit)
+	}
+}
+
+func (l *Local) Upsert(ctx context.Context, vectors []Vector) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	dimension := l.dimension
+	for _, v := range vectors {
+		if dimension == 0 {
+			dimension = len(v.Values)
+		}
+		if len(v.Values) != dimension {
+			return fmt.Errorf("%w: %s has %d values, the store has %d", ErrDimension, v.ID, len(v.Values), dimension)
+		}
+	}
+	l.dimension = dimension
+	for _, v := range vectors {
+		l.put(v)
+	}
+	l.compact()
+	return l.save()
+}
+
+func (l *Local) Query(ctx context.Context, req QueryRequest) ([]Match, error) {
+	if err := req.Filter.Validate(); err != nil {
+		return nil, err
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if len(l.vectors) == 0 || req.TopK <= 0 {
+		return nil, nil
+	}
+	if len(req.Vector) != l.dimension {
+		return nil, fmt.Errorf("%w: query has %d values, the store has %d", ErrDimension, len(req.Vector), l.dimension)
+	}
+	query := normalize(req.Vector)
+
+	var matching []string
+	for id, v := range l.vectors {
+		if req.Filter.Matches(v.Metadata) {
+			matching = append(matching, id)
+		}
+	}
+
+	var ids []string
+	if l.graph != nil && len(matching) > bruteForceLimit {
+		// over-fetch from the graph in proportion to how selective the filter is
+		ef := l.graph.params.EfSearch
+		k := req.TopK * len(l.vectors) / len(matching)
+		for _, index := range l.graph.search(query, k, ef) {
+			id := l.graph.nodes[index].id
+			if req.Filter.Matches(l.vectors[id].Metadata) {
+				ids = append(ids, id)
+			}
+		}
+	}
+	if len(ids) < req.TopK {
+		ids = matching
+	}
+
+	matches := make([]Match, 0, len(ids))
+	for _, id := range ids {
+		v := l.vectors[id]
+		if !req.IncludeValues {
+			v.Values = nil
+		}
+		matches = append(matches, Match{Vector: v, Score: dot(query, l.unit[id])})
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].ID < matches[j].ID
+	})
+	if len(matches) > req.TopK {
+		matches = matches[:req.TopK]
+	}
+	return matches, nil
+}
+
+func (l *Local) Fetch(ctx context.Context, ids []string) (map[string]Vector, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	found := make(map[string]Vector)
+	for _, id := range ids {
+		if v, ok := l.vectors[id]; ok {
+			found[id] = v
+		}
+	}
+	return found, nil
+}
+
+func (l *Local) DeleteByFilter(ctx context.Context, filter Filter) error {
+	if len(filter) == 0 {
+		return ErrEmptyFilter
+	}
+	if err := filter.Validate(); err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for id, v := range l.vectors {
+		if filter.Matches(v.Metadata) {
+			delete(l.vectors, id)
+			delete(l.unit, id)
+			if l.graph != nil {
+				l.graph.remove(id)
+			}
+		}
+	}
+	if len(l.vectors) == 0 {
+		l.dimension = 0
+	}
+	l.compact()
+	return l.save()
+}
+
+// compact rebuilds the graph once a quarter of its nodes are tombstones
+func (l *Local) compact() {
+	if l.graph == nil || l.graph.deleted == 0 || l.graph.deleted*4 < len(l.graph.nodes) {
+		return
+	}
+	l.resetGraph()
+	ids := make([]string, 0, len(l.vectors))
+	for id := range l.vectors {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		l.graph.insert(id, l.unit[id])
+	}
+}
+
+// save writes the vectors to a temporary file and renames it over Path so a
+// crash never leaves a truncated store
+func (l *Local) save() error {
+	if l.opts.Path == "" {
+		return nil
+	}
+	stored := persisted{Dimension: l.dimension, Vectors: make([]Vector, 0, len(l.vectors))}
+	for _, v := range l.vectors {
+		stored.Vectors = append(stored.Vectors, v)
+	}
+	sort.Slice(stored.Vectors, func(i, j int) bool { return stored.Vectors[i].ID < stored.Vectors[j].ID })
+	data, err := json.Marshal(stored)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(l.opts.Path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(l.opts.Path), ".vectors-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), l.opts.Path)
+}
@@ -0,0 +1,215 @@
+package vectorstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"path/filepath"
+	"testing"
+)
+
+// random vectors tagged with one of four groups, enough of them that
+// unfiltered queries search the graph instead of scanning
+func randomVectors(n int, dimension int, seed int64) []Vector {
+	rng := rand.New(rand.NewSource(seed))
+	vectors := make([]Vector, n)
+	for i := range vectors {
+		values := make([]float32, dimension)
+		for j := range values {
+			values[j] = float32(rng.NormFloat64())
+		}
+		vectors[i] = Vector{
+			ID:       fmt.Sprintf("v%05d", i),
+			Values:   values,
+			Metadata: map[string]interface{}{"group": float64(i % 4), "chunk": float64(i)},
+		}
+	}
+	return vectors
+}
+
+func openStore(t *testing.T, opts LocalOptions, vectors []Vector) *Local {
+	t.Helper()
+	store, err := OpenLocal(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vectors) > 0 {
+		if err := store.Upsert(context.Background(), vectors); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return store
+}
+
+func ids(matches []Match) []string {
+	out := make([]string, len(matches))
+	for i, m := range matches {
+		out[i] = m.ID
+	}
+	return out
+}
+
+func TestHNSWRecall(t *testing.T) {
+	const dimension, k = 16, 10
+	vectors := randomVectors(3*bruteForceLimit/2, dimension, 1)
+	flat := openStore(t, LocalOptions{Index: IndexFlat}, vectors)
+	graph := openStore(t, LocalOptions{Index: IndexHNSW}, vectors)
+
+	queries := randomVectors(50, dimension, 2)
+	found, total := 0, 0
+	for _, q := range queries {
+		req := QueryRequest{Vector: q.Values, TopK: k}
+		exact, err := flat.Query(context.Background(), req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		approximate, err := graph.Query(context.Background(), req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(approximate) != k {
+			t.Fatalf("got %d matches, want %d", len(approximate), k)
+		}
+		want := make(map[string]bool)
+		for _, id := range ids(exact) {
+			want[id] = true
+		}
+		for _, id := range ids(approximate) {
+			if want[id] {
+				found++
+			}
+		}
+		total += k
+	}
+	if recall := float64(found) / float64(total); recall < 0.9 {
+		t.Errorf("recall@%d is %.2f against the flat index, want at least 0.90", k, recall)
+	}
+}
+
+func TestUpsertReplacesID(t *testing.T) {
+	for _, index := range []string{IndexFlat, IndexHNSW} {
+		t.Run(index, func(t *testing.T) {
+			store := openStore(t, LocalOptions{Index: index}, []Vector{
+				{ID: "a", Values: []float32{1, 0, 0}, Metadata: map[string]interface{}{"version": float64(1)}},
+				{ID: "b", Values: []float32{0, 1, 0}},
+			})
+			err := store.Upsert(context.Background(), []Vector{
+				{ID: "a", Values: []float32{0, 0, 1}, Metadata: map[string]interface{}{"version": float64(2)}},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if store.Len() != 2 {
+				t.Fatalf("store has %d vectors after replacing one, want 2", store.Len())
+			}
+
+			matches, err := store.Query(context.Background(), QueryRequest{Vector: []float32{0, 0, 1}, TopK: 3})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(matches) != 2 || matches[0].ID != "a" || matches[0].Metadata["version"] != float64(2) {
+				t.Fatalf("got %+v, want the replaced a first", matches)
+			}
+			if matches[0].Score < 0.999 {
+				t.Errorf("a scores %.3f against its new vector, want 1", matches[0].Score)
+			}
+
+			if err := store.Upsert(context.Background(), []Vector{{ID: "c", Values: []float32{1, 0}}}); !errors.Is(err, ErrDimension) {
+				t.Errorf("upserting a shorter vector returned %v, want ErrDimension", err)
+			}
+		})
+	}
+}
+
+func TestDeleteByFilterCompacts(t *testing.T) {
+	const dimension = 8
+	vectors := randomVectors(400, dimension, 3)
+	store := openStore(t, LocalOptions{Index: IndexHNSW}, vectors)
+
+	if err := store.DeleteByFilter(context.Background(), nil); !errors.Is(err, ErrEmptyFilter) {
+		t.Fatalf("deleting without a filter returned %v, want ErrEmptyFilter", err)
+	}
+	// tombstones under a quarter of the graph are kept, past it the graph is rebuilt
+	if err := store.DeleteByFilter(context.Background(), Filter{"chunk": map[string]interface{}{"$lt": 40}}); err != nil {
+		t.Fatal(err)
+	}
+	if store.graph.deleted != 40 || len(store.graph.nodes) != 400 {
+		t.Fatalf("graph has %d nodes and %d tombstones, want 400 and 40", len(store.graph.nodes), store.graph.deleted)
+	}
+	if err := store.DeleteByFilter(context.Background(), Filter{"group": map[string]interface{}{"$in": []interface{}{0, 1}}}); err != nil {
+		t.Fatal(err)
+	}
+	if store.Len() != 180 {
+		t.Fatalf("store has %d vectors, want 180", store.Len())
+	}
+	if store.graph.deleted != 0 || len(store.graph.nodes) != 180 {
+		t.Fatalf("graph has %d nodes and %d tombstones, want a compacted graph of 180", len(store.graph.nodes), store.graph.deleted)
+	}
+
+	for _, q := range randomVectors(20, dimension, 4) {
+		matches, err := store.Query(context.Background(), QueryRequest{Vector: q.Values, TopK: 5})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, m := range matches {
+			if group := m.Metadata["group"]; group == float64(0) || group == float64(1) || m.Metadata["chunk"].(float64) < 40 {
+				t.Fatalf("query returned deleted vector %s of group %v", m.ID, group)
+			}
+		}
+	}
+
+	// deleting everything resets the dimension
+	if err := store.DeleteByFilter(context.Background(), Filter{"chunk": map[string]interface{}{"$exists": true}}); err != nil {
+		t.Fatal(err)
+	}
+	if dimension, _ := store.Dimension(context.Background()); dimension != 0 || store.Len() != 0 {
+		t.Errorf("empty store has dimension %d and %d vectors, want 0 and 0", dimension, store.Len())
+	}
+}
+
+func TestSaveAndReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store", "vectors.json")
+	vectors := randomVectors(50, 6, 5)
+	store := openStore(t, LocalOptions{Path: path, Index: IndexHNSW}, vectors)
+	if err := store.DeleteByFilter(context.Background(), Filter{"group": float64(3)}); err != nil {
+		t.Fatal(err)
+	}
+	query := QueryRequest{Vector: vectors[0].Values, TopK: 5, Filter: Filter{"chunk": map[string]interface{}{"$lt": 40}}}
+	before, err := store.Query(context.Background(), query)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	matches, _ := filepath.Glob(filepath.Join(filepath.Dir(path), ".vectors-*"))
+	if len(matches) != 0 {
+		t.Errorf("temporary files left behind: %v", matches)
+	}
+
+	for _, index := range []string{IndexHNSW, IndexFlat} {
+		reopened, err := OpenLocal(LocalOptions{Path: path, Index: index})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if reopened.Len() != store.Len() {
+			t.Fatalf("reopened %s store has %d vectors, want %d", index, reopened.Len(), store.Len())
+		}
+		if dimension, _ := reopened.Dimension(context.Background()); dimension != 6 {
+			t.Errorf("reopened %s store has dimension %d, want 6", index, dimension)
+		}
+		after, err := reopened.Query(context.Background(), query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fmt.Sprint(ids(after)) != fmt.Sprint(ids(before)) {
+			t.Errorf("reopened %s store returned %v, want %v", index, ids(after), ids(before))
+		}
+		fetched, _ := reopened.Fetch(context.Background(), []string{"v00000", "v00003"})
+		if _, ok := fetched["v00003"]; ok {
+			t.Errorf("reopened %s store still has deleted v00003", index)
+		}
+		if v, ok := fetched["v00000"]; !ok || v.Metadata["chunk"] != float64(0) || len(v.Values) != 6 {
+			t.Errorf("reopened %s store lost v00000: %+v", index, v)
+		}
+	}
+}
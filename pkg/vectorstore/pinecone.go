@@ -0,0 +1,156 @@
+package vectorstore
+
+import (
+	"context"
+
+	"github.com/pinecone-io/go-pinecone/pinecone"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// Pinecone adapts a Pinecone index connection to Store
+type Pinecone struct {
+	Index *pinecone.IndexConnection
+}
+
+// NewPinecone connects to the index at host
+func NewPinecone(apiKey string, host string) (*Pinecone, error) {
+	client, err := pinecone.NewClient(pinecone.NewClientParams{ApiKey: apiKey, Host: host})
+	if err != nil {
+		return nil, err
+	}
+	index, err := client.Index(pinecone.NewIndexConnParams{Host: host})
+	if err != nil {
+		return nil, err
+	}
+	return &Pinecone{Index: index}, nil
+}
+
+func toStruct(m map[string]interface{}) (*structpb.Struct, error) {
+	if len(m) == 0 {
+		return nil, nil
+	}
+	return structpb.NewStruct(normalizeFilter(m))
+}
+
+// normalizeFilter converts Filter and typed lists, which structpb rejects,
+// into plain maps and []interface{}
+func normalizeFilter(m map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(m))
+	for key, value := range m {
+		out[key] = normalizeValue(value)
+	}
+	return out
+}
+
+func normalizeValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case Filter:
+		return normalizeFilter(v)
+	case map[string]interface{}:
+		return normalizeFilter(v)
+	case []Filter:
+		list := make([]interface{}, len(v))
+		for i, f := range v {
+			list[i] = normalizeFilter(f)
+		}
+		return list
+	case []map[string]interface{}:
+		list := make([]interface{}, len(v))
+		for i, f := range v {
+			list[i] = normalizeFilter(f)
+		}
+		return list
+	case []string:
+		list := make([]interface{}, len(v))
+		for i, s := range v {
+			list[i] = s
+		}
+		return list
+	case []int:
+		list := make([]interface{}, len(v))
+		for i, n := range v {
+			list[i] = n
+		}
+		return list
+	}
+	return value
+}
+
+func fromPinecone(v *pinecone.Vector) Vector {
+	out := Vector{ID: v.Id, Values: v.Values}
+	if v.Metadata != nil {
+		out.Metadata = v.Metadata.AsMap()
+	}
+	return out
+}
+
+func (p *Pinecone) Upsert(ctx context.Context, vectors []Vector) error {
+	batch := make([]*pinecone.Vector, 0, len(vectors))
+	for _, v := range vectors {
+		metadata, err := toStruct(v.Metadata)
+		if err != nil {
+			return err
+		}
+		batch = append(batch, &pinecone.Vector{Id: v.ID, Values: v.Values, Metadata: metadata})
+	}
+	_, err := p.Index.UpsertVectors(ctx, batch)
+	return err
+}
+
+func (p *Pinecone) Query(ctx context.Context, req QueryRequest) ([]Match, error) {
+	filter, err := toStruct(req.Filter)
+	if err != nil {
+		return nil, err
+	}
+	res, err := p.Index.QueryByVectorValues(ctx, &pinecone.QueryByVectorValuesRequest{
+		Vector:          req.Vector,
+		TopK:            uint32(req.TopK),
+		MetadataFilter:  filter,
+		IncludeValues:   req.IncludeValues,
+		IncludeMetadata: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	matches := make([]Match, 0, len(res.Matches))
+	for _, m := range res.Matches {
+		if m.Vector == nil {
+			continue
+		}
+		matches = append(matches, Match{Vector: fromPinecone(m.Vector), Score: float64(m.Score)})
+	}
+	return matches, nil
+}
+
+func (p *Pinecone) Fetch(ctx context.Context, ids []string) (map[string]Vector, error) {
+	res, err := p.Index.FetchVectors(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	found := make(map[string]Vector, len(res.Vectors))
+	for id, v := range res.Vectors {
+		if v != nil {
+			found[id] = fromPinecone(v)
+		}
+	}
+	return found, nil
+}
+
+func (p *Pinecone) DeleteByFilter(ctx context.Context, filter Filter) error {
+	if len(filter) == 0 {
+		return ErrEmptyFilter
+	}
+	metadataFilter, err := toStruct(filter)
+	if err != nil {
+		return err
+	}
+	return p.Index.DeleteVectorsByFilter(ctx, metadataFilter)
+}
+
+func (p *Pinecone) Dimension(ctx context.Context) (int, error) {
+	stats, err := p.Index.DescribeIndexStats(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return int(stats.Dimension), nil
+}
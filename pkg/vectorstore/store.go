@@ -0,0 +1,69 @@
+package vectorstore
+
+import (
+	"context"
+	"errors"
+	"math"
+)
+
+// Vector is an embedding with its metadata. Chunk text is kept in the
+// "text" metadata field
+type Vector struct {
+	ID       string                 `json:"id"`
+	Values   []float32              `json:"values"`
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// Match is a query result, Score is the cosine similarity
+type Match struct {
+	Vector
+	Score float64 `json:"score"`
+}
+
+// QueryRequest is a nearest neighbour query restricted by a metadata filter
+type QueryRequest struct {
+	Vector []float32
+	TopK   int
+	Filter Filter
+	// IncludeValues returns the stored values with the matches
+	IncludeValues bool
+}
+
+// Store is a vector index. Pinecone is the hosted implementation and Local
+// the embedded one
+type Store interface {
+	Upsert(ctx context.Context, vectors []Vector) error
+	Query(ctx context.Context, req QueryRequest) ([]Match, error)
+	Fetch(ctx context.Context, ids []string) (map[string]Vector, error)
+	DeleteByFilter(ctx context.Context, filter Filter) error
+	// Dimension is the vector length the store holds, 0 when it accepts any
+	Dimension(ctx context.Context) (int, error)
+}
+
+// ErrDimension is returned for vectors whose length does not match the store
+var ErrDimension = errors.New("vector dimension does not match the store")
+
+// ErrEmptyFilter is returned by DeleteByFilter without a filter, which would
+// otherwise delete every vector
+var ErrEmptyFilter = errors.New("delete needs a metadata filter")
+
+func dot(a []float32, b []float32) float64 {
+	var sum float64
+	for i := range a {
+		sum += float64(a[i]) * float64(b[i])
+	}
+	return sum
+}
+
+// normalize returns a unit length copy so cosine similarity is a dot product
+func normalize(v []float32) []float32 {
+	norm := math.Sqrt(dot(v, v))
+	out := make([]float32, len(v))
+	if norm == 0 {
+		return out
+	}
+	for i, x := range v {
+		out[i] = float32(float64(x) / norm)
+	}
+	return out
+}
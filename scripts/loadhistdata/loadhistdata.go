@@ -1,17 +1,22 @@
 package main
 
 import (
-	"bufio"
 	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
+	"mime/multipart"
 	"net/http"
 	"os"
-	"regexp"
 	"strings"
+
+	"fineas/pkg/ingest"
 )
 
+// chunks per request, so each batch is embedded well within the ingestor's
+// two minute timeout
+const batchChunks = 50
+
 func main() {
 	var filePath string
 	var ticker string
@@ -33,109 +38,83 @@ func main() {
 		os.Exit(1)
 	}
 
-	// Convert content to a string
-	text := string(content)
-	sentences := splitIntoSentences(text)
-
-	chunkSize := 6
-	overlap := 2
-
-	for i := 0; i < len(sentences); i += chunkSize - overlap {
-		end := i + chunkSize
+	// the ingestor splits each batch into overlapping sentence chunks. Batches
+	// hold whole chunks and share the overlap, so the chunks are the same as
+	// for the file in one request
+	opts := ingest.DefaultChunkOptions()
+	stride := opts.Sentences - opts.Overlap
+	sentences := ingest.SplitSentences(string(content))
+	failed := 0
+	for start := 0; start < len(sentences); start += batchChunks * stride {
+		end := start + batchChunks*stride + opts.Overlap
 		if end > len(sentences) {
 			end = len(sentences)
 		}
-		chunk := sentences[i:end]
-		chunkText := strings.Join(chunk, " ")
 
 		var histData HISTDATA
-		histData.Info = chunkText
+		histData.Info = strings.Join(sentences[start:end], " ")
 		jsonPostData, err := json.Marshal(histData)
 		if err != nil {
 			fmt.Println("Error marshaling JSON:", err)
-			continue
+			os.Exit(1)
 		}
 
-		res := postFinancialData(string(jsonPostData), []string{ticker}, accessKey)
-		fmt.Println(res)
-	}
-}
-
-// splitIntoSentences attempts to split text into sentences more accurately.
-func splitIntoSentences(text string) []string {
-	var sentences []string
-	scanner := bufio.NewScanner(strings.NewReader(text))
-	scanner.Split(bufio.ScanWords)
-
-	var currentSentence strings.Builder
-	for scanner.Scan() {
-		word := scanner.Text()
-		currentSentence.WriteString(word + " ")
-
-		if isEndOfSentence(word) {
-			sentences = append(sentences, strings.TrimSpace(currentSentence.String()))
-			currentSentence.Reset()
+		if err := postFinancialData(string(jsonPostData), ticker, accessKey); err != nil {
+			fmt.Printf("Error posting sentences %d to %d: %v\n", start+1, end, err)
+			failed++
+		}
+		if end == len(sentences) {
+			break
 		}
 	}
-	if currentSentence.Len() > 0 {
-		sentences = append(sentences, strings.TrimSpace(currentSentence.String()))
-	}
-	for _, sentence := range sentences {
-		fmt.Println(sentence)
-	}
-	return sentences
-}
-
-// isEndOfSentence checks if a word ends with a sentence delimiter.
-func isEndOfSentence(word string) bool {
-	// Check for standard sentence endings
-	if strings.HasSuffix(word, ".") || strings.HasSuffix(word, "!") || strings.HasSuffix(word, "?") {
-		// Check for ellipses and common abbreviations
-		isEllipsis := strings.HasSuffix(word, "...")
-		isAbbreviation := regexp.MustCompile(`(?i)\b(?:[a-z]\.|[dr|mr|mrs|ms|prof|inc|llc|llp|ie|eg]\.?)$`).MatchString(word)
-		return !isEllipsis && !isAbbreviation
+	if failed > 0 {
+		os.Exit(1)
 	}
-	return false
 }
 
-func postFinancialData(dataValue string, eventSequenceArray []string, passHash string) string {
+func postFinancialData(dataValue string, ticker string, passHash string) error {
 	// sends request to the ingestor running locally
-	url := "http://0.0.0.0:6001/ingestor"
+	endpoint := "http://0.0.0.0:6001/ingestor"
 	bearerToken := passHash
-	infoData := dataValue
 
-	// Create payload as bytes
-	payload := []byte(fmt.Sprintf("info=%s", infoData))
+	// Create the multipart form payload, the ingestor reads up to 32 MB of it
+	var payload bytes.Buffer
+	form := multipart.NewWriter(&payload)
+	form.WriteField("info", dataValue)
+	form.WriteField("ticker", ticker)
+	if err := form.Close(); err != nil {
+		return err
+	}
 
 	// Create HTTP client
 	client := &http.Client{}
 
 	// Create POST request
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(payload))
+	req, err := http.NewRequest("POST", endpoint, &payload)
 	if err != nil {
-		fmt.Println("Error creating request:", err)
-		return ""
+		return err
 	}
 
 	// Set Authorization header
 	req.Header.Set("Authorization", "Bearer "+bearerToken)
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Content-Type", form.FormDataContentType())
 
 	// Send the request
 	resp, err := client.Do(req)
 	if err != nil {
-		fmt.Println("Error sending request:", err)
-		return ""
+		return err
 	}
 	defer resp.Body.Close()
 
 	// Read response
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		fmt.Println("Error reading response:", err)
-		return ""
+		return err
 	}
 
 	fmt.Println("Response:", string(respBody))
-	return string(respBody)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("ingestor returned %s", resp.Status)
+	}
+	return nil
 }
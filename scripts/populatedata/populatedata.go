@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"flag"
 	"fmt"
@@ -10,14 +11,13 @@ import (
 	"strings"
 	"sync"
 
+	"fineas/pkg/registry"
+
 	"github.com/joho/godotenv"
+	polygon "github.com/polygon-io/client-go/rest"
+	"github.com/polygon-io/client-go/rest/models"
 )
 
-type Ticker struct {
-	Value string `json:"value"`
-	Label string `json:"label"`
-}
-
 func main() {
 	err := godotenv.Load("../../.env")
 	if err != nil {
@@ -27,8 +27,8 @@ func main() {
 	KB_WRITE_KEY := os.Getenv("KB_WRITE_KEY")
 
 	// Load tickers from JSON file
-	var tickers []Ticker
-	file, err := os.ReadFile("../../utils/data/tickerslist.json")
+	var tickers []registry.Ticker
+	file, err := os.ReadFile(registry.DefaultPath)
 	if err != nil {
 		log.Fatalf("Error reading tickers list: %v", err)
 	}
@@ -48,17 +48,21 @@ func main() {
 	executeMRWRITE := flag.Bool("mrwrite", false, "Set to true to execute writes for market research")
 	executeKBWRITE := flag.Bool("kbwrite", false, "Set to true to execute writes to knowledge base")
 	batchSize := flag.Int("batchsize", 10, "Number of tickers per batch")
+	enrich := flag.Bool("enrich", false, "Set to true to fill SIC codes and sectors of the tickers list from Polygon")
 	flag.Parse()
 
 	if *manualExecution {
+		if *enrich {
+			enrichTickers(tickers, os.Getenv("API_KEY"))
+		}
 		if *executeMRWRITE {
 			sendBatches(stockTickers, MR_WRITE_KEY, *batchSize)
 		}
 		if *executeKBWRITE {
 			sendBatches(stockTickers, KB_WRITE_KEY, *batchSize)
 		}
-		if !*executeKBWRITE && !*executeMRWRITE {
-			log.Println("No action specified for manual execution. Please set either -kbwrite or -mrwrite.")
+		if !*executeKBWRITE && !*executeMRWRITE && !*enrich {
+			log.Println("No action specified for manual execution. Please set either -kbwrite, -mrwrite or -enrich.")
 		}
 	} else {
 		log.Println("Automated execution is not supported in this script.")
@@ -111,3 +115,40 @@ func createBatches(tickers []string, size int) [][]string {
 	batches = append(batches, tickers)
 	return batches
 }
+
+// fills the SIC code and sector of every ticker missing one from Polygon
+// ticker details and writes the tickers list back, peer selection matches
+// on them
+func enrichTickers(tickers []registry.Ticker, apiKey string) {
+	c := polygon.New(apiKey)
+	enriched := 0
+	for i, ticker := range tickers {
+		// crypto, forex and index tickers have no SIC code
+		if ticker.SICCode != "" || strings.Contains(ticker.Value, ":") {
+			continue
+		}
+		params := models.GetTickerDetailsParams{Ticker: strings.ToUpper(ticker.Value)}
+		details, err := c.GetTickerDetails(context.Background(), &params)
+		if err != nil {
+			log.Printf("Error fetching ticker details for %s: %v", ticker.Value, err)
+			continue
+		}
+		if details.Results.SICCode == "" {
+			continue
+		}
+		tickers[i].SICCode = details.Results.SICCode
+		if tickers[i].Sector == "" {
+			tickers[i].Sector = registry.SICDivision(details.Results.SICCode)
+		}
+		enriched++
+	}
+
+	data, err := json.MarshalIndent(tickers, "", "  ")
+	if err != nil {
+		log.Fatalf("Error marshaling tickers: %v", err)
+	}
+	if err := os.WriteFile(registry.DefaultPath, data, 0o644); err != nil {
+		log.Fatalf("Error writing tickers list: %v", err)
+	}
+	log.Printf("Filled SIC codes of %d tickers", enriched)
+}